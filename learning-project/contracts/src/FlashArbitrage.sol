@@ -72,38 +72,78 @@ contract FlashArbitrage {
         uint256 amountIn,
         uint256 minProfitBps
     ) external onlyOwner returns (uint256 finalAmount) {
+        uint256[3] memory minAmountsOut;
+        return _executeArbitrage(_addresses(routers), _addresses(tokens), amountIn, _amounts(minAmountsOut), minProfitBps);
+    }
+    
+    /// @notice Execute triangle arbitrage with a minimum output per swap
+    /// @notice 执行带每跳最小输出保护的三角套利
+    /// @dev Each swap reverts if it returns less than its minimum, so a trade
+    /// @dev that would lose money after gas reverts instead of landing
+    /// @dev 任一交易输出低于最小值即回滚，避免扣除 gas 后亏损的交易上链
+    /// @param minAmountsOut Minimum output of each swap [tokenB, tokenC, tokenA]
+    /// @param minAmountsOut 每次交易的最小输出 [代币B, 代币C, 代币A]
+    function executeArbitrageWithMinOut(
+        address[3] calldata routers,
+        address[3] calldata tokens,
+        uint256 amountIn,
+        uint256[3] calldata minAmountsOut,
+        uint256 minProfitBps
+    ) external onlyOwner returns (uint256 finalAmount) {
+        return _executeArbitrage(_addresses(routers), _addresses(tokens), amountIn, _amounts(minAmountsOut), minProfitBps);
+    }
+
+    /// @notice Execute a cycle of any length (e.g. a 2-hop cross-DEX trade)
+    /// @notice 执行任意长度的循环套利（例如 2 跳跨 DEX 交易）
+    /// @dev Swap i trades tokens[i] -> tokens[i+1] on routers[i]; the last swap returns to tokens[0]
+    /// @dev 第 i 次交易在 routers[i] 上将 tokens[i] 换成 tokens[i+1]，最后一次交易换回 tokens[0]
+    /// @param routers DEX router of each swap
+    /// @param routers 每次交易的 DEX 路由器
+    /// @param tokens Tokens of the cycle, starting token first
+    /// @param tokens 循环中的代币，起始代币在前
+    /// @param minAmountsOut Minimum output of each swap (0 accepts any amount)
+    /// @param minAmountsOut 每次交易的最小输出（0 表示接受任意数量）
+    function executeArbitragePath(
+        address[] calldata routers,
+        address[] calldata tokens,
+        uint256 amountIn,
+        uint256[] calldata minAmountsOut,
+        uint256 minProfitBps
+    ) external onlyOwner returns (uint256 finalAmount) {
+        return _executeArbitrage(routers, tokens, amountIn, minAmountsOut, minProfitBps);
+    }
+
+    /// @notice Internal cycle arbitrage
+    /// @notice 内部循环套利函数
+    function _executeArbitrage(
+        address[] memory routers,
+        address[] memory tokens,
+        uint256 amountIn,
+        uint256[] memory minAmountsOut,
+        uint256 minProfitBps
+    ) internal returns (uint256 finalAmount) {
+        uint256 hops = tokens.length;
+        require(hops >= 2 && routers.length == hops && minAmountsOut.length == hops, "Invalid path");
+        // 路径无效
+
         // Record initial balance / 记录初始余额
         uint256 initialBalance = IERC20(tokens[0]).balanceOf(address(this));
         require(initialBalance >= amountIn, "Insufficient balance");
         // 余额不足
-        
-        // Step 1: Swap tokenA -> tokenB
-        // 步骤1: 交易 代币A -> 代币B
-        uint256 amountB = _swap(
-            routers[0],
-            tokens[0],
-            tokens[1],
-            amountIn
-        );
-        
-        // Step 2: Swap tokenB -> tokenC
-        // 步骤2: 交易 代币B -> 代币C
-        uint256 amountC = _swap(
-            routers[1],
-            tokens[1],
-            tokens[2],
-            amountB
-        );
-        
-        // Step 3: Swap tokenC -> tokenA (complete the loop)
-        // 步骤3: 交易 代币C -> 代币A (闭环)
-        finalAmount = _swap(
-            routers[2],
-            tokens[2],
-            tokens[0],
-            amountC
-        );
-        
+
+        // Swap along the cycle, the last hop back to the starting token
+        // 沿循环依次交易，最后一跳换回起始代币
+        finalAmount = amountIn;
+        for (uint256 i = 0; i < hops; i++) {
+            finalAmount = _swap(
+                routers[i],
+                tokens[i],
+                tokens[(i + 1) % hops],
+                finalAmount,
+                minAmountsOut[i]
+            );
+        }
+
         // Calculate profit / 计算利润
         require(finalAmount > amountIn, "No profit");
         // 无利润
@@ -127,7 +167,8 @@ contract FlashArbitrage {
         address router,
         address tokenIn,
         address tokenOut,
-        uint256 amountIn
+        uint256 amountIn,
+        uint256 amountOutMin
     ) internal returns (uint256 amountOut) {
         // Approve router to spend tokens / 授权路由器使用代币
         IERC20(tokenIn).approve(router, amountIn);
@@ -140,8 +181,8 @@ contract FlashArbitrage {
         // Execute swap / 执行交易
         uint256[] memory amounts = IUniswapV2Router(router).swapExactTokensForTokens(
             amountIn,
-            0, // Accept any amount (can be optimized with slippage protection)
-               // 接受任意数量（可优化为滑点保护）
+            amountOutMin, // Slippage protection (0 accepts any amount)
+                          // 滑点保护（0 表示接受任意数量）
             path,
             address(this),
             block.timestamp + 300 // 5 minute deadline / 5分钟有效期
@@ -149,7 +190,25 @@ contract FlashArbitrage {
         
         return amounts[1];
     }
-    
+
+    /// @notice Copy a fixed 3-address array into a dynamic array
+    /// @notice 将固定长度的 3 地址数组复制为动态数组
+    function _addresses(address[3] memory values) internal pure returns (address[] memory result) {
+        result = new address[](3);
+        for (uint256 i = 0; i < 3; i++) {
+            result[i] = values[i];
+        }
+    }
+
+    /// @notice Copy a fixed 3-amount array into a dynamic array
+    /// @notice 将固定长度的 3 数额数组复制为动态数组
+    function _amounts(uint256[3] memory values) internal pure returns (uint256[] memory result) {
+        result = new uint256[](3);
+        for (uint256 i = 0; i < 3; i++) {
+            result[i] = values[i];
+        }
+    }
+
     /// @notice Withdraw profits
     /// @notice 提取利润
     /// @param token Token address to withdraw
@@ -162,6 +221,21 @@ contract FlashArbitrage {
         IERC20(token).transfer(owner, balance);
         emit ProfitWithdrawn(token, balance);
     }
+
+    /// @notice Withdraw part of a token balance (e.g. inventory above its target)
+    /// @notice 提取部分代币余额（例如超出目标的库存）
+    /// @param token Token address to withdraw
+    /// @param token 要提取的代币地址
+    /// @param amount Amount to withdraw
+    /// @param amount 提取数量
+    function withdraw(address token, uint256 amount) external onlyOwner {
+        uint256 balance = IERC20(token).balanceOf(address(this));
+        require(amount > 0 && amount <= balance, "Invalid amount");
+        // 提取数量无效
+
+        IERC20(token).transfer(owner, amount);
+        emit ProfitWithdrawn(token, amount);
+    }
     
     /// @notice Withdraw ETH (if any)
     /// @notice 提取 ETH（如有）
@@ -17,6 +17,25 @@ interface IPool {
     ) external;
 }
 
+// Balancer Vault Flash Loan Interface
+// Balancer 金库闪电贷接口
+interface IBalancerVault {
+    function flashLoan(
+        address recipient,
+        address[] memory tokens,
+        uint256[] memory amounts,
+        bytes memory userData
+    ) external;
+}
+
+// Uniswap V2 Pair Interface (flash swaps)
+// Uniswap V2 交易对接口（闪电兑换）
+interface IUniswapV2Pair {
+    function token0() external view returns (address);
+    function token1() external view returns (address);
+    function swap(uint amount0Out, uint amount1Out, address to, bytes calldata data) external;
+}
+
 // Uniswap V2 Router Interface
 // Uniswap V2 路由器接口
 interface IUniswapV2Router {
@@ -42,8 +61,8 @@ interface IERC20 {
 
 /// @title FlashLoanArbitrage - Flash Loan Triangle Arbitrage Contract
 /// @title FlashLoanArbitrage - 闪电贷三角套利合约
-/// @notice Executes arbitrage using Aave flash loans (no upfront capital needed)
-/// @notice 使用 Aave 闪电贷执行套利（无需前期资金）
+/// @notice Executes arbitrage on Aave or Balancer flash loans or Uniswap V2 flash swaps (no upfront capital needed)
+/// @notice 使用 Aave / Balancer 闪电贷或 Uniswap V2 闪电兑换执行套利（无需前期资金）
 /// @dev Borrows funds, executes arbitrage, repays loan + fee in a single transaction
 /// @dev 借入资金、执行套利、归还贷款+手续费，全部在单笔交易中完成
 contract FlashLoanArbitrage {
@@ -53,6 +72,10 @@ contract FlashLoanArbitrage {
     // Aave Pool Addresses Provider / Aave 池地址提供者
     IPoolAddressesProvider public immutable ADDRESSES_PROVIDER;
     IPool public immutable POOL;
+
+    // Lender of the Balancer flash loan or flash swap in progress, the only caller its callback accepts
+    // 进行中的 Balancer 闪电贷或闪电兑换的出借方，回调只接受它的调用
+    address private _lender;
     
     // Events / 事件
     event ArbitrageExecuted(
@@ -101,13 +124,59 @@ contract FlashLoanArbitrage {
         address[3] calldata tokens,
         uint256 minProfitBps
     ) external onlyOwner {
-        // Ensure first token matches borrowed asset
-        // 确保第一个代币与借入资产匹配
-        require(tokens[0] == asset, "First token must match borrowed asset");
-        
-        // Encode parameters for callback
-        // 编码回调参数
-        bytes memory params = abi.encode(routers, tokens, minProfitBps);
+        uint256[3] memory minAmountsOut;
+        _flashLoan(asset, loanAmount, _addresses(routers), _addresses(tokens), _amounts(minAmountsOut), minProfitBps);
+    }
+    
+    /// @notice Execute flash loan arbitrage with a minimum output per swap
+    /// @notice 执行带每跳最小输出保护的闪电贷套利
+    /// @dev Each swap reverts if it returns less than its minimum
+    /// @dev 任一交易输出低于最小值即回滚
+    /// @param minAmountsOut Minimum output of each swap [tokenB, tokenC, tokenA]
+    /// @param minAmountsOut 每次交易的最小输出 [代币B, 代币C, 代币A]
+    function executeFlashLoanArbitrageWithMinOut(
+        address asset,
+        uint256 loanAmount,
+        address[3] calldata routers,
+        address[3] calldata tokens,
+        uint256[3] calldata minAmountsOut,
+        uint256 minProfitBps
+    ) external onlyOwner {
+        _flashLoan(asset, loanAmount, _addresses(routers), _addresses(tokens), _amounts(minAmountsOut), minProfitBps);
+    }
+
+    /// @notice Execute flash loan arbitrage over a cycle of any length (e.g. a 2-hop cross-DEX trade)
+    /// @notice 对任意长度的循环执行闪电贷套利（例如 2 跳跨 DEX 交易）
+    /// @dev Swap i trades tokens[i] -> tokens[i+1] on routers[i]; the last swap returns to tokens[0]
+    /// @dev 第 i 次交易在 routers[i] 上将 tokens[i] 换成 tokens[i+1]，最后一次交易换回 tokens[0]
+    /// @param routers DEX router of each swap
+    /// @param routers 每次交易的 DEX 路由器
+    /// @param tokens Tokens of the cycle, borrowed asset first
+    /// @param tokens 循环中的代币，借入资产在前
+    /// @param minAmountsOut Minimum output of each swap (0 accepts any amount)
+    /// @param minAmountsOut 每次交易的最小输出（0 表示接受任意数量）
+    function executeFlashLoanArbitragePath(
+        address asset,
+        uint256 loanAmount,
+        address[] calldata routers,
+        address[] calldata tokens,
+        uint256[] calldata minAmountsOut,
+        uint256 minProfitBps
+    ) external onlyOwner {
+        _flashLoan(asset, loanAmount, routers, tokens, minAmountsOut, minProfitBps);
+    }
+
+    /// @notice Initiate the flash loan
+    /// @notice 发起闪电贷
+    function _flashLoan(
+        address asset,
+        uint256 loanAmount,
+        address[] memory routers,
+        address[] memory tokens,
+        uint256[] memory minAmountsOut,
+        uint256 minProfitBps
+    ) internal {
+        bytes memory params = _encodeParams(asset, routers, tokens, minAmountsOut, minProfitBps);
         
         // Initiate flash loan
         // 发起闪电贷
@@ -120,6 +189,167 @@ contract FlashLoanArbitrage {
         );
     }
     
+    /// @notice Execute arbitrage on a Balancer flash loan (no fee)
+    /// @notice 使用 Balancer 闪电贷执行套利（无手续费）
+    /// @dev Arbitrage executed in the receiveFlashLoan callback
+    /// @dev 套利在 receiveFlashLoan 回调中执行
+    /// @param vault Balancer Vault to borrow from
+    /// @param vault 借款的 Balancer 金库
+    function executeBalancerFlashLoan(
+        address vault,
+        address asset,
+        uint256 loanAmount,
+        address[3] calldata routers,
+        address[3] calldata tokens,
+        uint256 minProfitBps
+    ) external onlyOwner {
+        uint256[3] memory minAmountsOut;
+        _balancerFlashLoan(vault, asset, loanAmount, _addresses(routers), _addresses(tokens), _amounts(minAmountsOut), minProfitBps);
+    }
+
+    /// @notice Execute arbitrage on a Balancer flash loan with a minimum output per swap
+    /// @notice 使用 Balancer 闪电贷执行带每跳最小输出保护的套利
+    function executeBalancerFlashLoanWithMinOut(
+        address vault,
+        address asset,
+        uint256 loanAmount,
+        address[3] calldata routers,
+        address[3] calldata tokens,
+        uint256[3] calldata minAmountsOut,
+        uint256 minProfitBps
+    ) external onlyOwner {
+        _balancerFlashLoan(vault, asset, loanAmount, _addresses(routers), _addresses(tokens), _amounts(minAmountsOut), minProfitBps);
+    }
+
+    /// @notice Execute arbitrage over a cycle of any length on a Balancer flash loan
+    /// @notice 使用 Balancer 闪电贷对任意长度的循环执行套利
+    function executeBalancerFlashLoanPath(
+        address vault,
+        address asset,
+        uint256 loanAmount,
+        address[] calldata routers,
+        address[] calldata tokens,
+        uint256[] calldata minAmountsOut,
+        uint256 minProfitBps
+    ) external onlyOwner {
+        _balancerFlashLoan(vault, asset, loanAmount, routers, tokens, minAmountsOut, minProfitBps);
+    }
+
+    /// @notice Execute arbitrage on a Uniswap V2 flash swap
+    /// @notice 使用 Uniswap V2 闪电兑换执行套利
+    /// @dev Borrows asset from the pair, arbitrage executed in the uniswapV2Call callback.
+    ///      The pair is locked during the swap: the cycle must not trade through it.
+    /// @dev 从交易对借出资产，套利在 uniswapV2Call 回调中执行。兑换期间交易对被锁定：循环不能经过该交易对
+    /// @param pair Uniswap V2 style pair (0.3% fee) holding asset
+    /// @param pair 持有借入资产的 Uniswap V2 类交易对（0.3% 手续费）
+    function executeFlashSwap(
+        address pair,
+        address asset,
+        uint256 loanAmount,
+        address[3] calldata routers,
+        address[3] calldata tokens,
+        uint256 minProfitBps
+    ) external onlyOwner {
+        uint256[3] memory minAmountsOut;
+        _flashSwap(pair, asset, loanAmount, _addresses(routers), _addresses(tokens), _amounts(minAmountsOut), minProfitBps);
+    }
+
+    /// @notice Execute arbitrage on a Uniswap V2 flash swap with a minimum output per swap
+    /// @notice 使用 Uniswap V2 闪电兑换执行带每跳最小输出保护的套利
+    function executeFlashSwapWithMinOut(
+        address pair,
+        address asset,
+        uint256 loanAmount,
+        address[3] calldata routers,
+        address[3] calldata tokens,
+        uint256[3] calldata minAmountsOut,
+        uint256 minProfitBps
+    ) external onlyOwner {
+        _flashSwap(pair, asset, loanAmount, _addresses(routers), _addresses(tokens), _amounts(minAmountsOut), minProfitBps);
+    }
+
+    /// @notice Execute arbitrage over a cycle of any length on a Uniswap V2 flash swap
+    /// @notice 使用 Uniswap V2 闪电兑换对任意长度的循环执行套利
+    function executeFlashSwapPath(
+        address pair,
+        address asset,
+        uint256 loanAmount,
+        address[] calldata routers,
+        address[] calldata tokens,
+        uint256[] calldata minAmountsOut,
+        uint256 minProfitBps
+    ) external onlyOwner {
+        _flashSwap(pair, asset, loanAmount, routers, tokens, minAmountsOut, minProfitBps);
+    }
+
+    /// @notice Validate a cycle and encode it for the loan callback
+    /// @notice 校验循环并编码为借款回调参数
+    function _encodeParams(
+        address asset,
+        address[] memory routers,
+        address[] memory tokens,
+        uint256[] memory minAmountsOut,
+        uint256 minProfitBps
+    ) internal pure returns (bytes memory) {
+        uint256 hops = tokens.length;
+        require(hops >= 2 && routers.length == hops && minAmountsOut.length == hops, "Invalid path");
+        // 路径无效
+
+        // Ensure first token matches borrowed asset
+        // 确保第一个代币与借入资产匹配
+        require(tokens[0] == asset, "First token must match borrowed asset");
+
+        return abi.encode(routers, tokens, minAmountsOut, minProfitBps);
+    }
+
+    /// @notice Initiate the Balancer flash loan
+    /// @notice 发起 Balancer 闪电贷
+    function _balancerFlashLoan(
+        address vault,
+        address asset,
+        uint256 loanAmount,
+        address[] memory routers,
+        address[] memory tokens,
+        uint256[] memory minAmountsOut,
+        uint256 minProfitBps
+    ) internal {
+        bytes memory params = _encodeParams(asset, routers, tokens, minAmountsOut, minProfitBps);
+
+        address[] memory assets = new address[](1);
+        assets[0] = asset;
+        uint256[] memory amounts = new uint256[](1);
+        amounts[0] = loanAmount;
+
+        _lender = vault;
+        IBalancerVault(vault).flashLoan(address(this), assets, amounts, params);
+        _lender = address(0);
+    }
+
+    /// @notice Initiate the flash swap: borrow asset from the pair
+    /// @notice 发起闪电兑换：从交易对借出资产
+    function _flashSwap(
+        address pair,
+        address asset,
+        uint256 loanAmount,
+        address[] memory routers,
+        address[] memory tokens,
+        uint256[] memory minAmountsOut,
+        uint256 minProfitBps
+    ) internal {
+        bytes memory params = _encodeParams(asset, routers, tokens, minAmountsOut, minProfitBps);
+
+        address token0 = IUniswapV2Pair(pair).token0();
+        require(asset == token0 || asset == IUniswapV2Pair(pair).token1(), "Pair does not hold asset");
+
+        _lender = pair;
+        if (asset == token0) {
+            IUniswapV2Pair(pair).swap(loanAmount, 0, address(this), params);
+        } else {
+            IUniswapV2Pair(pair).swap(0, loanAmount, address(this), params);
+        }
+        _lender = address(0);
+    }
+
     /// @notice Flash loan callback - Aave calls this after transferring loan
     /// @notice 闪电贷回调 - Aave 在转账借款后调用此函数
     /// @dev MUST repay loan + premium before returning, or transaction reverts
@@ -146,18 +376,86 @@ contract FlashLoanArbitrage {
         require(msg.sender == address(POOL), "Caller must be Pool");
         require(initiator == address(this), "Initiator must be this contract");
         
+        uint256 amountOwed = _arbitrageOnLoan(asset, amount, premium, params);
+        
+        // Approve Pool to pull the owed amount
+        // 授权池扣除应还金额
+        IERC20(asset).approve(address(POOL), amountOwed);
+        
+        return true;
+    }
+
+    /// @notice Balancer flash loan callback - the Vault calls this after transferring the loan
+    /// @notice Balancer 闪电贷回调 - 金库在转账借款后调用此函数
+    /// @dev The Vault checks its balance afterwards: the loan is repaid by transfer
+    /// @dev 金库随后检查余额：通过转账归还贷款
+    function receiveFlashLoan(
+        address[] memory tokens,
+        uint256[] memory amounts,
+        uint256[] memory feeAmounts,
+        bytes memory userData
+    ) external {
+        // Security: only the Vault this contract is borrowing from
+        // 安全检查：仅本合约正在借款的金库可调用
+        require(msg.sender == _lender, "Caller must be lender");
+        require(tokens.length == 1, "Single asset loans only");
+
+        uint256 amountOwed = _arbitrageOnLoan(tokens[0], amounts[0], feeAmounts[0], userData);
+        IERC20(tokens[0]).transfer(msg.sender, amountOwed);
+    }
+
+    /// @notice Flash swap callback - the pair calls this after transferring the loan
+    /// @notice 闪电兑换回调 - 交易对在转账借款后调用此函数
+    /// @dev Repaid in the borrowed token: amount / 0.997, rounded up (0.3% pair fee)
+    /// @dev 以借入代币归还：金额 / 0.997，向上取整（交易对手续费 0.3%）
+    function uniswapV2Call(
+        address sender,
+        uint256 amount0,
+        uint256 amount1,
+        bytes calldata data
+    ) external {
+        // Security: only the pair this contract is borrowing from
+        // 安全检查：仅本合约正在借款的交易对可调用
+        require(msg.sender == _lender, "Caller must be lender");
+        require(sender == address(this), "Initiator must be this contract");
+
+        address asset = IUniswapV2Pair(msg.sender).token0();
+        uint256 amount = amount0;
+        if (amount0 == 0) {
+            asset = IUniswapV2Pair(msg.sender).token1();
+            amount = amount1;
+        }
+        uint256 fee = (amount * 3) / 997 + 1;
+
+        uint256 amountOwed = _arbitrageOnLoan(asset, amount, fee, data);
+        IERC20(asset).transfer(msg.sender, amountOwed);
+    }
+
+    /// @notice Execute the arbitrage on borrowed funds and check the profit after the fee
+    /// @notice 用借入资金执行套利，并检查扣除手续费后的利润
+    /// @return amountOwed Loan plus fee, repaid by the calling callback / 贷款加手续费，由回调归还
+    function _arbitrageOnLoan(
+        address asset,
+        uint256 amount,
+        uint256 fee,
+        bytes memory params
+    ) internal returns (uint256 amountOwed) {
         // Decode parameters
         // 解码参数
-        (address[3] memory routers, address[3] memory tokens, uint256 minProfitBps) = 
-            abi.decode(params, (address[3], address[3], uint256));
+        (
+            address[] memory routers,
+            address[] memory tokens,
+            uint256[] memory minAmountsOut,
+            uint256 minProfitBps
+        ) = abi.decode(params, (address[], address[], uint256[], uint256));
         
-        // Execute triangle arbitrage
-        // 执行三角套利
-        uint256 finalAmount = _executeArbitrage(routers, tokens, amount);
+        // Execute cycle arbitrage
+        // 执行循环套利
+        uint256 finalAmount = _executeArbitrage(routers, tokens, amount, minAmountsOut);
         
-        // Calculate total amount owed (loan + premium)
+        // Calculate total amount owed (loan + fee)
         // 计算应还总额（贷款 + 手续费）
-        uint256 amountOwed = amount + premium;
+        amountOwed = amount + fee;
         
         // Verify profit after repaying loan
         // 验证归还贷款后的利润
@@ -169,35 +467,24 @@ contract FlashLoanArbitrage {
         uint256 minProfit = (amount * minProfitBps) / 10000;
         require(profit >= minProfit, "Profit below minimum");
         
-        // Approve Pool to pull the owed amount
-        // 授权池扣除应还金额
-        IERC20(asset).approve(address(POOL), amountOwed);
-        
-        emit ArbitrageExecuted(asset, amount, profit, premium);
-        
-        return true;
+        emit ArbitrageExecuted(asset, amount, profit, fee);
     }
     
-    /// @notice Execute triangle arbitrage
-    /// @notice 执行三角套利
-    /// @dev Internal function to perform 3-step arbitrage
-    /// @dev 执行3步套利的内部函数
+    /// @notice Execute cycle arbitrage
+    /// @notice 执行循环套利
+    /// @dev Internal function swapping along the cycle, the last hop back to tokens[0]
+    /// @dev 沿循环依次交易的内部函数，最后一跳换回 tokens[0]
     function _executeArbitrage(
-        address[3] memory routers,
-        address[3] memory tokens,
-        uint256 amountIn
+        address[] memory routers,
+        address[] memory tokens,
+        uint256 amountIn,
+        uint256[] memory minAmountsOut
     ) internal returns (uint256 finalAmount) {
-        // Step 1: Swap tokenA -> tokenB
-        // 步骤1: 交易 代币A -> 代币B
-        uint256 amountB = _swap(routers[0], tokens[0], tokens[1], amountIn);
-        
-        // Step 2: Swap tokenB -> tokenC
-        // 步骤2: 交易 代币B -> 代币C
-        uint256 amountC = _swap(routers[1], tokens[1], tokens[2], amountB);
-        
-        // Step 3: Swap tokenC -> tokenA (complete the loop)
-        // 步骤3: 交易 代币C -> 代币A (闭环)
-        finalAmount = _swap(routers[2], tokens[2], tokens[0], amountC);
+        uint256 hops = tokens.length;
+        finalAmount = amountIn;
+        for (uint256 i = 0; i < hops; i++) {
+            finalAmount = _swap(routers[i], tokens[i], tokens[(i + 1) % hops], finalAmount, minAmountsOut[i]);
+        }
         
         return finalAmount;
     }
@@ -208,7 +495,8 @@ contract FlashLoanArbitrage {
         address router,
         address tokenIn,
         address tokenOut,
-        uint256 amountIn
+        uint256 amountIn,
+        uint256 amountOutMin
     ) internal returns (uint256 amountOut) {
         // Approve router to spend tokens
         // 授权路由器使用代币
@@ -224,8 +512,8 @@ contract FlashLoanArbitrage {
         // 执行交易
         uint256[] memory amounts = IUniswapV2Router(router).swapExactTokensForTokens(
             amountIn,
-            0, // Accept any amount (production should use slippage protection)
-               // 接受任意数量（生产环境应使用滑点保护）
+            amountOutMin, // Slippage protection (0 accepts any amount)
+                          // 滑点保护（0 表示接受任意数量）
             path,
             address(this),
             block.timestamp + 300 // 5 minute deadline / 5分钟有效期
@@ -233,6 +521,24 @@ contract FlashLoanArbitrage {
         
         return amounts[1];
     }
+
+    /// @notice Copy a fixed 3-address array into a dynamic array
+    /// @notice 将固定长度的 3 地址数组复制为动态数组
+    function _addresses(address[3] memory values) internal pure returns (address[] memory result) {
+        result = new address[](3);
+        for (uint256 i = 0; i < 3; i++) {
+            result[i] = values[i];
+        }
+    }
+
+    /// @notice Copy a fixed 3-amount array into a dynamic array
+    /// @notice 将固定长度的 3 数额数组复制为动态数组
+    function _amounts(uint256[3] memory values) internal pure returns (uint256[] memory result) {
+        result = new uint256[](3);
+        for (uint256 i = 0; i < 3; i++) {
+            result[i] = values[i];
+        }
+    }
     
     /// @notice Simulate arbitrage profit (off-chain view function)
     /// @notice 模拟套利利润（链下视图函数）
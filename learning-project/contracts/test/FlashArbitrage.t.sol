@@ -201,7 +201,56 @@ contract FlashArbitrageTest is Test {
             1000 // 10% minimum profit (unrealistic)
         );
     }
-    
+
+    /// @notice Test per-swap minimum outputs
+    /// @notice 测试每跳最小输出保护
+    function testMinAmountsOut() public {
+        address[3] memory routers = [
+            address(router1),
+            address(router2),
+            address(router3)
+        ];
+
+        address[3] memory tokens = [
+            address(tokenA),
+            address(tokenB),
+            address(tokenC)
+        ];
+
+        // Exact expected outputs pass / 恰好等于预期输出时成功
+        uint256[3] memory minAmountsOut = [uint256(1.01 ether), 1.0302 ether, 1.040502 ether];
+        uint256 finalAmount = arbitrage.executeArbitrageWithMinOut(routers, tokens, 1 ether, minAmountsOut, 0);
+        assertEq(finalAmount, 1.040502 ether, "Should return the final output");
+
+        // Second swap below its minimum reverts / 第二跳低于最小输出时回滚
+        minAmountsOut[1] = 1.0303 ether;
+        vm.expectRevert("Insufficient output");
+        arbitrage.executeArbitrageWithMinOut(routers, tokens, 1 ether, minAmountsOut, 0);
+    }
+
+    /// @notice Test a 2-hop cross-DEX cycle through the variable-length entry point
+    /// @notice 测试通过变长入口执行的 2 跳跨 DEX 循环
+    function testTwoHopPath() public {
+        // TKA -> TKB on router1, TKB -> TKA on router3
+        // 在 router1 上 TKA -> TKB，在 router3 上 TKB -> TKA
+        address[] memory routers = new address[](2);
+        routers[0] = address(router1);
+        routers[1] = address(router3);
+
+        address[] memory tokens = new address[](2);
+        tokens[0] = address(tokenA);
+        tokens[1] = address(tokenB);
+
+        uint256[] memory minAmountsOut = new uint256[](2);
+        uint256 finalAmount = arbitrage.executeArbitragePath(routers, tokens, 1 ether, minAmountsOut, 100);
+        assertEq(finalAmount, 1.0201 ether, "Should return the final output");
+
+        // Mismatched lengths revert / 长度不一致时回滚
+        uint256[] memory shortMinOut = new uint256[](1);
+        vm.expectRevert("Invalid path");
+        arbitrage.executeArbitragePath(routers, tokens, 1 ether, shortMinOut, 0);
+    }
+
     /// @notice Test profit withdrawal
     /// @notice 测试利润提取
     function testWithdrawProfit() public {
@@ -214,4 +263,19 @@ contract FlashArbitrageTest is Test {
         assertEq(ownerBalanceAfter - ownerBalanceBefore, contractBalance, "Should withdraw all");
         assertEq(tokenA.balanceOf(address(arbitrage)), 0, "Contract should be empty");
     }
+
+    /// @notice Test partial withdrawal
+    /// @notice 测试部分提取
+    function testWithdrawPartial() public {
+        uint256 ownerBalanceBefore = tokenA.balanceOf(owner);
+
+        arbitrage.withdraw(address(tokenA), 4 ether);
+
+        assertEq(tokenA.balanceOf(owner) - ownerBalanceBefore, 4 ether, "Should withdraw the amount");
+        assertEq(tokenA.balanceOf(address(arbitrage)), 6 ether, "Contract should keep the rest");
+
+        // More than the balance reverts / 超过余额时回滚
+        vm.expectRevert("Invalid amount");
+        arbitrage.withdraw(address(tokenA), 7 ether);
+    }
 }
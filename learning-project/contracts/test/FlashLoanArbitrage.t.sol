@@ -12,6 +12,8 @@ contract FlashLoanArbitrageTest is Test {
     // Mock contracts / 模拟合约
     MockPoolAddressesProvider public addressesProvider;
     MockPool public pool;
+    MockBalancerVault public vault;
+    MockPair public pair;
     MockERC20 public tokenA;
     MockERC20 public tokenB;
     MockERC20 public tokenC;
@@ -36,6 +38,10 @@ contract FlashLoanArbitrageTest is Test {
         pool = new MockPool();
         addressesProvider = new MockPoolAddressesProvider(address(pool));
         
+        // Deploy mock Balancer Vault and TKA/TKB pair / 部署模拟 Balancer 金库和 TKA/TKB 交易对
+        vault = new MockBalancerVault();
+        pair = new MockPair(address(tokenA), address(tokenB));
+        
         // Deploy arbitrage contract / 部署套利合约
         arbitrage = new FlashLoanArbitrage(address(addressesProvider));
         
@@ -56,6 +62,9 @@ contract FlashLoanArbitrageTest is Test {
         
         // Fund mock pool with tokens for flash loans / 为模拟池注入代币用于闪电贷
         tokenA.mint(address(pool), 1000000 * 1e18);
+        tokenA.mint(address(vault), 1000000 * 1e18);
+        tokenA.mint(address(pair), 1000000 * 1e18);
+        tokenB.mint(address(pair), 1000000 * 1e18);
         
         // Fund routers with liquidity / 为路由器注入流动性
         tokenA.mint(address(router1), 1000000 * 1e18);
@@ -190,6 +199,108 @@ contract FlashLoanArbitrageTest is Test {
         );
     }
     
+    /// @notice Test per-swap minimum outputs
+    /// @notice 测试每跳最小输出保护
+    function testFlashLoanArbitrageMinAmountsOut() public {
+        uint256 loanAmount = 100 * 1e18;
+
+        address[3] memory routers = [
+            address(router1),
+            address(router2),
+            address(router3)
+        ];
+        address[3] memory tokens = [
+            address(tokenA),
+            address(tokenB),
+            address(tokenC)
+        ];
+
+        // 100 TKA -> 200 TKB -> 300 TKC -> 120 TKA
+        uint256[3] memory minAmountsOut = [uint256(200 * 1e18), 300 * 1e18, 120 * 1e18];
+        arbitrage.executeFlashLoanArbitrageWithMinOut(
+            address(tokenA),
+            loanAmount,
+            routers,
+            tokens,
+            minAmountsOut,
+            0
+        );
+
+        // Final swap below its minimum reverts / 最后一跳低于最小输出时回滚
+        minAmountsOut[2] = 121 * 1e18;
+        vm.expectRevert("Insufficient output");
+        arbitrage.executeFlashLoanArbitrageWithMinOut(
+            address(tokenA),
+            loanAmount,
+            routers,
+            tokens,
+            minAmountsOut,
+            0
+        );
+    }
+
+    /// @notice Test arbitrage on a Balancer flash loan (no fee)
+    /// @notice 测试 Balancer 闪电贷套利（无手续费）
+    function testBalancerFlashLoanArbitrage() public {
+        uint256 loanAmount = 100 * 1e18;
+        address[3] memory routers = [address(router1), address(router2), address(router3)];
+        address[3] memory tokens = [address(tokenA), address(tokenB), address(tokenC)];
+
+        arbitrage.executeBalancerFlashLoan(address(vault), address(tokenA), loanAmount, routers, tokens, 100);
+
+        // 100 TKA -> 120 TKA, all of the 20 TKA is profit / 全部 20 TKA 为利润
+        assertEq(tokenA.balanceOf(address(arbitrage)), 20 * 1e18, "Profit should be 20 TKA");
+        assertEq(tokenA.balanceOf(address(vault)), 1000000 * 1e18, "Vault should be repaid");
+    }
+
+    /// @notice Test arbitrage on a Uniswap V2 flash swap, fixed and dynamic arrays
+    /// @notice 测试 Uniswap V2 闪电兑换套利（固定数组和动态数组入口）
+    function testFlashSwapArbitrage() public {
+        uint256 loanAmount = 100 * 1e18;
+        address[3] memory routers = [address(router1), address(router2), address(router3)];
+        address[3] memory tokens = [address(tokenA), address(tokenB), address(tokenC)];
+
+        arbitrage.executeFlashSwap(address(pair), address(tokenA), loanAmount, routers, tokens, 100);
+
+        // Repay 100 / 0.997 TKA (~0.3009% fee) / 归还 100 / 0.997 TKA（约 0.3009% 手续费）
+        uint256 fee = (loanAmount * 3) / 997 + 1;
+        assertEq(tokenA.balanceOf(address(arbitrage)), 20 * 1e18 - fee, "Profit should be 20 TKA less the fee");
+
+        address[] memory pathRouters = new address[](3);
+        address[] memory pathTokens = new address[](3);
+        for (uint256 i = 0; i < 3; i++) {
+            pathRouters[i] = routers[i];
+            pathTokens[i] = tokens[i];
+        }
+        arbitrage.executeFlashSwapPath(address(pair), address(tokenA), loanAmount, pathRouters, pathTokens, new uint256[](3), 100);
+        assertEq(tokenA.balanceOf(address(arbitrage)), 2 * (20 * 1e18 - fee), "Path entry point should earn the same");
+    }
+
+    /// @notice Test the pair must hold the borrowed asset
+    /// @notice 测试交易对必须持有借入资产
+    function testFlashSwapRequiresPairAsset() public {
+        address[3] memory routers = [address(router3), address(router1), address(router2)];
+        address[3] memory tokens = [address(tokenC), address(tokenA), address(tokenB)];
+
+        vm.expectRevert("Pair does not hold asset");
+        arbitrage.executeFlashSwap(address(pair), address(tokenC), 100 * 1e18, routers, tokens, 0);
+    }
+
+    /// @notice Test loan callbacks only accept the lender being borrowed from
+    /// @notice 测试借款回调只接受正在借款的出借方
+    function testLoanCallbacksRejectOtherCallers() public {
+        address[] memory assets = new address[](1);
+        assets[0] = address(tokenA);
+        uint256[] memory amounts = new uint256[](1);
+        amounts[0] = 100 * 1e18;
+
+        vm.expectRevert("Caller must be lender");
+        arbitrage.receiveFlashLoan(assets, amounts, new uint256[](1), "");
+
+        vm.expectRevert("Caller must be lender");
+        arbitrage.uniswapV2Call(address(arbitrage), 100 * 1e18, 0, "");
+    }
+
     /// @notice Test profit simulation (view function)
     /// @notice 测试利润模拟（视图函数）
     function testSimulateArbitrage() public view {
@@ -384,6 +495,52 @@ contract MockPool {
     }
 }
 
+/// @notice Mock Balancer Vault (zero fee)
+/// @notice 模拟 Balancer 金库（无手续费）
+contract MockBalancerVault {
+    function flashLoan(
+        address recipient,
+        address[] memory tokens,
+        uint256[] memory amounts,
+        bytes memory userData
+    ) external {
+        uint256 balanceBefore = MockERC20(tokens[0]).balanceOf(address(this));
+        MockERC20(tokens[0]).transfer(recipient, amounts[0]);
+        
+        FlashLoanArbitrage(payable(recipient)).receiveFlashLoan(tokens, amounts, new uint256[](1), userData);
+        
+        // Loan must be transferred back / 借款必须转回
+        require(MockERC20(tokens[0]).balanceOf(address(this)) >= balanceBefore, "Loan not repaid");
+    }
+}
+
+/// @notice Mock Uniswap V2 Pair (0.3% fee flash swaps)
+/// @notice 模拟 Uniswap V2 交易对（0.3% 手续费闪电兑换）
+contract MockPair {
+    address public token0;
+    address public token1;
+    
+    constructor(address _token0, address _token1) {
+        token0 = _token0;
+        token1 = _token1;
+    }
+    
+    function swap(uint amount0Out, uint amount1Out, address to, bytes calldata data) external {
+        address token = amount0Out > 0 ? token0 : token1;
+        uint256 amount = amount0Out > 0 ? amount0Out : amount1Out;
+        uint256 balanceBefore = MockERC20(token).balanceOf(address(this));
+        MockERC20(token).transfer(to, amount);
+        
+        FlashLoanArbitrage(payable(to)).uniswapV2Call(msg.sender, amount0Out, amount1Out, data);
+        
+        // Constant product check with the 0.3% fee on the amount paid in
+        // 恒定乘积检查：支付金额扣除 0.3% 手续费
+        uint256 balanceAfter = MockERC20(token).balanceOf(address(this));
+        uint256 amountIn = balanceAfter + amount - balanceBefore;
+        require(balanceAfter * 1000 - amountIn * 3 >= balanceBefore * 1000, "K");
+    }
+}
+
 /// @notice Mock Aave Pool Addresses Provider
 /// @notice 模拟 Aave 池地址提供者
 contract MockPoolAddressesProvider {
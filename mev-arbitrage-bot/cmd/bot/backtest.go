@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/backtest"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/executor"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/strategy"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
+)
+
+// unlimitedCapital 回测时假设资金合约库存总是足够（资金模式不付闪电贷手续费）
+type unlimitedCapital struct{}
+
+func (unlimitedCapital) CanFund(common.Address, *big.Int) bool { return true }
+
+// runBacktest 回放历史 Sync/Swap 日志，运行已启用的策略并输出理论盈亏
+//
+// 用法: bot backtest -from <区块> -to <区块> [-data 文件] [-range 区块数] [-priority-fee gwei] [-refetch] [-json]
+//
+// 历史日志只在本地文件不存在（或 -refetch）时从 RPC 获取一次，之后的回测完全离线
+func runBacktest(args []string) error {
+	flags := flag.NewFlagSet("backtest", flag.ExitOnError)
+	fromBlock := flags.Uint64("from", 0, "第一个回放的区块")
+	toBlock := flags.Uint64("to", 0, "最后一个回放的区块")
+	dataFile := flags.String("data", "", "历史日志文件（默认 data/backtest/history-<from>-<to>.json）")
+	rangeSize := flags.Uint64("range", 1000, "报告中每个区间的区块数")
+	priorityFee := flags.Float64("priority-fee", 1, "在基础费用之上的小费 (gwei)")
+	chunkSize := flags.Uint64("chunk", 2000, "每次 eth_getLogs 请求的区块数")
+	refetch := flags.Bool("refetch", false, "忽略本地文件，重新获取历史日志")
+	asJSON := flags.Bool("json", false, "以 JSON 输出报告")
+	flags.Parse(args)
+
+	if *fromBlock == 0 || *toBlock < *fromBlock {
+		return fmt.Errorf("需要有效的区块范围: -from %d -to %d", *fromBlock, *toBlock)
+	}
+	if *dataFile == "" {
+		*dataFile = filepath.Join("data", "backtest", fmt.Sprintf("history-%d-%d.json", *fromBlock, *toBlock))
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	history, err := loadOrFetchHistory(ctx, cfg, *dataFile, *fromBlock, *toBlock, *chunkSize, *refetch)
+	if err != nil {
+		return err
+	}
+	log.Infof("📼 回放区块 %d-%d: %d 个池子, %d 个有交易的区块", *fromBlock, *toBlock, len(history.Pools), len(history.Blocks))
+
+	// 与实盘相同的策略、Gas 模型和验证；路由器来自历史文件，无需 RPC
+	monitor := dex.NewPoolMonitor(nil, cfg)
+	for dexType, router := range history.Routers {
+		monitor.RegisterRouter(dexType, router)
+	}
+	finder := strategy.NewArbitrageFinder(monitor, cfg)
+	if cfg.ExecutionMode != config.ExecutionModeFlashLoan {
+		finder.SetCapital(unlimitedCapital{})
+		log.Infof("💰 资金模式 %s: 假设库存足够", cfg.ExecutionMode)
+	} else {
+		log.Infof("⚡ 闪电贷手续费按 Aave 费率 (%d bps) 估算", config.AaveFlashLoanFeeBps)
+	}
+
+	timeout := time.Duration(cfg.StrategyTimeoutMs) * time.Millisecond
+	strategies := strategy.NewRegistry()
+	if cfg.EnableCycleStrategy {
+		strategies.Register(finder, timeout)
+	}
+	if cfg.EnableCrossDEXStrategy {
+		strategies.Register(strategy.NewCrossDEXFinder(finder), timeout)
+	}
+	if cfg.EnableBackrunStrategy {
+		log.Warn("⚠️  历史日志不含待处理交易，跟随策略不参与回测")
+	}
+	if len(strategies.Names()) == 0 {
+		return fmt.Errorf("没有启用任何可回测的策略")
+	}
+
+	// 与实盘相同的可执行性检查：合约需有该路径的入口
+	contracts, err := executor.NewContracts(cfg.CapitalContract, cfg.ArbitrageContract)
+	if err != nil {
+		return err
+	}
+
+	engine := backtest.NewEngine(finder, strategies, backtest.Options{
+		ToBlock:            *toBlock,
+		RangeSize:          *rangeSize,
+		PriorityFee:        utils.EtherToWei(new(big.Float).Quo(big.NewFloat(*priorityFee), big.NewFloat(1e9))),
+		GasPriceMultiplier: cfg.GasPriceMultiplier,
+		Supports: func(opp *strategy.ArbitrageOpportunity) error {
+			_, err := contracts.EncodeArbitrage(opp.Path, cfg.MinProfitBps)
+			return err
+		},
+	})
+
+	// 回放期间只输出警告，避免逐个机会的日志刷屏
+	level := log.GetLevel()
+	if level == log.InfoLevel {
+		log.SetLevel(log.WarnLevel)
+	}
+	report, err := engine.Run(ctx, history)
+	log.SetLevel(level)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	}
+	report.Print(os.Stdout)
+	return nil
+}
+
+// loadOrFetchHistory 读取本地历史文件；不存在或不覆盖所需区间时从 RPC 获取并保存
+func loadOrFetchHistory(ctx context.Context, cfg *config.Config, path string, fromBlock, toBlock, chunkSize uint64, refetch bool) (*backtest.History, error) {
+	if !refetch {
+		history, err := backtest.LoadHistory(path)
+		if err == nil && history.Covers(fromBlock, toBlock) {
+			log.Infof("📂 使用本地历史日志: %s", path)
+			return history, nil
+		}
+		if err == nil {
+			log.Warnf("⚠️  %s 不覆盖区块 %d-%d，重新获取", path, fromBlock, toBlock)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	log.Info("🔗 正在连接 RPC 获取历史日志...")
+	client, err := blockchain.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	httpClient := client.GetHTTPClient()
+
+	chainID, err := client.GetChainID()
+	if err != nil {
+		return nil, fmt.Errorf("获取链 ID 失败: %w", err)
+	}
+	tokenRegistry, err := dex.NewTokenRegistry(httpClient, chainID.Uint64(), cfg.TokenListFile)
+	if err != nil {
+		return nil, fmt.Errorf("创建代币注册表失败: %w", err)
+	}
+
+	// 与实盘相同的池子集合
+	monitor := dex.NewPoolMonitor(httpClient, cfg)
+	routers := make(map[dex.DEXType]common.Address)
+	uniswapAdapter, err := dex.NewUniswapV2Adapter(httpClient, cfg.UniswapV2Router, cfg.UniswapV2InitCodeHash)
+	if err != nil {
+		return nil, fmt.Errorf("创建 Uniswap 适配器失败: %w", err)
+	}
+	monitor.RegisterAdapter(uniswapAdapter)
+	routers[dex.UniswapV2] = cfg.UniswapV2Router
+	if cfg.SushiswapRouter != (common.Address{}) {
+		sushiAdapter, err := dex.NewSushiSwapAdapter(httpClient, cfg.SushiswapRouter, cfg.SushiSwapInitCodeHash)
+		if err != nil {
+			return nil, fmt.Errorf("创建 SushiSwap 适配器失败: %w", err)
+		}
+		monitor.RegisterAdapter(sushiAdapter)
+		routers[dex.SushiSwap] = cfg.SushiswapRouter
+	}
+	if err := addMonitoredPools(monitor, tokenRegistry, cfg); err != nil {
+		return nil, err
+	}
+
+	fetcher, err := backtest.NewFetcher(httpClient, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	history, err := fetcher.Fetch(ctx, monitor.GetAllPools(), routers, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := history.Save(path); err != nil {
+		return nil, err
+	}
+	log.Infof("💾 历史日志已保存: %s", path)
+	return history, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/risk"
+)
+
+// runCommand 处理命令行子命令，返回 false 表示正常启动机器人
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	var err error
+	switch args[0] {
+	case "reset-breaker":
+		err = resetBreaker()
+	case "risk-status":
+		err = printRiskStatus()
+	case "backtest":
+		err = runBacktest(args[1:])
+	default:
+		log.Fatalf("❌ 未知命令: %s (可用: reset-breaker, risk-status, backtest)", args[0])
+	}
+	if err != nil {
+		log.Fatalf("❌ %s 失败: %v", args[0], err)
+	}
+	return true
+}
+
+// resetBreaker 在机器人停止时重置熔断器；运行中请使用管理 API 的 POST /risk/reset
+func resetBreaker() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	status, err := risk.LoadStatus(cfg.RiskStateFile)
+	if err != nil {
+		return err
+	}
+	if status == nil || !status.Tripped {
+		log.Infof("✅ 熔断器未触发 (%s)", cfg.RiskStateFile)
+		return nil
+	}
+
+	log.Warnf("🔓 重置熔断器: %s (触发于 %s)", status.Reason, status.TrippedAt.Format(time.RFC3339))
+	status.Tripped = false
+	status.Reason = ""
+	status.TrippedAt = time.Time{}
+	status.ConsecutiveReverts = 0
+	if err := risk.SaveStatus(cfg.RiskStateFile, status); err != nil {
+		return err
+	}
+	log.Info("✅ 熔断器已重置")
+	return nil
+}
+
+// printRiskStatus 输出持久化的风控状态
+func printRiskStatus() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	status, err := risk.LoadStatus(cfg.RiskStateFile)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		log.Infof("ℹ️  尚无风控状态 (%s)", cfg.RiskStateFile)
+		return nil
+	}
+
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stdout, string(data))
+	return nil
+}
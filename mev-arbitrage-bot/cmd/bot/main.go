@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
@@ -16,6 +21,11 @@ import (
 	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/executor"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/flashbots"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/flashloan"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/mempool"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/pricing"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/risk"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/simulator"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/strategy"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
 )
@@ -26,6 +36,10 @@ const (
 )
 
 func main() {
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	printBanner()
 
 	// 加载配置
@@ -74,6 +88,26 @@ func main() {
 	log.Info("👀 正在启动池子监控...")
 	modules.poolMonitor.Start()
 
+	// 订阅新区块（自动重连 + 补齐遗漏区块 + 重组检测）
+	log.Info("📡 正在订阅新区块...")
+	modules.headSubscriber = client.NewHeadSubscriber()
+	blocks := modules.poolMonitor.SubscribeNewBlocks(modules.headSubscriber.Subscribe())
+
+	// 链重组时刷新池子状态并重新检查交易
+	modules.headSubscriber.OnReorg(modules.poolMonitor.HandleReorg)
+	modules.headSubscriber.OnReorg(modules.executor.Tracker().HandleReorg)
+	modules.headSubscriber.Start()
+
+	// 订阅内存池中待处理的兑换交易
+	var pendingSwaps <-chan *mempool.PendingSwap
+	if modules.mempool != nil {
+		log.Info("🔭 正在订阅内存池...")
+		if modules.backrun != nil {
+			pendingSwaps = modules.mempool.Subscribe()
+		}
+		modules.mempool.Start()
+	}
+
 	log.Info("🚀 套利机器人已启动！")
 	log.Info("   正在监控 DEX 池子，寻找套利机会")
 	log.Info("   按 Ctrl+C 可停止运行")
@@ -83,21 +117,72 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go runArbitrageLoop(ctx, cfg, modules)
+	go runArbitrageLoop(ctx, cfg, modules, blocks, pendingSwaps)
+	go reportRPCUsage(ctx, cfg, client)
+	if modules.rebalancer != nil {
+		go modules.rebalancer.Run(ctx, time.Duration(cfg.RebalanceInterval)*time.Second)
+	}
+
+	// 管理 API：查看风控状态、手动触发或重置熔断器
+	var adminServer *http.Server
+	if cfg.AdminAPIAddr != "" {
+		adminServer = &http.Server{
+			Addr:              cfg.AdminAPIAddr,
+			Handler:           risk.NewAdminHandler(modules.risk, cfg.AdminAPIToken),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			log.Infof("🛠️  管理 API 监听于 %s", cfg.AdminAPIAddr)
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("❌ 管理 API 停止: %v", err)
+			}
+		}()
+	}
 
 	// 等待关闭信号
 	<-sigChan
 	cancel()
 
+	if adminServer != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		adminServer.Shutdown(shutdownCtx)
+		shutdownCancel()
+	}
+
+	// 停止区块订阅
+	log.Info("🛑 正在停止区块订阅...")
+	modules.headSubscriber.Stop()
+
+	if modules.mempool != nil {
+		log.Info("🛑 正在停止内存池监控...")
+		modules.mempool.Stop()
+	}
+
 	// 停止监控
 	log.Info("🛑 正在停止池子监控...")
 	modules.poolMonitor.Stop()
 
+	// 输出 RPC 调用统计
+	client.LogRPCStats()
+
 	log.Info("\n👋 正在优雅关闭...")
 	log.Info("✅ 机器人已成功停止")
 }
 
 func verifyConnection(client *blockchain.Client, cfg *config.Config) error {
+	// 检查 RPC 节点健康状态
+	if err := client.HealthCheck(); err != nil {
+		return err
+	}
+	for _, stats := range client.GetEndpointStats() {
+		status := "✅"
+		if !stats.Healthy {
+			status = "⚠️ "
+		}
+		log.Infof("%s RPC 节点: 延迟 %v, 区块 %d, 落后 %d", status,
+			stats.Latency.Round(time.Millisecond), stats.BlockNumber, stats.BlockLag)
+	}
+
 	// 获取链 ID
 	chainID, err := client.GetChainID()
 	if err != nil {
@@ -155,33 +240,92 @@ func printBanner() {
 
 // BotModules holds all initialized modules
 type BotModules struct {
+	client          *blockchain.Client
+	headSubscriber  *blockchain.HeadSubscriber
+	tokenRegistry   *dex.TokenRegistry
 	poolMonitor     *dex.PoolMonitor
 	arbitrageFinder *strategy.ArbitrageFinder
+	strategies      *strategy.Registry
+	backrun         *strategy.Registry           // 跟随策略：每笔待处理兑换和每个区块都会运行（未启用时为 nil）
+	tracker         *strategy.OpportunityTracker // 按路由去重、挂起和回滚退避
 	executor        *executor.Executor
+	risk            *risk.Manager        // 风控限额与熔断器
+	inventory       *executor.Inventory  // 资金合约库存（仅资金/自动模式）
+	rebalancer      *executor.Rebalancer // 库存再平衡任务（未配置目标时为 nil）
 	flashbotsClient *flashbots.FlashbotsClient
+	mempool         *mempool.Monitor // 待处理兑换交易（未启用时为 nil）
 }
 
 // initializeModules 初始化所有机器人模块
 func initializeModules(client *blockchain.Client, cfg *config.Config) (*BotModules, error) {
-	modules := &BotModules{}
+	modules := &BotModules{client: client}
 
 	// 获取 HTTP 客户端用于合约交互
 	httpClient := client.GetHTTPClient()
 
+	// 初始化代币注册表
+	log.Info("🪙 正在初始化代币注册表...")
+	chainID, err := client.GetChainID()
+	if err != nil {
+		return nil, fmt.Errorf("获取链 ID 失败: %w", err)
+	}
+	modules.tokenRegistry, err = dex.NewTokenRegistry(httpClient, chainID.Uint64(), cfg.TokenListFile)
+	if err != nil {
+		return nil, fmt.Errorf("创建代币注册表失败: %w", err)
+	}
+
 	// 初始化 DEX 适配器
 	log.Info("🔌 正在初始化 DEX 适配器...")
-	uniswapAdapter, err := dex.NewUniswapV2Adapter(httpClient, cfg.UniswapV2Router)
+	uniswapAdapter, err := dex.NewUniswapV2Adapter(httpClient, cfg.UniswapV2Router, cfg.UniswapV2InitCodeHash)
 	if err != nil {
 		return nil, fmt.Errorf("创建 Uniswap 适配器失败: %w", err)
 	}
 
+	// 单笔报价的滑点容差取滑点策略的上限
+	slippagePolicy := strategy.SlippagePolicyFor(cfg.SlippagePolicy, cfg.SlippageMaxBps)
+	uniswapAdapter.SetSlippageBps(slippagePolicy.MaxBps)
+
 	// 初始化池子监控器
 	log.Info("📊 正在初始化池子监控器...")
 	modules.poolMonitor = dex.NewPoolMonitor(httpClient, cfg)
 	modules.poolMonitor.RegisterAdapter(uniswapAdapter)
 
+	var sushiAdapter *dex.UniswapV2Adapter
+	if cfg.SushiswapRouter != (common.Address{}) {
+		sushiAdapter, err = dex.NewSushiSwapAdapter(httpClient, cfg.SushiswapRouter, cfg.SushiSwapInitCodeHash)
+		if err != nil {
+			return nil, fmt.Errorf("创建 SushiSwap 适配器失败: %w", err)
+		}
+		sushiAdapter.SetSlippageBps(slippagePolicy.MaxBps)
+		modules.poolMonitor.RegisterAdapter(sushiAdapter)
+	}
+
+	// 内存池监控：解码发往已知路由器的待处理兑换交易
+	if cfg.EnableMempool {
+		decoder, err := mempool.NewDecoder()
+		if err != nil {
+			return nil, fmt.Errorf("创建交易解码器失败: %w", err)
+		}
+		decoder.AddRouter(cfg.UniswapV2Router, dex.UniswapV2, uniswapAdapter)
+		if sushiAdapter != nil {
+			decoder.AddRouter(cfg.SushiswapRouter, dex.SushiSwap, sushiAdapter)
+		}
+		modules.mempool = mempool.NewMonitor(client, decoder, time.Duration(cfg.MempoolMaxAgeSec)*time.Second)
+	}
+
+	// 初始化代币安全检查（eth_call 状态覆盖下模拟买入/转账/卖出）
+	var tokenSafety *dex.TokenSafetyChecker
+	if cfg.TokenSafetyCheck {
+		log.Info("🧪 正在初始化代币安全检查...")
+		tokenSafety, err = dex.NewTokenSafetyChecker(httpClient, cfg.UniswapV2Router, cfg.WETHAddress, cfg.TokenMaxTaxBps)
+		if err != nil {
+			return nil, fmt.Errorf("创建代币安全检查器失败: %w", err)
+		}
+		modules.poolMonitor.SetTokenSafety(tokenSafety)
+	}
+
 	// 添加要监控的池子
-	if err := addMonitoredPools(modules.poolMonitor, cfg); err != nil {
+	if err := addMonitoredPools(modules.poolMonitor, modules.tokenRegistry, cfg); err != nil {
 		return nil, fmt.Errorf("添加监控池子失败: %w", err)
 	}
 
@@ -191,6 +335,63 @@ func initializeModules(client *blockchain.Client, cfg *config.Config) (*BotModul
 		modules.poolMonitor,
 		cfg,
 	)
+	if tokenSafety != nil {
+		modules.arbitrageFinder.SetTokenSafety(tokenSafety)
+	}
+
+	// 定价服务：通过流动性最好的池子路径把任意代币折算成 ETH，无路径时使用配置的备用价格
+	var fallback pricing.FallbackSource
+	if len(cfg.PriceFallbackETH) > 0 {
+		fallback = pricing.NewStaticPrices(cfg.PriceFallbackETH, modules.tokenRegistry)
+	}
+	modules.arbitrageFinder.SetPricing(pricing.NewService(
+		cfg.WETHAddress, utils.EtherToWei(cfg.PriceMinLiquidityETH), fallback))
+
+	// 闪电贷来源：每个机会选择手续费最低且流动性足够的来源
+	flashLoans, err := newFlashLoanSelector(httpClient, modules.poolMonitor, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建闪电贷来源失败: %w", err)
+	}
+	modules.arbitrageFinder.SetFlashLoans(flashLoans)
+
+	// 每跳最小输出：随快照延迟、池子波动和竞争的待处理交易放宽，最后一跳不低于盈亏平衡点
+	if modules.mempool != nil {
+		modules.arbitrageFinder.Slippage().SetPendingCounter(modules.mempool)
+	}
+	log.Infof("🛡️  滑点策略: %s (基础 %d bps, 上限 %d bps)",
+		cfg.SlippagePolicy, slippagePolicy.BaseBps, slippagePolicy.MaxBps)
+
+	// 注册套利策略（每个区块并行运行，各自有超时）
+	timeout := time.Duration(cfg.StrategyTimeoutMs) * time.Millisecond
+	modules.strategies = strategy.NewRegistry()
+	if cfg.EnableCycleStrategy {
+		modules.strategies.Register(modules.arbitrageFinder, timeout)
+	}
+	if cfg.EnableCrossDEXStrategy {
+		modules.strategies.Register(strategy.NewCrossDEXFinder(modules.arbitrageFinder), timeout)
+	}
+	if cfg.EnableBackrunStrategy {
+		if modules.mempool == nil {
+			log.Warn("⚠️  跟随策略需要 ENABLE_MEMPOOL=true，已跳过")
+		} else {
+			if !cfg.EnableFlashbots {
+				log.Warn("⚠️  跟随策略只能通过 Flashbots Bundle 执行，未启用 Flashbots 时仅记录机会")
+			}
+			modules.backrun = strategy.NewRegistry()
+			modules.backrun.Register(strategy.NewBackrunFinder(modules.arbitrageFinder), timeout)
+		}
+	}
+	if len(modules.strategies.Names()) == 0 && modules.backrun == nil {
+		return nil, fmt.Errorf("没有启用任何套利策略")
+	}
+
+	// 机会跟踪：同一路由挂起期间不重复执行，连续回滚后指数退避
+	modules.tracker = strategy.NewOpportunityTracker(strategy.TrackerConfig{
+		PendingBlocks:    uint64(cfg.OpportunityPendingBlocks),
+		BackoffAfter:     cfg.RevertBackoffAfter,
+		BackoffBlocks:    uint64(cfg.RevertBackoffBlocks),
+		MaxBackoffBlocks: uint64(cfg.RevertBackoffMaxBlocks),
+	})
 
 	// 初始化 Flashbots（如果启用）
 	if cfg.EnableFlashbots {
@@ -206,25 +407,155 @@ func initializeModules(client *blockchain.Client, cfg *config.Config) (*BotModul
 
 	// 初始化执行器
 	log.Info("⚙️  正在初始化交易执行器...")
-	modules.executor, err = executor.NewExecutor(httpClient, modules.flashbotsClient, cfg)
+	modules.executor, err = executor.NewExecutor(httpClient, modules.flashbotsClient, modules.tokenRegistry, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("创建执行器失败: %w", err)
 	}
+	// 执行器与套利查找器共享 Gas 模型：用收据和 eth_estimateGas 学习，用于净利润和 Gas 限制
+	modules.executor.SetGasModel(modules.arbitrageFinder.GasModel())
+	// 本地预检：发送前在最新区块的分叉上模拟，Relay 模拟不可用时替代
+	if cfg.LocalSimulation {
+		modules.executor.SetSimulator(simulator.NewSimulator(httpClient, chainID))
+	}
+
+	// 风控：亏损、回滚、Gas 和敞口超限时触发熔断器，强制模拟模式
+	if err := initializeRisk(modules, cfg); err != nil {
+		return nil, fmt.Errorf("初始化风控失败: %w", err)
+	}
+
+	// 资金模式：跟踪资金合约库存，库存足够时不使用闪电贷
+	if cfg.ExecutionMode != config.ExecutionModeFlashLoan {
+		if err := initializeInventory(httpClient, modules, cfg); err != nil {
+			return nil, fmt.Errorf("初始化资金库存失败: %w", err)
+		}
+	}
 
 	log.Info("✅ 所有模块初始化成功")
 	return modules, nil
 }
 
+// initializeRisk 创建风控管理器并接入执行器
+func initializeRisk(modules *BotModules, cfg *config.Config) error {
+	caps := make(map[common.Address]*big.Int, len(cfg.RiskExposureCaps))
+	for token, units := range cfg.RiskExposureCaps {
+		amount, err := modules.tokenRegistry.FromUnits(token, units)
+		if err != nil {
+			return fmt.Errorf("无法换算 %s 的敞口上限: %w", token.Hex(), err)
+		}
+		caps[token] = amount
+	}
+
+	limits := risk.Limits{
+		MaxDailyLossWei:       utils.EtherToWei(cfg.RiskMaxDailyLossETH),
+		MaxConsecutiveReverts: cfg.RiskMaxConsecutiveReverts,
+		MaxGasPerHourWei:      utils.EtherToWei(cfg.RiskMaxGasPerHourETH),
+		ExposureCaps:          caps,
+		MinWalletBalanceWei:   utils.EtherToWei(cfg.RiskMinWalletBalanceETH),
+	}
+
+	manager, err := risk.NewManager(limits, cfg.RiskStateFile)
+	if err != nil {
+		return err
+	}
+	modules.risk = manager
+	modules.executor.SetRiskManager(manager)
+
+	if manager.Tripped() {
+		log.Warn("🚨 熔断器已触发，运行 `bot reset-breaker` 或调用 POST /risk/reset 重置")
+	}
+	return nil
+}
+
+// initializeInventory 创建资金合约库存跟踪和再平衡任务
+func initializeInventory(client *ethclient.Client, modules *BotModules, cfg *config.Config) error {
+	if cfg.CapitalContract == (common.Address{}) {
+		return fmt.Errorf("执行模式 %s 需要配置 CAPITAL_CONTRACT_ADDRESS", cfg.ExecutionMode)
+	}
+
+	// 跟踪起始代币和所有设置了目标库存的代币
+	tokens := append([]common.Address(nil), cfg.StartTokens...)
+	targets := make(map[common.Address]*big.Int, len(cfg.InventoryTargets))
+	for token, units := range cfg.InventoryTargets {
+		amount, err := modules.tokenRegistry.FromUnits(token, units)
+		if err != nil {
+			return fmt.Errorf("无法换算 %s 的目标库存: %w", token.Hex(), err)
+		}
+		targets[token] = amount
+		tokens = append(tokens, token)
+	}
+
+	inventory, err := executor.NewInventory(client, cfg.CapitalContract, tokens)
+	if err != nil {
+		return err
+	}
+	inventory.Refresh(context.Background())
+
+	modules.inventory = inventory
+	modules.arbitrageFinder.SetCapital(inventory)
+	modules.executor.SetInventory(inventory)
+
+	if len(targets) > 0 && cfg.RebalanceInterval > 0 {
+		modules.rebalancer = executor.NewRebalancer(modules.executor, inventory, targets, cfg.RebalanceToleranceBps)
+	}
+
+	log.Infof("✅ 资金模式: %s, 合约 %s, 跟踪 %d 个代币", cfg.ExecutionMode, cfg.CapitalContract.Hex(), len(tokens))
+	return nil
+}
+
+// newFlashLoanSelector 根据配置创建闪电贷来源
+func newFlashLoanSelector(client *ethclient.Client, monitor *dex.PoolMonitor, cfg *config.Config) (*flashloan.Selector, error) {
+	providers := make([]flashloan.Provider, 0, len(cfg.FlashLoanProviders))
+	for _, name := range cfg.FlashLoanProviders {
+		switch name {
+		case "aave_v3":
+			if cfg.AavePoolProvider == (common.Address{}) {
+				log.Warn("⚠️  未配置 AAVE_POOL_PROVIDER，跳过 Aave 闪电贷")
+				continue
+			}
+			provider, err := flashloan.NewAaveV3Provider(client, cfg.AavePoolProvider)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, provider)
+		case "balancer":
+			provider, err := flashloan.NewBalancerProvider(client, cfg.BalancerVault)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, provider)
+		case "uniswap_v2_flash_swap":
+			providers = append(providers, flashloan.NewUniswapV2FlashSwapProvider(monitor.GetAllPools))
+		default:
+			return nil, fmt.Errorf("未知的闪电贷来源: %s", name)
+		}
+	}
+
+	if len(providers) == 0 {
+		log.Warnf("⚠️  没有可用的闪电贷来源，按 Aave 手续费 (%d bps) 估算", config.AaveFlashLoanFeeBps)
+		return nil, nil
+	}
+
+	// 只比较闪电贷合约有入口函数的来源：否则手续费最低的来源无法执行，净利润也按它的手续费计算
+	selector := flashloan.NewSelector(providers...)
+	selector.SetEntryPoints(executor.FlashLoanEntryPoints()...)
+	if len(selector.Names()) == 0 {
+		return nil, fmt.Errorf("闪电贷合约不支持任何已配置的闪电贷来源: %v", cfg.FlashLoanProviders)
+	}
+	log.Infof("✅ 闪电贷来源: %v", selector.Names())
+	return selector, nil
+}
+
 // addMonitoredPools 添加要监控的池子
-func addMonitoredPools(monitor *dex.PoolMonitor, cfg *config.Config) error {
+func addMonitoredPools(monitor *dex.PoolMonitor, tokens *dex.TokenRegistry, cfg *config.Config) error {
 	log.Info("👀 正在添加监控池子...")
 
 	// 定义要监控的代币对
-	pairs := []struct {
+	type monitoredPair struct {
 		token0  common.Address
 		token1  common.Address
 		dexType dex.DEXType
-	}{
+	}
+	pairs := []monitoredPair{
 		// WETH/USDC 交易对
 		{cfg.WETHAddress, cfg.USDCAddress, dex.UniswapV2},
 		// WETH/DAI 交易对
@@ -233,21 +564,30 @@ func addMonitoredPools(monitor *dex.PoolMonitor, cfg *config.Config) error {
 		{cfg.USDCAddress, cfg.DAIAddress, dex.UniswapV2},
 	}
 
+	// 同一交易对在 SushiSwap 上的池子，用于跨 DEX 套利
+	if cfg.SushiswapRouter != (common.Address{}) {
+		for _, pair := range pairs {
+			pairs = append(pairs, monitoredPair{pair.token0, pair.token1, dex.SushiSwap})
+		}
+	}
+
 	for _, pair := range pairs {
 		// 跳过零地址
 		if pair.token0 == (common.Address{}) || pair.token1 == (common.Address{}) {
 			continue
 		}
 
-		// Try to get pool for this token pair
-		pool, err := monitor.GetPoolForTokens(pair.token0, pair.token1, pair.dexType)
+		// 获取池子并检查代币安全性（转账税 / 貔貅盘）
+		pool, err := monitor.DiscoverPool(pair.token0, pair.token1, pair.dexType)
 		if err != nil {
-			log.Warnf("Failed to get pool for %s/%s: %v", pair.token0.Hex()[:8], pair.token1.Hex()[:8], err)
+			log.Warnf("Failed to get pool for %s/%s: %v", tokens.Symbol(pair.token0), tokens.Symbol(pair.token1), err)
 			continue
 		}
 
-		// Pool already exists in monitor
-		log.Infof("✅ 正在监控 %s 池子: %s", pair.dexType, pool.Address.Hex()[:10]+"...")
+		log.Infof("✅ 正在监控 %s 池子 %s: %s", pair.dexType, tokens.PairName(pool), pool.Address.Hex()[:10]+"...")
+		if price, err := tokens.MidPrice(pool, pair.token0); err == nil {
+			log.Infof("   当前价格: 1 %s = %s %s", tokens.Symbol(pair.token0), price.Text('f', 6), tokens.Symbol(pair.token1))
+		}
 	}
 
 	allPools := monitor.GetAllPools()
@@ -260,68 +600,169 @@ func addMonitoredPools(monitor *dex.PoolMonitor, cfg *config.Config) error {
 	return nil
 }
 
-// runArbitrageLoop 运行主套利检测和执行循环
-func runArbitrageLoop(ctx context.Context, cfg *config.Config, modules *BotModules) {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+// refreshPending 移除已打包、已过期的待处理兑换，返回剩余的待处理兑换
+func refreshPending(modules *BotModules, header *types.Header) []*mempool.PendingSwap {
+	block, err := modules.client.GetBlock(header.Number.Uint64())
+	if err != nil {
+		log.Warnf("获取区块 %d 交易失败，仅按过期时间清理内存池: %v", header.Number.Uint64(), err)
+		block = types.NewBlockWithHeader(header)
+	}
+	modules.mempool.HandleBlock(block)
+
+	pending := modules.mempool.Pending()
+	if len(pending) > 0 {
+		log.Debugf("🔭 内存池: %d 笔待处理兑换", len(pending))
+	}
+	return pending
+}
 
+// runArbitrageLoop 运行主套利检测和执行循环
+// 每个新区块（池子储备已刷新）触发一次搜索；每笔新的待处理兑换立即触发一次跟随搜索
+func runArbitrageLoop(ctx context.Context, cfg *config.Config, modules *BotModules, blocks <-chan *types.Header, pending <-chan *mempool.PendingSwap) {
 	log.Info("🔄 套利检测循环已启动...")
 
+	var gasPrice *big.Int // 最新区块的出价，待处理兑换沿用
 	for {
 		select {
 		case <-ctx.Done():
 			log.Info("🛑 套利循环已停止")
 			return
 
-		case <-ticker.C:
-			// 搜索套利机会
-			opportunities := searchArbitrageOpportunities(cfg, modules)
+		case header, ok := <-blocks:
+			if !ok {
+				log.Warn("⚠️  区块订阅已关闭，套利循环退出")
+				return
+			}
+
+			log.Debugf("📦 区块 %d: 池子已刷新，开始搜索套利", header.Number.Uint64())
+
+			// 清理超出重组窗口的已跟踪交易
+			modules.executor.Tracker().Prune(header.Number.Uint64())
 
-			if len(opportunities) == 0 {
-				log.Debug("🔍 未找到盈利套利机会")
+			// 获取当前 Gas 价格（含出价倍数），失败时退回区块基础费用
+			price, err := modules.client.GetGasPrice()
+			if err != nil {
+				log.Warnf("获取 Gas 价格失败，使用基础费用: %v", err)
+				price = header.BaseFee
+			}
+			if price == nil {
+				log.Warn("⚠️  无可用 Gas 价格，跳过本区块")
 				continue
 			}
+			gasPrice = utils.ApplyMultiplier(price, cfg.GasPriceMultiplier)
 
-			// 执行最佳机会
-			best := opportunities[0]
-			log.Infof("🎯 发现套利机会！利润: %s ETH (%.2f%%)",
-				best.Path.ProfitETH.Text('f', 6),
-				float64(best.Path.NetProfitBps)/100)
+			// 刷新资金合约库存，决定本区块哪些机会可以用自有资金执行
+			if modules.inventory != nil {
+				modules.inventory.Refresh(ctx)
+			}
 
-			if err := modules.executor.ExecuteArbitrage(ctx, best); err != nil {
-				log.Errorf("❌ 执行套利失败: %v", err)
+			// 并行运行所有策略，合并并排序结果
+			snapshot := &strategy.Snapshot{
+				BlockNumber: header.Number.Uint64(),
+				Pools:       modules.poolMonitor.GetAllPools(),
+				GasPrice:    gasPrice,
+				Timestamp:   time.Now(),
+			}
+			if modules.mempool != nil {
+				snapshot.Pending = refreshPending(modules, header)
 			}
+			candidates := modules.strategies.Run(ctx, snapshot)
+			if modules.backrun != nil && len(snapshot.Pending) > 0 {
+				candidates = append(candidates, modules.backrun.Run(ctx, snapshot)...)
+			}
+
+			executeBest(ctx, modules, snapshot, candidates)
+
+		case swap, ok := <-pending:
+			if !ok {
+				log.Warn("⚠️  内存池订阅已关闭，停止跟随搜索")
+				pending = nil
+				continue
+			}
+			if gasPrice == nil {
+				continue
+			}
+
+			// 把这笔兑换应用到当前池子状态上，搜索它留下的套利机会
+			snapshot := &strategy.Snapshot{
+				BlockNumber: modules.poolMonitor.LastBlock(),
+				Pools:       modules.poolMonitor.GetAllPools(),
+				GasPrice:    gasPrice,
+				Timestamp:   time.Now(),
+				Pending:     []*mempool.PendingSwap{swap},
+			}
+			executeBest(ctx, modules, snapshot, modules.backrun.Run(ctx, snapshot))
 		}
 	}
 }
 
-// searchArbitrageOpportunities 搜索盈利套利路径
-func searchArbitrageOpportunities(cfg *config.Config, modules *BotModules) []*strategy.ArbitrageOpportunity {
-	// 使用 WETH 作为三角套利的基础代币
-	if cfg.WETHAddress == (common.Address{}) {
-		return nil
+// executeBest 验证候选机会，执行净利润最高且可执行的一个
+func executeBest(ctx context.Context, modules *BotModules, snapshot *strategy.Snapshot, candidates []*strategy.ArbitrageOpportunity) {
+	// 用实时 Gas 价格验证：去重、扣除 Gas 后按净利润排序，只保留可执行的机会
+	opportunities := modules.arbitrageFinder.ValidateOpportunities(ctx, candidates, snapshot.GasPrice)
+
+	if len(opportunities) == 0 {
+		if len(candidates) > 0 {
+			log.Debugf("🔍 %d 个候选机会扣除 Gas 后均不可执行", len(candidates))
+		} else {
+			log.Debug("🔍 未找到盈利套利机会")
+		}
+		return
 	}
 
-	paths, err := modules.arbitrageFinder.FindTriangleArbitrage(cfg.WETHAddress)
+	// 跳过挂起中或回滚退避中的路由
+	blockNumber := snapshot.BlockNumber
+	opportunities = modules.tracker.Filter(opportunities, blockNumber)
+
+	// 执行合约支持的净利润最高的机会（路径需有合约入口，跟随交易需要 Flashbots）
+	var best *strategy.ArbitrageOpportunity
+	for _, opp := range opportunities {
+		if err := modules.executor.Supports(opp); err != nil {
+			log.Debugf("跳过 %s 机会: %v", opp.Strategy, err)
+			continue
+		}
+		best = opp
+		break
+	}
+	if best == nil {
+		log.Debugf("🔍 %d 个可执行机会均无法执行", len(opportunities))
+		return
+	}
+	log.Infof("🎯 发现套利机会！策略: %s, 净利润: %s ETH (%.2f%%), Gas: %s ETH (%d)",
+		best.Strategy,
+		best.Path.NetProfitETH.Text('f', 6),
+		utils.BpsToPercentage(best.Path.NetProfitBps),
+		utils.WeiToEther(best.Path.GasCostEst).Text('f', 6),
+		best.Path.GasEst)
+	if best.Backrun != nil {
+		log.Infof("   ↪️  跟随交易 %s (发送者 %s)", best.Backrun.Hash.Hex(), best.Backrun.Sender.Hex())
+	}
+
+	if !modules.tracker.Begin(best.Path, blockNumber) {
+		return
+	}
+	outcome, err := modules.executor.ExecuteArbitrage(ctx, best)
+	modules.tracker.Complete(best.Path, outcome)
 	if err != nil {
-		log.Debugf("套利搜索错误: %v", err)
-		return nil
+		log.Errorf("❌ 执行套利失败: %v", err)
 	}
+}
 
-	if len(paths) == 0 {
-		return nil
+// reportRPCUsage 定期输出各 RPC 方法的调用次数和计算单元消耗
+func reportRPCUsage(ctx context.Context, cfg *config.Config, client *blockchain.Client) {
+	if cfg.RPCStatsInterval <= 0 {
+		return
 	}
 
-	// 转换为套利机会
-	opportunities := make([]*strategy.ArbitrageOpportunity, 0, len(paths))
-	for _, path := range paths {
-		opp := &strategy.ArbitrageOpportunity{
-			Path:         path,
-			IsExecutable: true,
-			Priority:     path.ProfitBps,
+	ticker := time.NewTicker(time.Duration(cfg.RPCStatsInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			client.LogRPCStats()
 		}
-		opportunities = append(opportunities, opp)
 	}
-
-	return opportunities
 }
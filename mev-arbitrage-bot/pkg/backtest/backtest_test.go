@@ -0,0 +1,189 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/strategy"
+)
+
+var (
+	testWETH  = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	testUSDC  = common.HexToAddress("0x0000000000000000000000000000000000000002")
+	testDAI   = common.HexToAddress("0x0000000000000000000000000000000000000003")
+	testPoolA = common.HexToAddress("0xa")
+	testPoolB = common.HexToAddress("0xb")
+	testPoolC = common.HexToAddress("0xc") // WETH/DAI, no cycle
+)
+
+// ether returns n whole tokens of 18 decimals
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+// testPool creates a WETH pool priced at 2000 tokens per WETH
+func testPool(address common.Address, dexType dex.DEXType, token common.Address) *dex.Pool {
+	return &dex.Pool{
+		Address:  address,
+		DEX:      dexType,
+		Token0:   testWETH,
+		Token1:   token,
+		Reserve0: ether(1000),
+		Reserve1: ether(2_000_000),
+		Fee:      30,
+	}
+}
+
+// testHistory holds two pools of one pair and an unrelated pool: block 101
+// moves the price on one of the pair's pools, block 102 only trades the
+// unrelated pool
+func testHistory() *History {
+	return &History{
+		ChainID:   1,
+		FromBlock: 101,
+		ToBlock:   110,
+		Routers: map[dex.DEXType]common.Address{
+			dex.UniswapV2: common.HexToAddress("0x1001"),
+			dex.SushiSwap: common.HexToAddress("0x1002"),
+		},
+		Pools: []*dex.Pool{
+			testPool(testPoolA, dex.UniswapV2, testUSDC),
+			testPool(testPoolB, dex.SushiSwap, testUSDC),
+			testPool(testPoolC, dex.UniswapV2, testDAI),
+		},
+		Blocks: []*Block{
+			{
+				Number:  101,
+				BaseFee: big.NewInt(1e9),
+				Syncs:   []SyncEvent{{Pool: testPoolA, Reserve0: ether(1000), Reserve1: ether(2_200_000)}},
+				Swaps:   []SwapEvent{{Pool: testPoolA}},
+			},
+			{
+				Number:  102,
+				BaseFee: big.NewInt(1e9),
+				Syncs:   []SyncEvent{{Pool: testPoolC, Reserve0: ether(1001), Reserve1: ether(1_998_000)}},
+				Swaps:   []SwapEvent{{Pool: testPoolC}, {Pool: testPoolC}},
+			},
+		},
+	}
+}
+
+func newTestEngine(history *History) *Engine {
+	cfg := &config.Config{
+		WETHAddress:          testWETH,
+		MinProfitBps:         10,
+		MinProfitETH:         big.NewFloat(0),
+		MinTradeAmountETH:    big.NewFloat(0.01),
+		MaxTradeAmountETH:    big.NewFloat(100),
+		MaxHops:              2,
+		StartTokens:          []common.Address{testWETH},
+		GasLimitMultiplier:   1.25,
+		PriceMinLiquidityETH: big.NewFloat(0),
+		ExecutionMode:        config.ExecutionModeFlashLoan,
+		SlippagePolicy:       config.SlippagePolicyBalanced,
+	}
+
+	monitor := dex.NewPoolMonitor(nil, cfg)
+	for dexType, router := range history.Routers {
+		monitor.RegisterRouter(dexType, router)
+	}
+	finder := strategy.NewArbitrageFinder(monitor, cfg)
+
+	strategies := strategy.NewRegistry()
+	strategies.Register(finder, time.Second)
+
+	return NewEngine(finder, strategies, Options{RangeSize: 5, PriorityFee: big.NewInt(1e9)})
+}
+
+func TestReplayTradesPriceMoves(t *testing.T) {
+	history := testHistory()
+
+	// Round trip through the local store
+	path := filepath.Join(t.TempDir(), "history.json")
+	if err := history.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Covers(101, 105) || loaded.Covers(100, 110) || loaded.Covers(101, 111) {
+		t.Fatal("wrong coverage of the stored history")
+	}
+
+	report, err := newTestEngine(loaded).Run(context.Background(), loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The price move is traded once; the trade closes the gap, so block 102
+	// has nothing left
+	total := report.Total
+	if total.ActiveBlocks != 2 || total.Swaps != 3 || total.Trades != 1 {
+		t.Fatalf("got %d active blocks, %d swaps, %d trades", total.ActiveBlocks, total.Swaps, total.Trades)
+	}
+	if total.HitRate() != 0.5 {
+		t.Fatalf("hit rate %v, want 0.5", total.HitRate())
+	}
+
+	trade := report.Trades[0]
+	if trade.Block != 101 || trade.Hops != 2 || !trade.FlashLoan {
+		t.Fatalf("unexpected trade %+v", trade)
+	}
+	if trade.GasETH.Sign() <= 0 || trade.LoanFeeETH.Sign() <= 0 || trade.NetETH.Sign() <= 0 {
+		t.Fatalf("costs not modeled: gas %s, loan fee %s, net %s", trade.GasETH, trade.LoanFeeETH, trade.NetETH)
+	}
+
+	// Gross profit is split into gas, loan fee and net profit
+	sum := new(big.Float).Add(trade.GasETH, trade.LoanFeeETH)
+	sum.Add(sum, trade.NetETH)
+	if diff, _ := new(big.Float).Sub(sum, trade.GrossETH).Float64(); diff > 1e-12 || diff < -1e-12 {
+		t.Fatalf("gross %s != gas + fee + net %s", trade.GrossETH, sum)
+	}
+
+	// Two ranges of 5 blocks; both active blocks fall in the first
+	if len(report.Ranges) != 2 || report.Ranges[0].Trades != 1 || report.Ranges[1].ActiveBlocks != 0 {
+		t.Fatalf("wrong ranges: %+v", report.Ranges)
+	}
+
+	var out bytes.Buffer
+	report.Print(&out)
+	if !strings.Contains(out.String(), "Total") || !strings.Contains(out.String(), "cycle") {
+		t.Fatalf("report missing totals or strategies:\n%s", out.String())
+	}
+}
+
+func TestReplaySkipsUnsupportedOpportunities(t *testing.T) {
+	history := testHistory()
+	engine := newTestEngine(history)
+
+	var rejected int
+	engine.options.Supports = func(opp *strategy.ArbitrageOpportunity) error {
+		if len(opp.Path.Pools) == 2 {
+			rejected++
+			return fmt.Errorf("no entry point for %d hops", len(opp.Path.Pools))
+		}
+		return nil
+	}
+
+	report, err := engine.Run(context.Background(), history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected == 0 || report.Total.Trades != 0 {
+		t.Fatalf("%d opportunities rejected, %d trades; want the 2-hop trade skipped", rejected, report.Total.Trades)
+	}
+	if report.Total.ActiveBlocks != 2 {
+		t.Fatalf("got %d active blocks, want 2", report.Total.ActiveBlocks)
+	}
+}
@@ -0,0 +1,188 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/strategy"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
+)
+
+// Options configure a replay
+type Options struct {
+	ToBlock            uint64   // Last block replayed (0 = end of the history)
+	RangeSize          uint64   // Blocks per report range
+	PriorityFee        *big.Int // Tip over the block base fee, in wei
+	GasPriceMultiplier float64  // Applied to base fee + tip, like the live bid
+
+	// Supports reports whether the deployed contracts can execute an
+	// opportunity, like the live executor (nil = all)
+	Supports func(*strategy.ArbitrageOpportunity) error
+}
+
+// Engine replays recorded pool history block by block through the
+// strategies. Each block's best executable opportunity is taken as a trade
+// at the modeled gas price and flash-loan fee, and applied to the replayed
+// reserves so it is not counted again until the pools move. Opportunities
+// the contracts cannot execute are skipped, as in the live bot.
+type Engine struct {
+	finder     *strategy.ArbitrageFinder
+	strategies *strategy.Registry
+	options    Options
+}
+
+// NewEngine creates a replay engine. finder validates candidates (gas,
+// loan fees, thresholds) like the live bot.
+func NewEngine(finder *strategy.ArbitrageFinder, strategies *strategy.Registry, options Options) *Engine {
+	if options.RangeSize == 0 {
+		options.RangeSize = 1000
+	}
+	if options.PriorityFee == nil {
+		options.PriorityFee = new(big.Int)
+	}
+	if options.GasPriceMultiplier <= 0 {
+		options.GasPriceMultiplier = 1
+	}
+
+	return &Engine{finder: finder, strategies: strategies, options: options}
+}
+
+// Run replays the history and reports the theoretical results
+func (e *Engine) Run(ctx context.Context, history *History) (*Report, error) {
+	toBlock := history.ToBlock
+	if e.options.ToBlock != 0 && e.options.ToBlock < toBlock {
+		toBlock = e.options.ToBlock
+	}
+
+	// Replayed pool state, in history order
+	pools := make([]*dex.Pool, len(history.Pools))
+	byAddress := make(map[common.Address]*dex.Pool, len(history.Pools))
+	for i, pool := range history.Pools {
+		poolCopy := *pool
+		pools[i] = &poolCopy
+		byAddress[pool.Address] = &poolCopy
+	}
+
+	report := newReport(history.FromBlock, toBlock, e.options.RangeSize)
+
+	for _, block := range history.Blocks {
+		if block.Number > toBlock {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		applySyncs(byAddress, block)
+
+		snapshot := &strategy.Snapshot{
+			BlockNumber: block.Number,
+			Pools:       snapshotPools(pools),
+			GasPrice:    e.gasPrice(block),
+			Timestamp:   time.Unix(int64(block.Timestamp), 0),
+		}
+
+		candidates := e.strategies.Run(ctx, snapshot)
+		opportunities := e.finder.ValidateOpportunities(ctx, candidates, snapshot.GasPrice)
+
+		var trade *Trade
+		if best := e.bestSupported(opportunities); best != nil {
+			trade = newTrade(block.Number, best)
+			if err := applyTrade(byAddress, best.Path); err != nil {
+				return nil, fmt.Errorf("block %d: %w", block.Number, err)
+			}
+			log.Debugf("Block %d: %s trade, net %s ETH", block.Number, trade.Strategy, trade.NetETH.Text('f', 6))
+		}
+
+		report.addBlock(block, len(candidates), trade)
+	}
+
+	report.finish()
+	return report, nil
+}
+
+// bestSupported returns the most profitable opportunity the contracts can
+// execute, or nil
+func (e *Engine) bestSupported(opportunities []*strategy.ArbitrageOpportunity) *strategy.ArbitrageOpportunity {
+	for _, opp := range opportunities {
+		if e.options.Supports == nil {
+			return opp
+		}
+		if err := e.options.Supports(opp); err != nil {
+			log.Debugf("Skipped %s opportunity %s: %v", opp.Strategy, opp.Path.ID[:8], err)
+			continue
+		}
+		return opp
+	}
+	return nil
+}
+
+// gasPrice returns the modeled gas price of a block
+func (e *Engine) gasPrice(block *Block) *big.Int {
+	price := new(big.Int).Set(e.options.PriorityFee)
+	if block.BaseFee != nil {
+		price.Add(price, block.BaseFee)
+	}
+	return utils.ApplyMultiplier(price, e.options.GasPriceMultiplier)
+}
+
+// applySyncs sets reserves from a block's Sync logs, in log order
+func applySyncs(pools map[common.Address]*dex.Pool, block *Block) {
+	syncs := append([]SyncEvent(nil), block.Syncs...)
+	sort.Slice(syncs, func(i, j int) bool { return syncs[i].LogIndex < syncs[j].LogIndex })
+
+	for _, event := range syncs {
+		pool, exists := pools[event.Pool]
+		if !exists {
+			continue
+		}
+		pool.Reserve0 = new(big.Int).Set(event.Reserve0)
+		pool.Reserve1 = new(big.Int).Set(event.Reserve1)
+		pool.BlockNumber = block.Number
+		pool.LastUpdated = int64(block.Timestamp)
+	}
+}
+
+// snapshotPools copies pools for a snapshot
+func snapshotPools(pools []*dex.Pool) []*dex.Pool {
+	copies := make([]*dex.Pool, len(pools))
+	for i, pool := range pools {
+		poolCopy := *pool
+		copies[i] = &poolCopy
+	}
+	return copies
+}
+
+// applyTrade moves the replayed reserves along a traded path
+func applyTrade(pools map[common.Address]*dex.Pool, path *strategy.ArbitragePath) error {
+	amount := path.StartAmount
+	for i, hop := range path.Pools {
+		pool, exists := pools[hop.Address]
+		if !exists {
+			return fmt.Errorf("traded pool %s not replayed", hop.Address.Hex())
+		}
+
+		reserveIn, reserveOut, err := pool.GetReservesFor(path.Tokens[i])
+		if err != nil {
+			return err
+		}
+		amountOut := utils.CalculateAmountOut(amount, reserveIn, reserveOut, pool.Fee)
+
+		newIn := new(big.Int).Add(reserveIn, amount)
+		newOut := new(big.Int).Sub(reserveOut, amountOut)
+		if path.Tokens[i] == pool.Token0 {
+			pool.Reserve0, pool.Reserve1 = newIn, newOut
+		} else {
+			pool.Reserve1, pool.Reserve0 = newIn, newOut
+		}
+		amount = amountOut
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// Uniswap V2 pair events
+var (
+	syncEventSig = crypto.Keccak256Hash([]byte("Sync(uint112,uint112)"))
+	swapEventSig = crypto.Keccak256Hash([]byte("Swap(address,uint256,uint256,uint256,uint256,address)"))
+)
+
+// History is the recorded state of a set of pools over a block range: the
+// reserves before the range and every Sync and Swap log in it, grouped by
+// block. Only blocks with logs are recorded; pool state does not change in
+// the others.
+type History struct {
+	ChainID   uint64                         `json:"chainId"`
+	FromBlock uint64                         `json:"fromBlock"`
+	ToBlock   uint64                         `json:"toBlock"`
+	Routers   map[dex.DEXType]common.Address `json:"routers"`
+	Pools     []*dex.Pool                    `json:"pools"` // Reserves as of FromBlock-1
+	Blocks    []*Block                       `json:"blocks"`
+}
+
+// Block is a block with pool logs
+type Block struct {
+	Number    uint64      `json:"number"`
+	Timestamp uint64      `json:"timestamp"`
+	BaseFee   *big.Int    `json:"baseFee"`
+	Syncs     []SyncEvent `json:"syncs"`
+	Swaps     []SwapEvent `json:"swaps"`
+}
+
+// SyncEvent is a pool's reserves after a swap, mint or burn
+type SyncEvent struct {
+	Pool     common.Address `json:"pool"`
+	Reserve0 *big.Int       `json:"reserve0"`
+	Reserve1 *big.Int       `json:"reserve1"`
+	LogIndex uint           `json:"logIndex"`
+}
+
+// SwapEvent is a swap through a pool
+type SwapEvent struct {
+	Pool       common.Address `json:"pool"`
+	TxHash     common.Hash    `json:"txHash"`
+	Amount0In  *big.Int       `json:"amount0In"`
+	Amount1In  *big.Int       `json:"amount1In"`
+	Amount0Out *big.Int       `json:"amount0Out"`
+	Amount1Out *big.Int       `json:"amount1Out"`
+	LogIndex   uint           `json:"logIndex"`
+}
+
+// LoadHistory reads a history file
+func LoadHistory(path string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var history History
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse history %s: %w", path, err)
+	}
+	return &history, nil
+}
+
+// Save writes the history to a file
+func (h *History) Save(path string) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Covers reports whether the history replays a block range
+func (h *History) Covers(fromBlock, toBlock uint64) bool {
+	return h.FromBlock == fromBlock && h.ToBlock >= toBlock
+}
+
+// Fetcher downloads pool history from an RPC node
+type Fetcher struct {
+	client    *ethclient.Client
+	pairABI   abi.ABI
+	chunkSize uint64 // Blocks per eth_getLogs request
+}
+
+// NewFetcher creates a fetcher querying logs in chunks of chunkSize blocks
+func NewFetcher(client *ethclient.Client, chunkSize uint64) (*Fetcher, error) {
+	pairABI, err := abi.JSON(strings.NewReader(dex.UniswapV2PairABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pair ABI: %w", err)
+	}
+	if chunkSize == 0 {
+		chunkSize = 2000
+	}
+
+	return &Fetcher{client: client, pairABI: pairABI, chunkSize: chunkSize}, nil
+}
+
+// Fetch records the history of pools over [fromBlock, toBlock]. routers
+// maps each DEX of the pools to its router.
+func (f *Fetcher) Fetch(ctx context.Context, pools []*dex.Pool, routers map[dex.DEXType]common.Address, fromBlock, toBlock uint64) (*History, error) {
+	if fromBlock == 0 || toBlock < fromBlock {
+		return nil, fmt.Errorf("invalid block range %d-%d", fromBlock, toBlock)
+	}
+
+	chainID, err := f.client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	history := &History{
+		ChainID:   chainID.Uint64(),
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Routers:   routers,
+		Pools:     make([]*dex.Pool, 0, len(pools)),
+	}
+
+	// Starting reserves, as of the block before the range
+	addresses := make([]common.Address, 0, len(pools))
+	for _, pool := range pools {
+		reserve0, reserve1, err := f.reservesAt(ctx, pool.Address, fromBlock-1)
+		if err != nil {
+			return nil, err
+		}
+
+		start := *pool
+		start.Reserve0, start.Reserve1 = reserve0, reserve1
+		start.BlockNumber = fromBlock - 1
+		start.LastUpdated = 0
+		history.Pools = append(history.Pools, &start)
+		addresses = append(addresses, pool.Address)
+	}
+
+	blocks := make(map[uint64]*Block)
+	for start := fromBlock; start <= toBlock; start += f.chunkSize {
+		end := start + f.chunkSize - 1
+		if end > toBlock {
+			end = toBlock
+		}
+
+		logs, err := f.client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: addresses,
+			Topics:    [][]common.Hash{{syncEventSig, swapEventSig}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch logs of blocks %d-%d: %w", start, end, err)
+		}
+
+		for _, entry := range logs {
+			if entry.Removed {
+				continue
+			}
+			block, exists := blocks[entry.BlockNumber]
+			if !exists {
+				block = &Block{Number: entry.BlockNumber}
+				blocks[entry.BlockNumber] = block
+			}
+			addLog(block, entry)
+		}
+
+		log.Infof("Fetched logs of blocks %d-%d (%d blocks with activity)", start, end, len(blocks))
+	}
+
+	// Block timestamps and base fees price gas
+	for number, block := range blocks {
+		header, err := f.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch header %d: %w", number, err)
+		}
+		block.Timestamp = header.Time
+		block.BaseFee = header.BaseFee
+		history.Blocks = append(history.Blocks, block)
+	}
+
+	sort.Slice(history.Blocks, func(i, j int) bool {
+		return history.Blocks[i].Number < history.Blocks[j].Number
+	})
+	return history, nil
+}
+
+// reservesAt returns a pair's reserves at a block
+func (f *Fetcher) reservesAt(ctx context.Context, pair common.Address, blockNumber uint64) (*big.Int, *big.Int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	data, err := f.pairABI.Pack("getReserves")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output, err := f.client.CallContract(ctx, ethereum.CallMsg{To: &pair, Data: data}, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return nil, nil, fmt.Errorf("getReserves of %s at block %d failed: %w", pair.Hex(), blockNumber, err)
+	}
+
+	result, err := f.pairABI.Unpack("getReserves", output)
+	if err != nil || len(result) < 2 {
+		return nil, nil, fmt.Errorf("invalid getReserves result of %s: %v", pair.Hex(), err)
+	}
+	return result[0].(*big.Int), result[1].(*big.Int), nil
+}
+
+// addLog decodes a Sync or Swap log into its block
+func addLog(block *Block, entry types.Log) {
+	word := func(i int) *big.Int {
+		return new(big.Int).SetBytes(entry.Data[i*32 : (i+1)*32])
+	}
+
+	switch {
+	case entry.Topics[0] == syncEventSig && len(entry.Data) == 64:
+		block.Syncs = append(block.Syncs, SyncEvent{
+			Pool:     entry.Address,
+			Reserve0: word(0),
+			Reserve1: word(1),
+			LogIndex: entry.Index,
+		})
+	case entry.Topics[0] == swapEventSig && len(entry.Data) == 128:
+		block.Swaps = append(block.Swaps, SwapEvent{
+			Pool:       entry.Address,
+			TxHash:     entry.TxHash,
+			Amount0In:  word(0),
+			Amount1In:  word(1),
+			Amount0Out: word(2),
+			Amount1Out: word(3),
+			LogIndex:   entry.Index,
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/strategy"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
+)
+
+// profitBuckets are the upper bounds (ETH) of the net profit distribution
+var profitBuckets = []float64{0.001, 0.01, 0.1, 1}
+
+// Trade is the theoretical execution of an opportunity
+type Trade struct {
+	Block      uint64     `json:"block"`
+	Strategy   string     `json:"strategy"`
+	Hops       int        `json:"hops"`
+	FlashLoan  bool       `json:"flashLoan"`
+	GasUsed    uint64     `json:"gasUsed"`
+	GrossETH   *big.Float `json:"grossEth"`   // Profit before costs
+	GasETH     *big.Float `json:"gasEth"`     // Modeled gas cost
+	LoanFeeETH *big.Float `json:"loanFeeEth"` // Modeled flash-loan fee
+	NetETH     *big.Float `json:"netEth"`
+}
+
+// newTrade records an opportunity validated at the block's gas price
+func newTrade(block uint64, opportunity *strategy.ArbitrageOpportunity) *Trade {
+	path := opportunity.Path
+	gas := utils.WeiToEther(path.GasCostEst)
+
+	// Whatever gross profit gas and net profit do not explain went to the loan
+	loanFee := new(big.Float).Sub(path.ProfitETH, gas)
+	loanFee.Sub(loanFee, path.NetProfitETH)
+	if loanFee.Sign() < 0 {
+		loanFee.SetInt64(0)
+	}
+
+	return &Trade{
+		Block:      block,
+		Strategy:   opportunity.Strategy,
+		Hops:       len(path.Pools),
+		FlashLoan:  path.FlashLoan,
+		GasUsed:    path.GasEst,
+		GrossETH:   new(big.Float).Set(path.ProfitETH),
+		GasETH:     gas,
+		LoanFeeETH: loanFee,
+		NetETH:     new(big.Float).Set(path.NetProfitETH),
+	}
+}
+
+// RangeStats aggregates replayed blocks
+type RangeStats struct {
+	FromBlock    uint64     `json:"fromBlock"`
+	ToBlock      uint64     `json:"toBlock"`
+	ActiveBlocks int        `json:"activeBlocks"` // Blocks in which a replayed pool moved
+	Swaps        int        `json:"swaps"`
+	Candidates   int        `json:"candidates"` // Opportunities found before validation
+	Trades       int        `json:"trades"`
+	GrossETH     *big.Float `json:"grossEth"`
+	GasETH       *big.Float `json:"gasEth"`
+	LoanFeeETH   *big.Float `json:"loanFeeEth"`
+	NetETH       *big.Float `json:"netEth"`
+}
+
+// newRangeStats creates empty stats of a block range
+func newRangeStats(fromBlock, toBlock uint64) *RangeStats {
+	return &RangeStats{
+		FromBlock:  fromBlock,
+		ToBlock:    toBlock,
+		GrossETH:   new(big.Float),
+		GasETH:     new(big.Float),
+		LoanFeeETH: new(big.Float),
+		NetETH:     new(big.Float),
+	}
+}
+
+// HitRate is the share of active blocks with a trade
+func (s *RangeStats) HitRate() float64 {
+	if s.ActiveBlocks == 0 {
+		return 0
+	}
+	return float64(s.Trades) / float64(s.ActiveBlocks)
+}
+
+// add counts a replayed block
+func (s *RangeStats) add(block *Block, candidates int, trade *Trade) {
+	s.ActiveBlocks++
+	s.Swaps += len(block.Swaps)
+	s.Candidates += candidates
+	if trade == nil {
+		return
+	}
+	s.Trades++
+	s.GrossETH.Add(s.GrossETH, trade.GrossETH)
+	s.GasETH.Add(s.GasETH, trade.GasETH)
+	s.LoanFeeETH.Add(s.LoanFeeETH, trade.LoanFeeETH)
+	s.NetETH.Add(s.NetETH, trade.NetETH)
+}
+
+// StrategyStats aggregates the trades of one strategy
+type StrategyStats struct {
+	Trades int        `json:"trades"`
+	NetETH *big.Float `json:"netEth"`
+}
+
+// Bucket is a net profit range of the trade distribution
+type Bucket struct {
+	Label  string `json:"label"`
+	Trades int    `json:"trades"`
+}
+
+// Report is the outcome of a replay
+type Report struct {
+	Total        *RangeStats               `json:"total"`
+	Ranges       []*RangeStats             `json:"ranges"`
+	Strategies   map[string]*StrategyStats `json:"strategies"`
+	Hops         map[int]int               `json:"hops"` // Trades by path length
+	Distribution []Bucket                  `json:"distribution"`
+	Trades       []*Trade                  `json:"trades"`
+
+	rangeSize uint64
+}
+
+// newReport creates an empty report of a block range split into ranges
+func newReport(fromBlock, toBlock, rangeSize uint64) *Report {
+	report := &Report{
+		Total:      newRangeStats(fromBlock, toBlock),
+		Strategies: make(map[string]*StrategyStats),
+		Hops:       make(map[int]int),
+		rangeSize:  rangeSize,
+	}
+	for start := fromBlock; start <= toBlock; start += rangeSize {
+		end := start + rangeSize - 1
+		if end > toBlock {
+			end = toBlock
+		}
+		report.Ranges = append(report.Ranges, newRangeStats(start, end))
+	}
+	return report
+}
+
+// addBlock counts a replayed block and its trade, if any
+func (r *Report) addBlock(block *Block, candidates int, trade *Trade) {
+	r.Total.add(block, candidates, trade)
+	if i := (block.Number - r.Total.FromBlock) / r.rangeSize; i < uint64(len(r.Ranges)) {
+		r.Ranges[i].add(block, candidates, trade)
+	}
+	if trade == nil {
+		return
+	}
+
+	r.Trades = append(r.Trades, trade)
+	r.Hops[trade.Hops]++
+	stats, exists := r.Strategies[trade.Strategy]
+	if !exists {
+		stats = &StrategyStats{NetETH: new(big.Float)}
+		r.Strategies[trade.Strategy] = stats
+	}
+	stats.Trades++
+	stats.NetETH.Add(stats.NetETH, trade.NetETH)
+}
+
+// finish computes the net profit distribution
+func (r *Report) finish() {
+	r.Distribution = make([]Bucket, len(profitBuckets)+1)
+	lower := "0"
+	for i, bound := range profitBuckets {
+		r.Distribution[i].Label = fmt.Sprintf("%s - %g ETH", lower, bound)
+		lower = fmt.Sprintf("%g", bound)
+	}
+	r.Distribution[len(profitBuckets)].Label = fmt.Sprintf(">= %s ETH", lower)
+
+	for _, trade := range r.Trades {
+		net, _ := trade.NetETH.Float64()
+		i := sort.SearchFloat64s(profitBuckets, net)
+		if i < len(profitBuckets) && profitBuckets[i] == net {
+			i++ // Bounds are exclusive
+		}
+		r.Distribution[i].Trades++
+	}
+}
+
+// Print writes the report as text tables
+func (r *Report) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Blocks\tActive\tSwaps\tCandidates\tTrades\tHit rate\tGross ETH\tGas ETH\tLoan fee ETH\tNet ETH\t")
+	rows := append(append([]*RangeStats(nil), r.Ranges...), r.Total)
+	for _, stats := range rows {
+		label := fmt.Sprintf("%d-%d", stats.FromBlock, stats.ToBlock)
+		if stats == r.Total {
+			label = "Total"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.2f%%\t%s\t%s\t%s\t%s\t\n",
+			label, stats.ActiveBlocks, stats.Swaps, stats.Candidates, stats.Trades, stats.HitRate()*100,
+			stats.GrossETH.Text('f', 6), stats.GasETH.Text('f', 6),
+			stats.LoanFeeETH.Text('f', 6), stats.NetETH.Text('f', 6))
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Strategy\tTrades\tNet ETH\t")
+	names := make([]string, 0, len(r.Strategies))
+	for name := range r.Strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", name, r.Strategies[name].Trades, r.Strategies[name].NetETH.Text('f', 6))
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Net profit\tTrades\t")
+	for _, bucket := range r.Distribution {
+		fmt.Fprintf(tw, "%s\t%d\t\n", bucket.Label, bucket.Trades)
+	}
+	hops := make([]int, 0, len(r.Hops))
+	for n := range r.Hops {
+		hops = append(hops, n)
+	}
+	sort.Ints(hops)
+	for _, n := range hops {
+		fmt.Fprintf(tw, "%d hops\t%d\t\n", n, r.Hops[n])
+	}
+	tw.Flush()
+}
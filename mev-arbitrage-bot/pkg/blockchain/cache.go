@@ -0,0 +1,321 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+// cacheMaxBlocks bounds the number of cached block-by-hash responses
+const cacheMaxBlocks = 512
+
+// immutableSelectors are view functions whose result never changes for a
+// given contract and arguments. symbol() and name() are left out: some
+// tokens can rename themselves.
+var immutableSelectors = map[string]bool{
+	selector("factory()"):                       true,
+	selector("getPair(address,address)"):        true,
+	selector("token0()"):                        true,
+	selector("token1()"):                        true,
+	selector("decimals()"):                      true,
+	selector("WETH()"):                          true,
+	selector("allPairs(uint256)"):               true,
+	selector("getPool(address,address,uint24)"): true,
+}
+
+// selector returns the hex-encoded 4-byte function selector of a signature
+func selector(signature string) string {
+	return hex.EncodeToString(crypto.Keccak256([]byte(signature))[:4])
+}
+
+// ResponseCache is an http.RoundTripper that answers JSON-RPC requests for
+// immutable chain data (static contract reads, blocks by hash) from a local
+// cache keyed by chain ID, optionally persisted to disk
+type ResponseCache struct {
+	next      http.RoundTripper
+	chainID   uint64 // 0 = caching disabled until set
+	path      string // Persistence file ("" = memory only)
+	entries   map[string]json.RawMessage
+	blockKeys []string // Insertion order of block entries for eviction
+	dirty     bool
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	mu        sync.RWMutex
+}
+
+// NewResponseCache wraps a transport with an immutable-data cache
+func NewResponseCache(next http.RoundTripper, path string) *ResponseCache {
+	return &ResponseCache{
+		next:    next,
+		path:    path,
+		entries: make(map[string]json.RawMessage),
+	}
+}
+
+// SetChainID enables caching for the given chain
+func (c *ResponseCache) SetChainID(chainID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.chainID = chainID
+}
+
+// Load reads persisted entries from disk. A missing file is not an error.
+func (c *ResponseCache) Load() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	entries := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse cache file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, value := range entries {
+		c.entries[key] = value
+		if isBlockKey(key) {
+			c.blockKeys = append(c.blockKeys, key)
+		}
+	}
+	c.evictBlocks()
+
+	log.Infof("Loaded %d cached RPC responses from %s", len(entries), c.path)
+	return nil
+}
+
+// Save writes the cache to disk if it changed since the last save
+func (c *ResponseCache) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	c.dirty = false
+	c.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write atomically so a crash never leaves a truncated file
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+
+	return nil
+}
+
+// Stats returns cache hit and miss counts and the number of entries
+func (c *ResponseCache) Stats() (hits, misses uint64, entries int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.hits.Load(), c.misses.Load(), len(c.entries)
+}
+
+// RoundTrip answers cacheable requests locally and stores fresh results
+func (c *ResponseCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return c.next.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var call struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &call); err != nil {
+		// Batches are not cached
+		return c.next.RoundTrip(req)
+	}
+
+	key := c.keyFor(call.Method, call.Params)
+	if key == "" {
+		return c.next.RoundTrip(req)
+	}
+
+	c.mu.RLock()
+	result, exists := c.entries[key]
+	c.mu.RUnlock()
+
+	if exists {
+		c.hits.Add(1)
+		return cachedResponse(req, call.ID, result), nil
+	}
+	c.misses.Add(1)
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &reply); err == nil && reply.Error == nil && isCacheable(reply.Result) {
+		c.store(key, reply.Result)
+	}
+
+	return resp, nil
+}
+
+// keyFor returns the cache key of an immutable request, or "" if the
+// request must go to the network
+func (c *ResponseCache) keyFor(method string, params []json.RawMessage) string {
+	c.mu.RLock()
+	chainID := c.chainID
+	c.mu.RUnlock()
+
+	if chainID == 0 {
+		return ""
+	}
+
+	switch method {
+	case "eth_getBlockByHash":
+		if len(params) != 2 {
+			return ""
+		}
+		return fmt.Sprintf("%d:block:%s:%s", chainID,
+			strings.ToLower(string(params[0])), string(params[1]))
+
+	case "eth_call":
+		// State overrides change the result, never cache them
+		if len(params) < 1 || len(params) > 2 {
+			return ""
+		}
+
+		var msg struct {
+			To    string `json:"to"`
+			Data  string `json:"data"`
+			Input string `json:"input"`
+		}
+		if err := json.Unmarshal(params[0], &msg); err != nil {
+			return ""
+		}
+
+		data := strings.ToLower(strings.TrimPrefix(msg.Input, "0x"))
+		if data == "" {
+			data = strings.ToLower(strings.TrimPrefix(msg.Data, "0x"))
+		}
+		if len(data) < 8 || !immutableSelectors[data[:8]] {
+			return ""
+		}
+
+		return fmt.Sprintf("%d:call:%s:%s", chainID, strings.ToLower(msg.To), data)
+	}
+
+	return ""
+}
+
+// store adds an entry, evicting the oldest blocks when over capacity
+func (c *ResponseCache) store(key string, result json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; exists {
+		return
+	}
+
+	c.entries[key] = append(json.RawMessage(nil), result...)
+	c.dirty = true
+
+	if isBlockKey(key) {
+		c.blockKeys = append(c.blockKeys, key)
+		c.evictBlocks()
+	}
+}
+
+// evictBlocks drops the oldest block entries above cacheMaxBlocks
+func (c *ResponseCache) evictBlocks() {
+	for len(c.blockKeys) > cacheMaxBlocks {
+		delete(c.entries, c.blockKeys[0])
+		c.blockKeys = c.blockKeys[1:]
+	}
+}
+
+// isBlockKey reports whether a cache key holds a block
+func isBlockKey(key string) bool {
+	return strings.Contains(key, ":block:")
+}
+
+// isCacheable rejects empty results: a null block or an all-zero return
+// value (e.g. getPair for a pair that does not exist yet) may change later
+func isCacheable(result json.RawMessage) bool {
+	trimmed := strings.Trim(string(result), `"`)
+	if trimmed == "" || trimmed == "null" || trimmed == "0x" {
+		return false
+	}
+
+	return strings.Trim(strings.TrimPrefix(trimmed, "0x"), "0") != ""
+}
+
+// cachedResponse builds a JSON-RPC response from a cached result
+func cachedResponse(req *http.Request, id, result json.RawMessage) *http.Response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":%s}`, id, result)
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
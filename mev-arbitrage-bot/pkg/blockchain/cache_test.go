@@ -0,0 +1,109 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const (
+	testToken       = `"0x6b175474e89094c44da98b954eedeac495271d0f"`
+	testBlockHash   = `"0xAB00000000000000000000000000000000000000000000000000000000000001"`
+	decimalsCall    = "0x313ce567"
+	balanceOfCall   = "0x70a08231000000000000000000000000000000000000000000000000000000000000dead"
+	symbolCall      = "0x95d89b41"
+	decimalsReplied = `"0x0000000000000000000000000000000000000000000000000000000000000012"`
+)
+
+func callParams(to, data string, extra ...string) []json.RawMessage {
+	params := []json.RawMessage{json.RawMessage(`{"to":` + to + `,"data":"` + data + `"}`)}
+	for _, param := range extra {
+		params = append(params, json.RawMessage(param))
+	}
+	return params
+}
+
+func TestResponseCacheKeyFor(t *testing.T) {
+	cache := NewResponseCache(&recordingTransport{}, "")
+
+	if key := cache.keyFor("eth_call", callParams(testToken, decimalsCall)); key != "" {
+		t.Fatalf("cached before the chain ID is known: %s", key)
+	}
+	cache.SetChainID(1)
+
+	cases := []struct {
+		name      string
+		method    string
+		params    []json.RawMessage
+		cacheable bool
+	}{
+		{"decimals", "eth_call", callParams(testToken, decimalsCall, `"latest"`), true},
+		{"decimals via input", "eth_call", []json.RawMessage{json.RawMessage(`{"to":` + testToken + `,"input":"` + decimalsCall + `"}`)}, true},
+		{"balance", "eth_call", callParams(testToken, balanceOfCall, `"latest"`), false},
+		{"symbol", "eth_call", callParams(testToken, symbolCall, `"latest"`), false},
+		{"state override", "eth_call", callParams(testToken, decimalsCall, `"latest"`, `{}`), false},
+		{"block by hash", "eth_getBlockByHash", []json.RawMessage{json.RawMessage(testBlockHash), json.RawMessage(`false`)}, true},
+		{"block by number", "eth_getBlockByNumber", []json.RawMessage{json.RawMessage(`"latest"`), json.RawMessage(`false`)}, false},
+	}
+	for _, c := range cases {
+		if key := cache.keyFor(c.method, c.params); (key != "") != c.cacheable {
+			t.Errorf("%s: key %q, want cacheable=%v", c.name, key, c.cacheable)
+		}
+	}
+
+	// Keys ignore the block tag and address case
+	upper := callParams(`"0x6B175474E89094C44DA98B954EEDEAC495271D0F"`, decimalsCall, `"0x10"`)
+	if cache.keyFor("eth_call", upper) != cache.keyFor("eth_call", callParams(testToken, decimalsCall, `"latest"`)) {
+		t.Error("same immutable call keyed differently")
+	}
+
+	// Blocks keep the full-transactions flag in the key
+	hash := json.RawMessage(testBlockHash)
+	if cache.keyFor("eth_getBlockByHash", []json.RawMessage{hash, json.RawMessage(`false`)}) ==
+		cache.keyFor("eth_getBlockByHash", []json.RawMessage{hash, json.RawMessage(`true`)}) {
+		t.Error("header and full block share a key")
+	}
+}
+
+func TestIsCacheable(t *testing.T) {
+	cases := map[string]bool{
+		decimalsReplied:    true,
+		`{"number":"0x1"}`: true,
+		`null`:             false,
+		`""`:               false,
+		`"0x"`:             false,
+		`"0x0000000000000000000000000000000000000000000000000000000000000000"`: false,
+	}
+	for result, want := range cases {
+		if got := isCacheable(json.RawMessage(result)); got != want {
+			t.Errorf("isCacheable(%s) = %v, want %v", result, got, want)
+		}
+	}
+}
+
+func TestResponseCacheServesRepeatedCalls(t *testing.T) {
+	transport := &recordingTransport{}
+	cache := NewResponseCache(transport, "")
+	cache.SetChainID(1)
+
+	body := `{"jsonrpc":"2.0","id":7,"method":"eth_call","params":[{"to":` + testToken + `,"data":"` + decimalsCall + `"},"latest"]}`
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodPost, "http://node", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := cache.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if calls := len(transport.order()); calls != 1 {
+		t.Fatalf("%d network calls for a repeated immutable call, want 1", calls)
+	}
+	if hits, misses, entries := cache.Stats(); hits != 1 || misses != 1 || entries != 1 {
+		t.Fatalf("hits=%d misses=%d entries=%d, want 1/1/1", hits, misses, entries)
+	}
+}
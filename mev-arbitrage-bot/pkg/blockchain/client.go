@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
@@ -18,6 +21,11 @@ import (
 type Client struct {
 	httpClient *ethclient.Client
 	wssClient  *ethclient.Client
+	wssMu      sync.RWMutex
+	wssIndex   int            // Index into config.RPCWSSUrls of the current WSS endpoint
+	endpoints  *EndpointPool  // Routes HTTPS calls to the healthiest endpoint
+	limiter    *RateLimiter   // Budgets HTTPS calls per method and priority
+	cache      *ResponseCache // Serves immutable chain data locally
 	config     *config.Config
 	ctx        context.Context
 	cancel     context.CancelFunc
@@ -47,33 +55,110 @@ func NewClient(cfg *config.Config) (*Client, error) {
 	return client, nil
 }
 
-// connectHTTP establishes HTTPS RPC connection
+// connectHTTP establishes HTTPS RPC connection through the endpoint pool
 func (c *Client) connectHTTP() error {
 	ctx, cancel := context.WithTimeout(c.ctx, time.Duration(c.config.ConnectionTimeout)*time.Second)
 	defer cancel()
 
-	client, err := ethclient.DialContext(ctx, c.config.RPCHTTPSUrl)
+	urls := c.config.RPCHTTPSUrls
+	if len(urls) == 0 {
+		urls = []string{c.config.RPCHTTPSUrl}
+	}
+
+	endpoints, err := NewEndpointPool(urls, c.config.RPCMaxBlockLag,
+		time.Duration(c.config.RPCHedgeDelayMs)*time.Millisecond)
+	if err != nil {
+		return fmt.Errorf("failed to create endpoint pool: %w", err)
+	}
+	endpoints.Start(time.Duration(c.config.RPCHealthCheckInterval) * time.Second)
+
+	limiter := NewRateLimiter(endpoints, c.config.RPCRateLimitRPS, c.config.RPCComputeUnits, c.config.RPCMethodCU)
+
+	cache := NewResponseCache(limiter, c.config.RPCCacheFile)
+
+	rpcClient, err := rpc.DialOptions(ctx, urls[0], rpc.WithHTTPClient(&http.Client{Transport: cache}))
 	if err != nil {
+		endpoints.Stop()
 		return fmt.Errorf("HTTPS dial failed: %w", err)
 	}
+	client := ethclient.NewClient(rpcClient)
 
 	// Test connection
-	if _, err := client.ChainID(ctx); err != nil {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
 		client.Close()
+		endpoints.Stop()
 		return fmt.Errorf("HTTPS connection test failed: %w", err)
 	}
 
+	// Cache entries are keyed by chain ID
+	cache.SetChainID(chainID.Uint64())
+	if err := cache.Load(); err != nil {
+		log.Warnf("RPC cache not loaded: %v", err)
+	}
+	go c.persistCache()
+
 	c.httpClient = client
-	log.Info("HTTPS RPC client connected")
+	c.endpoints = endpoints
+	c.limiter = limiter
+	c.cache = cache
+	log.Infof("HTTPS RPC client connected (%d endpoints, %d healthy)", len(urls), endpoints.HealthyCount())
 	return nil
 }
 
-// connectWSS establishes WebSocket connection
+// persistCache periodically writes the response cache to disk
+func (c *Client) persistCache() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.cache.Save(); err != nil {
+				log.Warnf("Failed to save RPC cache: %v", err)
+			}
+		}
+	}
+}
+
+// connectWSS establishes WebSocket connection, trying each configured
+// endpoint in turn starting from the current one
 func (c *Client) connectWSS() error {
+	urls := c.config.RPCWSSUrls
+	if len(urls) == 0 {
+		urls = []string{c.config.RPCWSSUrl}
+	}
+
+	c.wssMu.RLock()
+	start := c.wssIndex
+	c.wssMu.RUnlock()
+
+	var lastErr error
+	for attempt := 0; attempt < len(urls); attempt++ {
+		index := (start + attempt) % len(urls)
+		if err := c.dialWSS(urls[index]); err != nil {
+			lastErr = err
+			log.Warnf("WSS endpoint %s failed: %v", maskEndpoint(urls[index]), err)
+			continue
+		}
+
+		c.wssMu.Lock()
+		c.wssIndex = index
+		c.wssMu.Unlock()
+		return nil
+	}
+
+	return lastErr
+}
+
+// dialWSS connects to a single WebSocket endpoint
+func (c *Client) dialWSS(wssURL string) error {
 	ctx, cancel := context.WithTimeout(c.ctx, time.Duration(c.config.ConnectionTimeout)*time.Second)
 	defer cancel()
 
-	client, err := ethclient.DialContext(ctx, c.config.RPCWSSUrl)
+	client, err := ethclient.DialContext(ctx, wssURL)
 	if err != nil {
 		return fmt.Errorf("WSS dial failed: %w", err)
 	}
@@ -84,11 +169,32 @@ func (c *Client) connectWSS() error {
 		return fmt.Errorf("WSS connection test failed: %w", err)
 	}
 
+	c.wssMu.Lock()
 	c.wssClient = client
+	c.wssMu.Unlock()
+
 	log.Info("WebSocket RPC client connected")
 	return nil
 }
 
+// ReconnectWSS drops the current WebSocket connection and dials a new one,
+// failing over to the next configured WSS endpoint first
+func (c *Client) ReconnectWSS() error {
+	c.wssMu.Lock()
+	old := c.wssClient
+	c.wssClient = nil
+	if len(c.config.RPCWSSUrls) > 1 {
+		c.wssIndex = (c.wssIndex + 1) % len(c.config.RPCWSSUrls)
+	}
+	c.wssMu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+
+	return c.connectWSS()
+}
+
 // GetHTTPClient returns the HTTP client
 func (c *Client) GetHTTPClient() *ethclient.Client {
 	return c.httpClient
@@ -96,6 +202,9 @@ func (c *Client) GetHTTPClient() *ethclient.Client {
 
 // GetWSSClient returns the WebSocket client
 func (c *Client) GetWSSClient() *ethclient.Client {
+	c.wssMu.RLock()
+	defer c.wssMu.RUnlock()
+
 	return c.wssClient
 }
 
@@ -138,6 +247,32 @@ func (c *Client) GetBlock(number uint64) (*types.Block, error) {
 	return block, nil
 }
 
+// GetHeader returns a block header by number
+func (c *Client) GetHeader(number uint64) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+	defer cancel()
+
+	header, err := c.httpClient.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header %d: %w", number, err)
+	}
+
+	return header, nil
+}
+
+// GetHeaderByHash returns a block header by hash
+func (c *Client) GetHeaderByHash(hash common.Hash) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+	defer cancel()
+
+	header, err := c.httpClient.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header %s: %w", hash.Hex(), err)
+	}
+
+	return header, nil
+}
+
 // GetGasPrice returns the suggested gas price
 func (c *Client) GetGasPrice() (*big.Int, error) {
 	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
@@ -164,13 +299,41 @@ func (c *Client) GetChainID() (*big.Int, error) {
 	return chainID, nil
 }
 
+// GetEndpointStats returns health statistics of the HTTPS endpoints
+func (c *Client) GetEndpointStats() []EndpointStats {
+	return c.endpoints.Stats()
+}
+
+// GetRPCStats returns per-method RPC call counters
+func (c *Client) GetRPCStats() []MethodStats {
+	return c.limiter.Stats()
+}
+
+// LogRPCStats logs per-method RPC call counters and cache efficiency
+func (c *Client) LogRPCStats() {
+	c.limiter.LogStats()
+
+	hits, misses, entries := c.cache.Stats()
+	log.Infof("RPC cache: %d hits, %d misses, %d entries", hits, misses, entries)
+}
+
 // Close closes all client connections
 func (c *Client) Close() {
 	c.cancel()
+	if c.endpoints != nil {
+		c.endpoints.Stop()
+	}
+	if c.cache != nil {
+		if err := c.cache.Save(); err != nil {
+			log.Warnf("Failed to save RPC cache: %v", err)
+		}
+	}
 	if c.httpClient != nil {
 		c.httpClient.Close()
 		log.Info("HTTPS client closed")
 	}
+	c.wssMu.Lock()
+	defer c.wssMu.Unlock()
 	if c.wssClient != nil {
 		c.wssClient.Close()
 		log.Info("WSS client closed")
@@ -179,6 +342,10 @@ func (c *Client) Close() {
 
 // HealthCheck performs a health check on the connection
 func (c *Client) HealthCheck() error {
+	if c.endpoints.HealthyCount() == 0 {
+		return fmt.Errorf("health check failed: no healthy RPC endpoints")
+	}
+
 	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
 	defer cancel()
 
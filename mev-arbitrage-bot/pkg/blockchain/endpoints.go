@@ -0,0 +1,506 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Endpoint scoring weights
+const (
+	endpointEWMAWeight     = 0.2   // Weight of the newest sample in latency/error averages
+	endpointLagPenaltyMs   = 100.0 // Score penalty per block behind the best endpoint
+	endpointErrorPenaltyMs = 2000.0
+	endpointCheckTimeout   = 5 * time.Second
+)
+
+// hedgedMethods are latency-critical calls sent to a second endpoint when
+// the first one is slow to respond
+var hedgedMethods = map[string]bool{
+	"eth_call":        true,
+	"eth_estimateGas": true,
+}
+
+// nonRetryableMethods are never sent twice: a send that timed out or failed
+// on one endpoint may still have reached the network
+var nonRetryableMethods = map[string]bool{
+	"eth_sendRawTransaction": true,
+}
+
+// endpointErrorCodes are JSON-RPC error codes that report a problem with the
+// endpoint (rate limits, exhausted quota) rather than with the request. Errors
+// are classified by code only: reverts (code 3, or -32000 "execution
+// reverted: ...") can carry any message and must reach the caller.
+var endpointErrorCodes = map[int]bool{
+	-32005: true, // Limit exceeded (Infura, QuickNode)
+	-32016: true, // Rate limit (Ankr)
+	429:    true, // Compute units exceeded (Alchemy)
+}
+
+// Endpoint is a single RPC endpoint with its health statistics
+type Endpoint struct {
+	URL         string
+	parsedURL   *url.URL
+	latency     time.Duration // EWMA of response latency
+	errorRate   float64       // EWMA of failed requests (0..1)
+	blockNumber uint64        // Latest block reported by the health check
+	checkFailed bool          // Whether the last health check failed
+}
+
+// EndpointStats is a snapshot of an endpoint's health
+type EndpointStats struct {
+	URL         string
+	Latency     time.Duration
+	ErrorRate   float64
+	BlockNumber uint64
+	BlockLag    uint64
+	Healthy     bool
+	Score       float64
+}
+
+// EndpointPool routes JSON-RPC requests over HTTP to the healthiest of
+// several endpoints. It implements http.RoundTripper so it can sit under
+// the rpc.Client used by ethclient.
+type EndpointPool struct {
+	endpoints   []*Endpoint
+	transport   http.RoundTripper
+	maxBlockLag uint64
+	hedgeDelay  time.Duration
+	mu          sync.RWMutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// NewEndpointPool creates an endpoint pool for the given HTTP(S) URLs
+func NewEndpointPool(urls []string, maxBlockLag uint64, hedgeDelay time.Duration) (*EndpointPool, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no RPC endpoints configured")
+	}
+
+	endpoints := make([]*Endpoint, 0, len(urls))
+	for _, rawURL := range urls {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RPC endpoint %q: %w", rawURL, err)
+		}
+		endpoints = append(endpoints, &Endpoint{URL: rawURL, parsedURL: parsed})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &EndpointPool{
+		endpoints:   endpoints,
+		transport:   http.DefaultTransport,
+		maxBlockLag: maxBlockLag,
+		hedgeDelay:  hedgeDelay,
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// Start runs an initial health check and then checks periodically
+func (p *EndpointPool) Start(interval time.Duration) {
+	p.checkAll()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				p.checkAll()
+			}
+		}
+	}()
+}
+
+// Stop stops periodic health checks
+func (p *EndpointPool) Stop() {
+	p.cancel()
+}
+
+// RoundTrip sends the request to the best endpoint, failing over to the
+// next one on transport errors, 5xx or rate-limit responses. Transaction
+// sends go to the best endpoint only and are never retried.
+func (p *EndpointPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	ranked := p.ranked()
+	methods := rpcMethods(body)
+
+	if !retryable(methods) {
+		resp, err := p.send(req.Context(), req, ranked[0], body)
+		if err != nil {
+			return nil, fmt.Errorf("RPC endpoint failed (not retried): %w", err)
+		}
+		return resp, nil
+	}
+
+	if len(ranked) > 1 && len(methods) == 1 && hedgedMethods[methods[0]] {
+		return p.hedge(req, body, ranked)
+	}
+
+	var lastErr error
+	for _, ep := range ranked {
+		resp, err := p.send(req.Context(), req, ep, body)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+
+		if req.Context().Err() != nil {
+			break
+		}
+		log.Debugf("RPC endpoint %s failed, trying next: %v", maskEndpoint(ep.URL), err)
+	}
+
+	return nil, fmt.Errorf("all RPC endpoints failed: %w", lastErr)
+}
+
+// hedge sends the request to the best endpoint and, if it has not answered
+// within the hedge delay (or failed), to the next one; the first success wins
+func (p *EndpointPool) hedge(req *http.Request, body []byte, ranked []*Endpoint) (*http.Response, error) {
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	results := make(chan result, len(ranked))
+	launched := 0
+	launch := func() {
+		ep := ranked[launched]
+		launched++
+		go func() {
+			resp, err := p.send(ctx, req, ep, body)
+			results <- result{resp, err}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(p.hedgeDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	for received := 0; received < launched; {
+		select {
+		case <-timer.C:
+			if launched < 2 {
+				launch()
+			}
+
+		case r := <-results:
+			received++
+			if r.err == nil {
+				return r.resp, nil
+			}
+			lastErr = r.err
+
+			// Fail over immediately instead of waiting for the hedge timer
+			if launched < len(ranked) && ctx.Err() == nil {
+				launch()
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("all RPC endpoints failed: %w", lastErr)
+}
+
+// send performs a single request against an endpoint and records its outcome.
+// The response body is fully read so the request context may be cancelled.
+func (p *EndpointPool) send(ctx context.Context, req *http.Request, ep *Endpoint, body []byte) (*http.Response, error) {
+	out := req.Clone(ctx)
+	out.URL = ep.parsedURL
+	out.Host = ""
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	out.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	start := time.Now()
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		p.record(ep, time.Since(start), false)
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		p.record(ep, time.Since(start), false)
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		p.record(ep, time.Since(start), false)
+		return nil, fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+
+	// Providers report rate limits as JSON-RPC errors with HTTP 200
+	if err := endpointRPCError(respBody); err != nil {
+		p.record(ep, time.Since(start), false)
+		return nil, err
+	}
+
+	p.record(ep, time.Since(start), true)
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+	return resp, nil
+}
+
+// record updates an endpoint's latency and error averages
+func (p *EndpointPool) record(ep *Endpoint, latency time.Duration, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	failed := 0.0
+	if !ok {
+		failed = 1.0
+	}
+	ep.errorRate = ep.errorRate*(1-endpointEWMAWeight) + failed*endpointEWMAWeight
+
+	if ok {
+		if ep.latency == 0 {
+			ep.latency = latency
+		} else {
+			ep.latency = time.Duration(float64(ep.latency)*(1-endpointEWMAWeight) + float64(latency)*endpointEWMAWeight)
+		}
+	}
+}
+
+// checkAll health-checks every endpoint concurrently
+func (p *EndpointPool) checkAll() {
+	var wg sync.WaitGroup
+	for _, ep := range p.endpoints {
+		wg.Add(1)
+		go func(ep *Endpoint) {
+			defer wg.Done()
+			p.check(ep)
+		}(ep)
+	}
+	wg.Wait()
+
+	for _, stats := range p.Stats() {
+		if !stats.Healthy {
+			log.Warnf("RPC endpoint %s unhealthy (lag=%d, errors=%.0f%%)",
+				maskEndpoint(stats.URL), stats.BlockLag, stats.ErrorRate*100)
+			continue
+		}
+		log.Debugf("RPC endpoint %s: latency=%v, block=%d, score=%.0f",
+			maskEndpoint(stats.URL), stats.Latency, stats.BlockNumber, stats.Score)
+	}
+}
+
+// check queries eth_blockNumber on an endpoint to measure latency and height
+func (p *EndpointPool) check(ep *Endpoint) {
+	ctx, cancel := context.WithTimeout(p.ctx, endpointCheckTimeout)
+	defer cancel()
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, nil)
+	if err != nil {
+		p.markCheck(ep, 0, false)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.send(ctx, req, ep, body)
+	if err != nil {
+		p.markCheck(ep, 0, false)
+		return
+	}
+
+	var result struct {
+		Result string `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		p.markCheck(ep, 0, false)
+		return
+	}
+
+	blockNumber, err := strconv.ParseUint(strings.TrimPrefix(result.Result, "0x"), 16, 64)
+	if err != nil {
+		p.markCheck(ep, 0, false)
+		return
+	}
+
+	p.markCheck(ep, blockNumber, true)
+}
+
+// markCheck stores the result of a health check
+func (p *EndpointPool) markCheck(ep *Endpoint, blockNumber uint64, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ep.checkFailed = !ok
+	if ok {
+		ep.blockNumber = blockNumber
+	}
+}
+
+// ranked returns endpoints ordered from healthiest to least healthy
+func (p *EndpointPool) ranked() []*Endpoint {
+	stats := p.Stats()
+
+	order := make([]int, len(stats))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return stats[order[a]].Score < stats[order[b]].Score
+	})
+
+	ranked := make([]*Endpoint, len(order))
+	for i, idx := range order {
+		ranked[i] = p.endpoints[idx]
+	}
+	return ranked
+}
+
+// Stats returns health statistics for every endpoint, in configuration order
+func (p *EndpointPool) Stats() []EndpointStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var bestBlock uint64
+	for _, ep := range p.endpoints {
+		if ep.blockNumber > bestBlock {
+			bestBlock = ep.blockNumber
+		}
+	}
+
+	stats := make([]EndpointStats, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		lag := bestBlock - ep.blockNumber
+		healthy := !ep.checkFailed && lag <= p.maxBlockLag
+
+		score := float64(ep.latency.Milliseconds()) +
+			float64(lag)*endpointLagPenaltyMs +
+			ep.errorRate*endpointErrorPenaltyMs
+		if !healthy {
+			score = math.MaxFloat64
+		}
+
+		stats = append(stats, EndpointStats{
+			URL:         ep.URL,
+			Latency:     ep.latency,
+			ErrorRate:   ep.errorRate,
+			BlockNumber: ep.blockNumber,
+			BlockLag:    lag,
+			Healthy:     healthy,
+			Score:       score,
+		})
+	}
+
+	return stats
+}
+
+// HealthyCount returns the number of endpoints currently considered healthy
+func (p *EndpointPool) HealthyCount() int {
+	count := 0
+	for _, stats := range p.Stats() {
+		if stats.Healthy {
+			count++
+		}
+	}
+	return count
+}
+
+// rpcMethods extracts the method names of a JSON-RPC request or batch
+func rpcMethods(body []byte) []string {
+	var single struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &single); err == nil {
+		return []string{single.Method}
+	}
+
+	var batch []struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil
+	}
+
+	methods := make([]string, 0, len(batch))
+	for _, call := range batch {
+		methods = append(methods, call.Method)
+	}
+	return methods
+}
+
+// retryable reports whether a request may be sent to more than one endpoint
+func retryable(methods []string) bool {
+	for _, method := range methods {
+		if nonRetryableMethods[method] {
+			return false
+		}
+	}
+	return true
+}
+
+// endpointRPCError returns an error if a JSON-RPC response (or any response
+// in a batch) carries an error caused by the endpoint rather than the request
+func endpointRPCError(body []byte) error {
+	type rpcError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	type reply struct {
+		Error *rpcError `json:"error"`
+	}
+
+	var replies []reply
+	var single reply
+	if err := json.Unmarshal(body, &single); err == nil {
+		replies = []reply{single}
+	} else if err := json.Unmarshal(body, &replies); err != nil {
+		return nil
+	}
+
+	for _, r := range replies {
+		if r.Error == nil {
+			continue
+		}
+		if endpointErrorCodes[r.Error.Code] {
+			return fmt.Errorf("endpoint returned JSON-RPC error %d: %s", r.Error.Code, r.Error.Message)
+		}
+	}
+	return nil
+}
+
+// maskEndpoint hides API keys in endpoint URLs for logging
+func maskEndpoint(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "***"
+	}
+	return parsed.Scheme + "://" + parsed.Host + "/***"
+}
@@ -0,0 +1,156 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeEndpoint is a JSON-RPC server that answers every request with a
+// fixed HTTP status and reply, counting calls per method
+type fakeEndpoint struct {
+	status int
+	reply  string // JSON-RPC reply fields after the id, e.g. `"result":"0x1"`
+	calls  map[string]int
+	mu     sync.Mutex
+}
+
+func newFakeEndpoint(t *testing.T, status int, reply string) (*fakeEndpoint, string) {
+	endpoint := &fakeEndpoint{status: status, reply: reply, calls: make(map[string]int)}
+	server := httptest.NewServer(endpoint)
+	t.Cleanup(server.Close)
+	return endpoint, server.URL
+}
+
+func (e *fakeEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	e.mu.Lock()
+	e.calls[req.Method]++
+	e.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.status)
+	w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,` + e.reply + `}`))
+}
+
+func (e *fakeEndpoint) callCount(method string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls[method]
+}
+
+// newTestPool builds a pool over the given URLs, ranked in configuration order
+func newTestPool(t *testing.T, urls ...string) (*EndpointPool, *rpc.Client) {
+	pool, err := NewEndpointPool(urls, 5, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Stop)
+
+	client, err := rpc.DialOptions(context.Background(), urls[0], rpc.WithHTTPClient(&http.Client{Transport: pool}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return pool, client
+}
+
+func TestEndpointPoolFailsOverOnServerError(t *testing.T) {
+	broken, brokenURL := newFakeEndpoint(t, http.StatusBadGateway, `"result":"0x1"`)
+	healthy, healthyURL := newFakeEndpoint(t, http.StatusOK, `"result":"0x10"`)
+	pool, client := newTestPool(t, brokenURL, healthyURL)
+
+	var blockNumber string
+	if err := client.Call(&blockNumber, "eth_blockNumber"); err != nil {
+		t.Fatal(err)
+	}
+	if blockNumber != "0x10" || broken.callCount("eth_blockNumber") != 1 || healthy.callCount("eth_blockNumber") != 1 {
+		t.Fatalf("got %s, want the healthy endpoint's answer after one failover", blockNumber)
+	}
+	if stats := pool.Stats(); stats[0].ErrorRate == 0 || stats[1].ErrorRate != 0 {
+		t.Fatalf("error rates %.2f/%.2f, want only the broken endpoint penalized", stats[0].ErrorRate, stats[1].ErrorRate)
+	}
+}
+
+func TestEndpointPoolNeverRetriesSends(t *testing.T) {
+	broken, brokenURL := newFakeEndpoint(t, http.StatusBadGateway, `"result":"0x1"`)
+	healthy, healthyURL := newFakeEndpoint(t, http.StatusOK, `"result":"0x1"`)
+	_, client := newTestPool(t, brokenURL, healthyURL)
+
+	err := client.Call(nil, "eth_sendRawTransaction", "0x01")
+	if err == nil {
+		t.Fatal("failed send reported as success")
+	}
+	if broken.callCount("eth_sendRawTransaction") != 1 || healthy.callCount("eth_sendRawTransaction") != 0 {
+		t.Fatal("transaction send retried on another endpoint")
+	}
+}
+
+func TestEndpointPoolCountsJSONRPCRateLimits(t *testing.T) {
+	limited, limitedURL := newFakeEndpoint(t, http.StatusOK, `"error":{"code":429,"message":"Your app has exceeded its compute units per second capacity"}`)
+	healthy, healthyURL := newFakeEndpoint(t, http.StatusOK, `"result":"0x10"`)
+	pool, client := newTestPool(t, limitedURL, healthyURL)
+
+	var balance string
+	if err := client.Call(&balance, "eth_getBalance", "0x0000000000000000000000000000000000000000", "latest"); err != nil {
+		t.Fatal(err)
+	}
+	if balance != "0x10" || limited.callCount("eth_getBalance") != 1 || healthy.callCount("eth_getBalance") != 1 {
+		t.Fatalf("got %s, want failover past the rate-limited endpoint", balance)
+	}
+	if pool.Stats()[0].ErrorRate == 0 {
+		t.Fatal("rate-limited endpoint not penalized")
+	}
+}
+
+func TestEndpointPoolPassesRequestErrorsThrough(t *testing.T) {
+	reverting, revertingURL := newFakeEndpoint(t, http.StatusOK, `"error":{"code":3,"message":"execution reverted"}`)
+	other, otherURL := newFakeEndpoint(t, http.StatusOK, `"result":"0x"`)
+	pool, client := newTestPool(t, revertingURL, otherURL)
+
+	err := client.Call(nil, "eth_getCode", "0x0000000000000000000000000000000000000000", "latest")
+	if err == nil || !strings.Contains(err.Error(), "execution reverted") {
+		t.Fatalf("got %v, want the revert passed through", err)
+	}
+	if reverting.callCount("eth_getCode") != 1 || other.callCount("eth_getCode") != 0 {
+		t.Fatal("request error treated as an endpoint failure")
+	}
+	if pool.Stats()[0].ErrorRate != 0 {
+		t.Fatal("endpoint penalized for a request error")
+	}
+}
+
+func TestEndpointRPCErrorInBatch(t *testing.T) {
+	batch := []byte(`[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":-32005,"message":"limit exceeded"}}]`)
+	if endpointRPCError(batch) == nil {
+		t.Fatal("rate limit inside a batch not detected")
+	}
+	if endpointRPCError([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too low"}}`)) != nil {
+		t.Fatal("request error reported as an endpoint error")
+	}
+}
+
+func TestEndpointRPCErrorIgnoresReverts(t *testing.T) {
+	reverts := []string{
+		`{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted: gas limit exceeded","data":"0x08c379a0"}}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"execution reverted: insufficient capacity"}}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"too many requests for this pool"}}`,
+	}
+	for _, body := range reverts {
+		if err := endpointRPCError([]byte(body)); err != nil {
+			t.Fatalf("revert reported as an endpoint error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// Head subscription settings
+const (
+	headReconnectDelay    = 3 * time.Second  // Initial reconnection delay
+	headMaxReconnectDelay = 30 * time.Second // Max reconnection delay
+	headHeartbeatInterval = 30 * time.Second // Connection health check interval
+	headMaxBackfill       = 64               // Max missed blocks fetched after a gap
+	headFeedBuffer        = 16               // Per-subscriber channel buffer
+	headReorgWindow       = 64               // Recent headers kept for reorg detection
+)
+
+// ReorgEvent describes a chain reorganization
+type ReorgEvent struct {
+	CommonAncestor uint64          // Last block shared by the old and new chain
+	Depth          int             // Number of orphaned blocks
+	Orphaned       []*types.Header // Headers dropped from the canonical chain
+	NewHead        *types.Header   // Head of the new canonical chain
+}
+
+// IsOrphaned reports whether the given block hash was dropped by the reorg
+func (e *ReorgEvent) IsOrphaned(hash common.Hash) bool {
+	for _, header := range e.Orphaned {
+		if header.Hash() == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// ReorgHandler is called for every detected reorg, before the new chain's
+// heads are published to subscribers
+type ReorgHandler func(event *ReorgEvent)
+
+// HeadSource is the chain access a HeadSubscriber needs
+type HeadSource interface {
+	// SubscribeNewHead subscribes to new heads on the current connection
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+
+	// BlockNumber checks the subscription connection (heartbeat)
+	BlockNumber(ctx context.Context) (uint64, error)
+
+	// HeaderByHash fetches a header to back-fill missed blocks
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+
+	// Reconnect drops the subscription connection and dials a new one
+	Reconnect() error
+}
+
+// wssHeadSource subscribes on the client's WebSocket connection and
+// back-fills over HTTPS
+type wssHeadSource struct {
+	client *Client
+}
+
+func (s wssHeadSource) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	wssClient := s.client.GetWSSClient()
+	if wssClient == nil {
+		return nil, fmt.Errorf("WSS client not connected")
+	}
+	return wssClient.SubscribeNewHead(ctx, ch)
+}
+
+func (s wssHeadSource) BlockNumber(ctx context.Context) (uint64, error) {
+	wssClient := s.client.GetWSSClient()
+	if wssClient == nil {
+		return 0, fmt.Errorf("WSS client not connected")
+	}
+	return wssClient.BlockNumber(ctx)
+}
+
+func (s wssHeadSource) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return s.client.httpClient.HeaderByHash(ctx, hash)
+}
+
+func (s wssHeadSource) Reconnect() error {
+	return s.client.ReconnectWSS()
+}
+
+// HeadSubscriber delivers new block headers in order, reconnecting the
+// WebSocket client with exponential backoff, back-filling missed blocks and
+// detecting chain reorganizations from parent hashes
+type HeadSubscriber struct {
+	source      HeadSource
+	subscribers []chan *types.Header
+	reorgFuncs  []ReorgHandler
+	recent      []*types.Header // Canonical headers window, oldest first
+	lastNumber  uint64
+	mu          sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// NewHeadSubscriber creates a head subscription service on this client
+func (c *Client) NewHeadSubscriber() *HeadSubscriber {
+	return newHeadSubscriber(c.ctx, wssHeadSource{client: c})
+}
+
+// newHeadSubscriber creates a head subscription service on a source
+func newHeadSubscriber(ctx context.Context, source HeadSource) *HeadSubscriber {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &HeadSubscriber{
+		source: source,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Subscribe returns a channel receiving every new head. The channel is
+// closed when the subscriber stops. Must be called before Start.
+func (hs *HeadSubscriber) Subscribe() <-chan *types.Header {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	ch := make(chan *types.Header, headFeedBuffer)
+	hs.subscribers = append(hs.subscribers, ch)
+	return ch
+}
+
+// OnReorg registers a handler for chain reorganizations. Must be called before Start.
+func (hs *HeadSubscriber) OnReorg(handler ReorgHandler) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	hs.reorgFuncs = append(hs.reorgFuncs, handler)
+}
+
+// Start begins the subscription loop
+func (hs *HeadSubscriber) Start() {
+	log.Info("Starting head subscriber...")
+	go hs.run()
+}
+
+// Stop stops the subscription loop and closes all subscriber channels
+func (hs *HeadSubscriber) Stop() {
+	log.Info("Stopping head subscriber...")
+	hs.cancel()
+}
+
+// LastBlock returns the number of the most recently delivered head
+func (hs *HeadSubscriber) LastBlock() uint64 {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	return hs.lastNumber
+}
+
+// run keeps a head subscription alive until stopped
+func (hs *HeadSubscriber) run() {
+	defer hs.closeSubscribers()
+
+	reconnectDelay := headReconnectDelay
+
+	for {
+		err := hs.listen()
+		if hs.ctx.Err() != nil {
+			log.Info("Head subscriber stopped")
+			return
+		}
+
+		log.Warnf("Head subscription lost: %v, reconnecting in %v", err, reconnectDelay)
+
+		timer := time.NewTimer(reconnectDelay)
+		select {
+		case <-hs.ctx.Done():
+			timer.Stop()
+			log.Info("Head subscriber stopped")
+			return
+		case <-timer.C:
+		}
+
+		if err := hs.source.Reconnect(); err != nil {
+			log.Errorf("WSS reconnection failed: %v", err)
+
+			// Exponential backoff
+			reconnectDelay *= 2
+			if reconnectDelay > headMaxReconnectDelay {
+				reconnectDelay = headMaxReconnectDelay
+			}
+			continue
+		}
+
+		reconnectDelay = headReconnectDelay
+	}
+}
+
+// listen subscribes to new heads and forwards them until the subscription fails
+func (hs *HeadSubscriber) listen() error {
+	headers := make(chan *types.Header)
+	sub, err := hs.source.SubscribeNewHead(hs.ctx, headers)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to new heads: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	log.Info("Subscribed to new block headers")
+
+	heartbeat := time.NewTicker(headHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-hs.ctx.Done():
+			return hs.ctx.Err()
+
+		case err := <-sub.Err():
+			return fmt.Errorf("subscription error: %w", err)
+
+		case header := <-headers:
+			hs.handleHeader(header)
+
+		case <-heartbeat.C:
+			ctx, cancel := context.WithTimeout(hs.ctx, 5*time.Second)
+			_, err := hs.source.BlockNumber(ctx)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("heartbeat failed: %w", err)
+			}
+			log.Debug("Head subscription heartbeat OK")
+		}
+	}
+}
+
+// handleHeader links the header to the recent chain window, back-filling
+// missed blocks and detecting reorgs, then publishes the new canonical heads
+func (hs *HeadSubscriber) handleHeader(header *types.Header) {
+	hs.mu.Lock()
+	windowEmpty := len(hs.recent) == 0
+	hs.mu.Unlock()
+
+	if windowEmpty {
+		hs.publish(header)
+		return
+	}
+
+	if known, ok := hs.hashAt(header.Number.Uint64()); ok && known == header.Hash() {
+		log.Debugf("Ignoring duplicate head %d", header.Number.Uint64())
+		return
+	}
+
+	// Walk back through parent hashes until the new chain joins the window
+	chain := []*types.Header{header}
+	cursor := header
+	for {
+		parentNumber := cursor.Number.Uint64() - 1
+		if known, ok := hs.hashAt(parentNumber); ok && known == cursor.ParentHash {
+			break
+		}
+
+		if len(chain) > headMaxBackfill || parentNumber < hs.windowBase() {
+			log.Warnf("Head %d does not link to the last %d blocks, resetting chain window",
+				header.Number.Uint64(), headReorgWindow)
+			hs.resetWindow()
+			hs.publish(header)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(hs.ctx, 5*time.Second)
+		parent, err := hs.source.HeaderByHash(ctx, cursor.ParentHash)
+		cancel()
+		if err != nil {
+			log.Warnf("Failed to fetch parent of block %d: %v", cursor.Number.Uint64(), err)
+			hs.resetWindow()
+			hs.publish(header)
+			return
+		}
+
+		chain = append(chain, parent)
+		cursor = parent
+	}
+
+	ancestor := cursor.Number.Uint64() - 1
+	if orphaned := hs.truncateWindow(ancestor); len(orphaned) > 0 {
+		hs.emitReorg(&ReorgEvent{
+			CommonAncestor: ancestor,
+			Depth:          len(orphaned),
+			Orphaned:       orphaned,
+			NewHead:        header,
+		})
+	}
+
+	if len(chain) > 1 {
+		log.Infof("Back-filling missed blocks %d-%d",
+			chain[len(chain)-1].Number.Uint64(), chain[1].Number.Uint64())
+	}
+
+	// Publish oldest first, in one batch so readers can coalesce the gap
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	hs.publish(chain...)
+}
+
+// hashAt returns the hash of the canonical header at number, if in the window
+func (hs *HeadSubscriber) hashAt(number uint64) (common.Hash, bool) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	for _, header := range hs.recent {
+		if header.Number.Uint64() == number {
+			return header.Hash(), true
+		}
+	}
+	return common.Hash{}, false
+}
+
+// windowBase returns the oldest block number in the window
+func (hs *HeadSubscriber) windowBase() uint64 {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if len(hs.recent) == 0 {
+		return 0
+	}
+	return hs.recent[0].Number.Uint64()
+}
+
+// truncateWindow drops and returns all window headers above ancestor
+func (hs *HeadSubscriber) truncateWindow(ancestor uint64) []*types.Header {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	for i, header := range hs.recent {
+		if header.Number.Uint64() > ancestor {
+			orphaned := append([]*types.Header(nil), hs.recent[i:]...)
+			hs.recent = hs.recent[:i]
+			hs.lastNumber = ancestor
+			return orphaned
+		}
+	}
+	return nil
+}
+
+// resetWindow forgets all tracked headers
+func (hs *HeadSubscriber) resetWindow() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	hs.recent = nil
+}
+
+// emitReorg notifies all reorg handlers
+func (hs *HeadSubscriber) emitReorg(event *ReorgEvent) {
+	log.Warnf("⚠️  Chain reorg detected: depth=%d, common ancestor=%d, new head=%d",
+		event.Depth, event.CommonAncestor, event.NewHead.Number.Uint64())
+
+	hs.mu.Lock()
+	handlers := append([]ReorgHandler(nil), hs.reorgFuncs...)
+	hs.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// publish appends headers to the window and delivers them to all
+// subscribers without blocking. A lagging subscriber loses its oldest
+// queued head, never the newest.
+func (hs *HeadSubscriber) publish(headers ...*types.Header) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	for _, header := range headers {
+		hs.lastNumber = header.Number.Uint64()
+
+		hs.recent = append(hs.recent, header)
+		if len(hs.recent) > headReorgWindow {
+			hs.recent = hs.recent[len(hs.recent)-headReorgWindow:]
+		}
+
+		for _, ch := range hs.subscribers {
+			select {
+			case ch <- header:
+				continue
+			default:
+			}
+
+			select {
+			case dropped := <-ch:
+				log.Warnf("Head subscriber is lagging, dropped block %d", dropped.Number.Uint64())
+			default:
+			}
+			select {
+			case ch <- header:
+			default:
+			}
+		}
+	}
+}
+
+// closeSubscribers closes all subscriber channels
+func (hs *HeadSubscriber) closeSubscribers() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	for _, ch := range hs.subscribers {
+		close(ch)
+	}
+	hs.subscribers = nil
+}
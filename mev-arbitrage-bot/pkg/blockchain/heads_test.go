@@ -0,0 +1,155 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeHeadSource serves headers by hash for back-filling
+type fakeHeadSource struct {
+	headers map[common.Hash]*types.Header
+	fetched int
+}
+
+func (s *fakeHeadSource) SubscribeNewHead(context.Context, chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (s *fakeHeadSource) BlockNumber(context.Context) (uint64, error) { return 0, nil }
+
+func (s *fakeHeadSource) HeaderByHash(_ context.Context, hash common.Hash) (*types.Header, error) {
+	s.fetched++
+	header, exists := s.headers[hash]
+	if !exists {
+		return nil, fmt.Errorf("header %s not found", hash.Hex())
+	}
+	return header, nil
+}
+
+func (s *fakeHeadSource) Reconnect() error { return nil }
+
+// chain builds count headers on top of parent; fork distinguishes
+// competing chains at the same heights
+func (s *fakeHeadSource) chain(parent *types.Header, count int, fork uint64) []*types.Header {
+	headers := make([]*types.Header, 0, count)
+	for i := 0; i < count; i++ {
+		header := &types.Header{
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			ParentHash: parent.Hash(),
+			Time:       fork,
+		}
+		s.headers[header.Hash()] = header
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+func newTestHeadSubscriber(t *testing.T) (*HeadSubscriber, *fakeHeadSource, <-chan *types.Header, *[]*ReorgEvent) {
+	source := &fakeHeadSource{headers: make(map[common.Hash]*types.Header)}
+	hs := newHeadSubscriber(context.Background(), source)
+	t.Cleanup(hs.Stop)
+
+	feed := hs.Subscribe()
+	reorgs := new([]*ReorgEvent)
+	hs.OnReorg(func(event *ReorgEvent) { *reorgs = append(*reorgs, event) })
+	return hs, source, feed, reorgs
+}
+
+// received drains the heads delivered so far
+func received(feed <-chan *types.Header) []uint64 {
+	var numbers []uint64
+	for {
+		select {
+		case header := <-feed:
+			numbers = append(numbers, header.Number.Uint64())
+		default:
+			return numbers
+		}
+	}
+}
+
+func TestHeadSubscriberBackfillsGap(t *testing.T) {
+	hs, source, feed, reorgs := newTestHeadSubscriber(t)
+
+	genesis := &types.Header{Number: big.NewInt(100)}
+	chain := source.chain(genesis, 5, 0) // 101..105
+
+	hs.handleHeader(chain[0])
+	hs.handleHeader(chain[4]) // 102..104 missed
+
+	if got := received(feed); fmt.Sprint(got) != "[101 102 103 104 105]" {
+		t.Fatalf("delivered %v, want 101-105 in order", got)
+	}
+	if source.fetched != 3 {
+		t.Fatalf("fetched %d parents, want 3", source.fetched)
+	}
+	if len(*reorgs) != 0 {
+		t.Fatalf("gap reported as reorg: %+v", (*reorgs)[0])
+	}
+	if hs.LastBlock() != 105 {
+		t.Fatalf("last block %d, want 105", hs.LastBlock())
+	}
+
+	// A duplicate head is ignored
+	hs.handleHeader(chain[4])
+	if got := received(feed); len(got) != 0 {
+		t.Fatalf("duplicate head delivered: %v", got)
+	}
+}
+
+func TestHeadSubscriberDetectsReorg(t *testing.T) {
+	hs, source, feed, reorgs := newTestHeadSubscriber(t)
+
+	genesis := &types.Header{Number: big.NewInt(100)}
+	canonical := source.chain(genesis, 3, 0) // 101..103
+	for _, header := range canonical {
+		hs.handleHeader(header)
+	}
+	received(feed)
+
+	// A competing chain forks after 101 and overtakes at 104
+	fork := source.chain(canonical[0], 3, 1) // 102'..104'
+	hs.handleHeader(fork[2])
+
+	if len(*reorgs) != 1 {
+		t.Fatalf("got %d reorg events, want 1", len(*reorgs))
+	}
+	event := (*reorgs)[0]
+	if event.CommonAncestor != 101 || event.Depth != 2 || event.NewHead.Hash() != fork[2].Hash() {
+		t.Fatalf("reorg ancestor %d depth %d, want 101 and 2", event.CommonAncestor, event.Depth)
+	}
+	if !event.IsOrphaned(canonical[1].Hash()) || !event.IsOrphaned(canonical[2].Hash()) || event.IsOrphaned(canonical[0].Hash()) {
+		t.Fatal("wrong orphaned blocks")
+	}
+
+	if got := received(feed); fmt.Sprint(got) != "[102 103 104]" {
+		t.Fatalf("delivered %v, want the new chain 102-104", got)
+	}
+	if hash, ok := hs.hashAt(103); !ok || hash != fork[1].Hash() {
+		t.Fatal("window does not hold the new chain")
+	}
+}
+
+func TestHeadSubscriberKeepsNewestForLaggingReader(t *testing.T) {
+	hs, source, feed, _ := newTestHeadSubscriber(t)
+
+	genesis := &types.Header{Number: big.NewInt(100)}
+	chain := source.chain(genesis, headFeedBuffer+10, 0)
+	hs.handleHeader(chain[0])
+	hs.handleHeader(chain[len(chain)-1])
+
+	got := received(feed)
+	if len(got) != headFeedBuffer {
+		t.Fatalf("delivered %d heads, want a full buffer of %d", len(got), headFeedBuffer)
+	}
+	if newest := got[len(got)-1]; newest != chain[len(chain)-1].Number.Uint64() {
+		t.Fatalf("newest delivered head %d, want %d", newest, chain[len(chain)-1].Number.Uint64())
+	}
+}
@@ -0,0 +1,339 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Priority is the lane a request is scheduled in when the budget is exhausted
+type Priority int
+
+const (
+	PriorityMonitoring Priority = iota // Pool refreshes, block queries
+	PrioritySimulation                 // eth_call / eth_estimateGas before sending
+	PriorityExecution                  // Sending and tracking our transactions
+	numPriorities
+)
+
+// String returns the lane name
+func (p Priority) String() string {
+	switch p {
+	case PriorityExecution:
+		return "execution"
+	case PrioritySimulation:
+		return "simulation"
+	default:
+		return "monitoring"
+	}
+}
+
+// defaultMethodCU is the compute-unit cost per method (Alchemy pricing),
+// used for methods without a configured override
+var defaultMethodCU = map[string]int{
+	"eth_chainId":               0,
+	"net_version":               0,
+	"eth_blockNumber":           10,
+	"eth_gasPrice":              19,
+	"eth_maxPriorityFeePerGas":  10,
+	"eth_getBalance":            19,
+	"eth_getTransactionCount":   26,
+	"eth_getCode":               26,
+	"eth_getStorageAt":          17,
+	"eth_call":                  26,
+	"eth_estimateGas":           87,
+	"eth_getBlockByNumber":      16,
+	"eth_getBlockByHash":        21,
+	"eth_getLogs":               75,
+	"eth_getTransactionByHash":  17,
+	"eth_getTransactionReceipt": 15,
+	"eth_sendRawTransaction":    250,
+	"eth_feeHistory":            10,
+}
+
+// defaultUnknownMethodCU is charged for methods missing from the cost table
+const defaultUnknownMethodCU = 20
+
+// executionMethods are scheduled in the execution lane unless tagged otherwise
+var executionMethods = map[string]bool{
+	"eth_sendRawTransaction": true,
+}
+
+type priorityKey struct{}
+
+// WithPriority tags RPC calls made with the returned context with a lane
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// MethodStats holds per-method call counters
+type MethodStats struct {
+	Method      string
+	Calls       uint64
+	Errors      uint64
+	ComputeUnit uint64        // Total compute units charged
+	Throttled   uint64        // Calls that had to wait for budget
+	WaitTime    time.Duration // Total time spent waiting for budget
+}
+
+// RateLimiter is an http.RoundTripper that enforces a requests-per-second
+// limit and a compute-unit budget per second, serving waiting requests in
+// priority order (execution > simulation > monitoring)
+type RateLimiter struct {
+	next      http.RoundTripper
+	rps       float64 // 0 = unlimited
+	cuPerSec  float64 // 0 = unlimited
+	methodCU  map[string]int
+	reqTokens float64
+	cuTokens  float64
+	lastFill  time.Time
+	waiting   [numPriorities]int
+	stats     map[string]*MethodStats
+	mu        sync.Mutex
+}
+
+// NewRateLimiter wraps a transport with a request and compute-unit budget.
+// methodCU overrides the default per-method compute-unit costs.
+func NewRateLimiter(next http.RoundTripper, rps, cuPerSec float64, methodCU map[string]int) *RateLimiter {
+	costs := make(map[string]int, len(defaultMethodCU)+len(methodCU))
+	for method, cost := range defaultMethodCU {
+		costs[method] = cost
+	}
+	for method, cost := range methodCU {
+		costs[method] = cost
+	}
+
+	return &RateLimiter{
+		next:      next,
+		rps:       rps,
+		cuPerSec:  cuPerSec,
+		methodCU:  costs,
+		reqTokens: rps,
+		cuTokens:  cuPerSec,
+		lastFill:  time.Now(),
+		stats:     make(map[string]*MethodStats),
+	}
+}
+
+// RoundTrip waits for budget in the request's lane and forwards it
+func (rl *RateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	methods := rpcMethods(body)
+	cost := 0
+	for _, method := range methods {
+		cost += rl.costOf(method)
+	}
+
+	priority := rl.priorityOf(req.Context(), methods)
+
+	waited, err := rl.acquire(req.Context(), priority, len(methods), cost)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := rl.next.RoundTrip(req)
+	rl.record(methods, waited, err != nil)
+	return resp, err
+}
+
+// costOf returns the compute-unit cost of a method
+func (rl *RateLimiter) costOf(method string) int {
+	if cost, exists := rl.methodCU[method]; exists {
+		return cost
+	}
+	return defaultUnknownMethodCU
+}
+
+// priorityOf returns the lane from the context, or infers it from the methods
+func (rl *RateLimiter) priorityOf(ctx context.Context, methods []string) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+
+	for _, method := range methods {
+		if executionMethods[method] {
+			return PriorityExecution
+		}
+		if method == "eth_estimateGas" {
+			return PrioritySimulation
+		}
+	}
+	return PriorityMonitoring
+}
+
+// acquire blocks until the budget allows the request and no higher-priority
+// request is waiting. Returns how long the request waited.
+func (rl *RateLimiter) acquire(ctx context.Context, priority Priority, requests, cost int) (time.Duration, error) {
+	start := time.Now()
+	registered := false
+
+	defer func() {
+		if registered {
+			rl.mu.Lock()
+			rl.waiting[priority]--
+			rl.mu.Unlock()
+		}
+	}()
+
+	for {
+		rl.mu.Lock()
+		rl.refill()
+
+		if !rl.higherWaiting(priority) && rl.fits(requests, cost) {
+			if rl.rps > 0 {
+				rl.reqTokens -= float64(requests)
+			}
+			if rl.cuPerSec > 0 {
+				rl.cuTokens -= float64(cost)
+			}
+			rl.mu.Unlock()
+			return time.Since(start), nil
+		}
+
+		if !registered {
+			rl.waiting[priority]++
+			registered = true
+		}
+		wait := rl.timeUntilFit(requests, cost)
+		rl.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return time.Since(start), fmt.Errorf("rate limiter: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// refill adds tokens for the time elapsed since the last refill. Buckets
+// hold at most one second of budget.
+func (rl *RateLimiter) refill() {
+	now := time.Now()
+	elapsed := now.Sub(rl.lastFill).Seconds()
+	rl.lastFill = now
+
+	rl.reqTokens = min(rl.rps, rl.reqTokens+elapsed*rl.rps)
+	rl.cuTokens = min(rl.cuPerSec, rl.cuTokens+elapsed*rl.cuPerSec)
+}
+
+// fits reports whether the buckets hold enough tokens. Requests larger than
+// a full bucket are let through once the bucket is full.
+func (rl *RateLimiter) fits(requests, cost int) bool {
+	if rl.rps > 0 && rl.reqTokens < min(float64(requests), rl.rps) {
+		return false
+	}
+	if rl.cuPerSec > 0 && rl.cuTokens < min(float64(cost), rl.cuPerSec) {
+		return false
+	}
+	return true
+}
+
+// timeUntilFit estimates how long until the request fits the budget
+func (rl *RateLimiter) timeUntilFit(requests, cost int) time.Duration {
+	wait := 5 * time.Millisecond
+
+	if rl.rps > 0 {
+		missing := min(float64(requests), rl.rps) - rl.reqTokens
+		if d := time.Duration(missing / rl.rps * float64(time.Second)); d > wait {
+			wait = d
+		}
+	}
+	if rl.cuPerSec > 0 {
+		missing := min(float64(cost), rl.cuPerSec) - rl.cuTokens
+		if d := time.Duration(missing / rl.cuPerSec * float64(time.Second)); d > wait {
+			wait = d
+		}
+	}
+
+	// Re-check often enough to notice higher-priority waiters finishing
+	return min(wait, 50*time.Millisecond)
+}
+
+// higherWaiting reports whether a request in a higher lane is waiting
+func (rl *RateLimiter) higherWaiting(priority Priority) bool {
+	for p := priority + 1; p < numPriorities; p++ {
+		if rl.waiting[p] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// record updates per-method counters
+func (rl *RateLimiter) record(methods []string, waited time.Duration, failed bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for _, method := range methods {
+		stats, exists := rl.stats[method]
+		if !exists {
+			stats = &MethodStats{Method: method}
+			rl.stats[method] = stats
+		}
+
+		stats.Calls++
+		stats.ComputeUnit += uint64(rl.costOf(method))
+		if failed {
+			stats.Errors++
+		}
+		if waited > time.Millisecond {
+			stats.Throttled++
+			stats.WaitTime += waited
+		}
+	}
+}
+
+// Stats returns per-method counters sorted by compute units used
+func (rl *RateLimiter) Stats() []MethodStats {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	stats := make([]MethodStats, 0, len(rl.stats))
+	for _, s := range rl.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].ComputeUnit > stats[j].ComputeUnit
+	})
+
+	return stats
+}
+
+// LogStats logs the per-method call counters
+func (rl *RateLimiter) LogStats() {
+	stats := rl.Stats()
+	if len(stats) == 0 {
+		return
+	}
+
+	var totalCalls, totalCU uint64
+	for _, s := range stats {
+		totalCalls += s.Calls
+		totalCU += s.ComputeUnit
+	}
+
+	log.Infof("RPC usage: %d calls, %d CU", totalCalls, totalCU)
+	for _, s := range stats {
+		log.Infof("  %-28s calls=%-6d cu=%-8d errors=%-4d throttled=%d (%v)",
+			s.Method, s.Calls, s.ComputeUnit, s.Errors, s.Throttled, s.WaitTime.Round(time.Millisecond))
+	}
+}
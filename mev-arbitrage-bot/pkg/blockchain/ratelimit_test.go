@@ -0,0 +1,147 @@
+package blockchain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTransport answers every request and records the method order
+type recordingTransport struct {
+	methods []string
+	mu      sync.Mutex
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+
+	t.mu.Lock()
+	t.methods = append(t.methods, rpcMethods(body)...)
+	t.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`)),
+		Request:    req,
+	}, nil
+}
+
+func (t *recordingTransport) order() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.methods...)
+}
+
+func rpcRequest(t *testing.T, ctx context.Context, method string) *http.Request {
+	body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[]}`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://node", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func roundTrip(t *testing.T, rl *RateLimiter, ctx context.Context, method string) {
+	resp, err := rl.RoundTrip(rpcRequest(t, ctx, method))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func TestRateLimiterRequestBudget(t *testing.T) {
+	rl := NewRateLimiter(&recordingTransport{}, 20, 0, nil)
+
+	// A full bucket serves a burst of 20, the next 5 wait for refill
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		roundTrip(t, rl, context.Background(), "eth_blockNumber")
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("25 requests at 20 rps took %v, want about 250ms", elapsed)
+	}
+
+	stats := rl.Stats()
+	if len(stats) != 1 || stats[0].Calls != 25 || stats[0].Throttled == 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestRateLimiterComputeUnitBudget(t *testing.T) {
+	rl := NewRateLimiter(&recordingTransport{}, 0, 100, map[string]int{"eth_call": 50})
+
+	// Two calls use the full second of compute units; the third waits
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		roundTrip(t, rl, context.Background(), "eth_call")
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("150 CU at 100 CU/s took %v, want about 500ms", elapsed)
+	}
+	if stats := rl.Stats(); stats[0].ComputeUnit != 150 {
+		t.Fatalf("charged %d CU, want 150", stats[0].ComputeUnit)
+	}
+}
+
+func TestRateLimiterServesHigherLanesFirst(t *testing.T) {
+	transport := &recordingTransport{}
+	rl := NewRateLimiter(transport, 10, 0, nil)
+
+	// Drain the bucket so every following request has to wait
+	for i := 0; i < 10; i++ {
+		roundTrip(t, rl, context.Background(), "eth_chainId")
+	}
+
+	// Queue one request per lane, lowest first
+	lanes := []struct {
+		method   string
+		priority Priority
+	}{
+		{"eth_blockNumber", PriorityMonitoring},
+		{"eth_call", PrioritySimulation},
+		{"eth_getTransactionReceipt", PriorityExecution},
+	}
+	var wg sync.WaitGroup
+	for _, lane := range lanes {
+		wg.Add(1)
+		go func(method string, priority Priority) {
+			defer wg.Done()
+			roundTrip(t, rl, WithPriority(context.Background(), priority), method)
+		}(lane.method, lane.priority)
+		time.Sleep(20 * time.Millisecond)
+	}
+	wg.Wait()
+
+	order := transport.order()[10:]
+	want := []string{"eth_getTransactionReceipt", "eth_call", "eth_blockNumber"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("served %v, want %v", order, want)
+		}
+	}
+}
+
+func TestRateLimiterInfersLane(t *testing.T) {
+	rl := NewRateLimiter(&recordingTransport{}, 0, 0, nil)
+
+	cases := []struct {
+		ctx     context.Context
+		methods []string
+		want    Priority
+	}{
+		{context.Background(), []string{"eth_sendRawTransaction"}, PriorityExecution},
+		{context.Background(), []string{"eth_estimateGas"}, PrioritySimulation},
+		{context.Background(), []string{"eth_call"}, PriorityMonitoring},
+		{WithPriority(context.Background(), PrioritySimulation), []string{"eth_call"}, PrioritySimulation},
+	}
+	for _, c := range cases {
+		if got := rl.priorityOf(c.ctx, c.methods); got != c.want {
+			t.Errorf("%v: lane %s, want %s", c.methods, got, c.want)
+		}
+	}
+}
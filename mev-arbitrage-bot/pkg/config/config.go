@@ -18,6 +18,8 @@ type Config struct {
 	Network             string
 	RPCHTTPSUrl         string
 	RPCWSSUrl           string
+	RPCHTTPSUrls        []string // Primary HTTPS endpoint followed by fallbacks
+	RPCWSSUrls          []string // Primary WSS endpoint followed by fallbacks
 	FlashbotsRelay      string
 	FlashbotsSigningKey string
 
@@ -26,24 +28,88 @@ type Config struct {
 	PublicAddress common.Address
 
 	// Contract Addresses
-	ArbitrageContract common.Address
+	ArbitrageContract common.Address // FlashLoanArbitrage (borrows the start amount)
+	CapitalContract   common.Address // FlashArbitrage (trades its own inventory)
 	AavePoolProvider  common.Address
+	BalancerVault     common.Address
 
 	// DEX Router Addresses
 	UniswapV2Router common.Address
 	SushiswapRouter common.Address
 
+	// Pair init code hashes (for offline pair address derivation)
+	UniswapV2InitCodeHash common.Hash
+	SushiSwapInitCodeHash common.Hash
+
 	// Token Addresses
 	WETHAddress common.Address
 	USDCAddress common.Address
 	DAIAddress  common.Address
 
+	// Token metadata overrides (JSON token list)
+	TokenListFile string
+
+	// Token Safety (fee-on-transfer / honeypot detection)
+	TokenSafetyCheck bool // Simulate a buy/sell round trip before monitoring a pool
+	TokenMaxTaxBps   int  // Tokens taxed above this are excluded
+
+	// Local Simulation
+	LocalSimulation      bool   // Pre-flight transactions on a local fork; fallback for relay simulation
+	SimulationFixtureDir string // Failed pre-flights are recorded here for offline replay ("" = off)
+
 	// Strategy Parameters
 	MinProfitBps       int
+	MinProfitETH       *big.Float // Minimum net profit after gas, valued in ETH
 	MaxTradeAmountETH  *big.Float
 	MinTradeAmountETH  *big.Float
 	GasPriceMultiplier float64
 	MaxGasPriceGwei    uint64
+	GasLimitMultiplier float64          // Gas limit = modeled or estimated gas x multiplier
+	MaxHops            int              // Longest arbitrage cycle searched (2..N pools)
+	StartTokens        []common.Address // Tokens arbitrage cycles start from (defaults to WETH)
+
+	// Execution Funding
+	ExecutionMode         string                        // flashloan, capital or auto (capital when inventory suffices)
+	InventoryTargets      map[common.Address]*big.Float // Target capital contract balance per token, in whole tokens
+	RebalanceInterval     int                           // Seconds between inventory rebalances (0 = off)
+	RebalanceToleranceBps int                           // Deviation from target tolerated before rebalancing
+
+	// Risk limits; exceeding one trips a circuit breaker that forces dry-run
+	RiskMaxDailyLossETH       *big.Float                    // Realized loss per UTC day (0 = off)
+	RiskMaxConsecutiveReverts int                           // Mined reverts in a row (0 = off)
+	RiskMaxGasPerHourETH      *big.Float                    // Gas spent over the last hour (0 = off)
+	RiskExposureCaps          map[common.Address]*big.Float // Max amount in flight per token, in whole tokens
+	RiskMinWalletBalanceETH   *big.Float                    // Wallet ETH kept for gas (0 = off)
+	RiskStateFile             string                        // Persisted breaker state and daily P&L ("" = memory only)
+	AdminAPIAddr              string                        // Admin API listen address ("" = off)
+	AdminAPIToken             string                        // Bearer token required by the admin API
+
+	// Opportunity tracking per route (canonical cycle)
+	OpportunityPendingBlocks int // Blocks an unconfirmed attempt suppresses its route
+	RevertBackoffAfter       int // Consecutive reverts of a route before it backs off
+	RevertBackoffBlocks      int // First backoff, doubled per further revert
+	RevertBackoffMaxBlocks   int // Backoff cap
+
+	// Per-hop minimum outputs passed to the contracts
+	SlippagePolicy string // conservative, balanced or aggressive
+	SlippageMaxBps int    // Cap on any hop's tolerance (0 = policy default)
+
+	// Flash-loan sources compared per opportunity (aave_v3, balancer, uniswap_v2_flash_swap)
+	FlashLoanProviders []string
+
+	// Pricing (token amounts valued in ETH)
+	PriceMinLiquidityETH *big.Float                    // Pools shallower than this are not used for pricing
+	PriceFallbackETH     map[common.Address]*big.Float // ETH per whole token for tokens without a pool path to WETH
+
+	// Strategies
+	EnableCycleStrategy    bool // N-hop cycle search over the token graph
+	EnableCrossDEXStrategy bool // Same pair priced differently on two DEXs
+	EnableBackrunStrategy  bool // Arbitrage left by pending swaps (needs the mempool and Flashbots)
+	StrategyTimeoutMs      int  // Per-strategy time budget per block
+
+	// Mempool
+	EnableMempool    bool // Decode pending router swaps from the WSS endpoint
+	MempoolMaxAgeSec int  // Pending swaps not mined within this time are dropped
 
 	// Monitoring Configuration
 	LogLevel         string
@@ -57,6 +123,18 @@ type Config struct {
 	ConnectionTimeout   int
 	MaxRetryAttempts    int
 	PoolMonitorInterval int
+
+	// RPC Endpoint Pool
+	RPCHealthCheckInterval int    // Seconds between endpoint health checks
+	RPCMaxBlockLag         uint64 // Blocks behind the best endpoint before marked unhealthy
+	RPCHedgeDelayMs        int    // Delay before a hedged request goes to the next endpoint
+
+	// RPC Rate Limiting
+	RPCRateLimitRPS  float64        // Max requests per second (0 = unlimited)
+	RPCComputeUnits  float64        // Max compute units per second (0 = unlimited)
+	RPCMethodCU      map[string]int // Per-method compute-unit cost overrides
+	RPCStatsInterval int            // Seconds between RPC usage reports (0 = off)
+	RPCCacheFile     string         // Persistence file for immutable RPC data ("" = memory only)
 }
 
 var globalConfig *Config
@@ -82,6 +160,9 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("RPC_HTTPS_URL and RPC_WSS_URL are required")
 	}
 
+	cfg.RPCHTTPSUrls = append([]string{cfg.RPCHTTPSUrl}, getEnvAsList("RPC_HTTPS_FALLBACK_URLS")...)
+	cfg.RPCWSSUrls = append([]string{cfg.RPCWSSUrl}, getEnvAsList("RPC_WSS_FALLBACK_URLS")...)
+
 	// Wallet Configuration
 	cfg.PrivateKey = getEnv("PRIVATE_KEY", "")
 	if cfg.PrivateKey == "" {
@@ -96,23 +177,91 @@ func LoadConfig() (*Config, error) {
 
 	// Contract Addresses
 	cfg.ArbitrageContract = common.HexToAddress(getEnv("ARBITRAGE_CONTRACT_ADDRESS", ""))
+	cfg.CapitalContract = common.HexToAddress(getEnv("CAPITAL_CONTRACT_ADDRESS", ""))
 	cfg.AavePoolProvider = common.HexToAddress(getEnv("AAVE_POOL_PROVIDER", ""))
+	cfg.BalancerVault = common.HexToAddress(getEnv("BALANCER_VAULT", BalancerVaultAddress))
+	cfg.ExecutionMode = strings.ToLower(getEnv("EXECUTION_MODE", ExecutionModeFlashLoan))
+	switch cfg.ExecutionMode {
+	case ExecutionModeFlashLoan, ExecutionModeCapital, ExecutionModeAuto:
+	default:
+		return nil, fmt.Errorf("invalid EXECUTION_MODE %q (flashloan, capital or auto)", cfg.ExecutionMode)
+	}
+	cfg.InventoryTargets = getEnvAsAmountMap("INVENTORY_TARGETS")
+	cfg.RebalanceInterval = getEnvAsInt("REBALANCE_INTERVAL", 300)
+	cfg.RebalanceToleranceBps = getEnvAsInt("REBALANCE_TOLERANCE_BPS", 2000)
+	cfg.RiskMaxDailyLossETH = parseEther(getEnv("RISK_MAX_DAILY_LOSS_ETH", "0.1"))
+	cfg.RiskMaxConsecutiveReverts = getEnvAsInt("RISK_MAX_CONSECUTIVE_REVERTS", 5)
+	cfg.RiskMaxGasPerHourETH = parseEther(getEnv("RISK_MAX_GAS_PER_HOUR_ETH", "0.05"))
+	cfg.RiskExposureCaps = getEnvAsAmountMap("RISK_EXPOSURE_CAPS")
+	cfg.RiskMinWalletBalanceETH = parseEther(getEnv("RISK_MIN_WALLET_BALANCE_ETH", "0.05"))
+	cfg.RiskStateFile = getEnv("RISK_STATE_FILE", "data/risk_state.json")
+	cfg.AdminAPIAddr = getEnv("ADMIN_API_ADDR", "")
+	cfg.AdminAPIToken = getEnv("ADMIN_API_TOKEN", "")
+	cfg.OpportunityPendingBlocks = getEnvAsInt("OPPORTUNITY_PENDING_BLOCKS", 2)
+	cfg.RevertBackoffAfter = getEnvAsInt("REVERT_BACKOFF_AFTER", 2)
+	cfg.RevertBackoffBlocks = getEnvAsInt("REVERT_BACKOFF_BLOCKS", 5)
+	cfg.RevertBackoffMaxBlocks = getEnvAsInt("REVERT_BACKOFF_MAX_BLOCKS", 300)
+	cfg.SlippagePolicy = strings.ToLower(getEnv("SLIPPAGE_POLICY", SlippagePolicyBalanced))
+	switch cfg.SlippagePolicy {
+	case SlippagePolicyConservative, SlippagePolicyBalanced, SlippagePolicyAggressive:
+	default:
+		return nil, fmt.Errorf("invalid SLIPPAGE_POLICY %q (conservative, balanced or aggressive)", cfg.SlippagePolicy)
+	}
+	cfg.SlippageMaxBps = getEnvAsInt("SLIPPAGE_MAX_BPS", 0)
+	cfg.FlashLoanProviders = getEnvAsList("FLASH_LOAN_PROVIDERS")
+	if len(cfg.FlashLoanProviders) == 0 {
+		cfg.FlashLoanProviders = []string{"aave_v3"}
+	}
 
 	// DEX Router Addresses
 	cfg.UniswapV2Router = common.HexToAddress(getEnv("UNISWAP_V2_ROUTER", ""))
 	cfg.SushiswapRouter = common.HexToAddress(getEnv("SUSHISWAP_ROUTER", ""))
+	cfg.UniswapV2InitCodeHash = common.HexToHash(getEnv("UNISWAP_V2_INIT_CODE_HASH", UniswapV2InitCodeHash))
+	cfg.SushiSwapInitCodeHash = common.HexToHash(getEnv("SUSHISWAP_INIT_CODE_HASH", SushiSwapInitCodeHash))
 
 	// Token Addresses
 	cfg.WETHAddress = common.HexToAddress(getEnv("WETH_ADDRESS", ""))
 	cfg.USDCAddress = common.HexToAddress(getEnv("USDC_ADDRESS", ""))
 	cfg.DAIAddress = common.HexToAddress(getEnv("DAI_ADDRESS", ""))
+	cfg.TokenListFile = getEnv("TOKEN_LIST_FILE", "")
+
+	// Token Safety
+	cfg.TokenSafetyCheck = getEnvAsBool("TOKEN_SAFETY_CHECK", true)
+	cfg.TokenMaxTaxBps = getEnvAsInt("TOKEN_MAX_TAX_BPS", 100)
+
+	// Local Simulation
+	cfg.LocalSimulation = getEnvAsBool("LOCAL_SIMULATION", true)
+	cfg.SimulationFixtureDir = getEnv("SIMULATION_FIXTURE_DIR", "")
 
 	// Strategy Parameters
 	cfg.MinProfitBps = getEnvAsInt("MIN_PROFIT_BPS", 50)
+	cfg.MinProfitETH = parseEther(getEnv("MIN_PROFIT_ETH", "0"))
 	cfg.MaxTradeAmountETH = parseEther(getEnv("MAX_TRADE_AMOUNT_ETH", "10"))
 	cfg.MinTradeAmountETH = parseEther(getEnv("MIN_TRADE_AMOUNT_ETH", "0.1"))
 	cfg.GasPriceMultiplier = getEnvAsFloat64("GAS_PRICE_MULTIPLIER", 1.2)
 	cfg.MaxGasPriceGwei = uint64(getEnvAsInt("MAX_GAS_PRICE_GWEI", 100))
+	cfg.GasLimitMultiplier = getEnvAsFloat64("GAS_LIMIT_MULTIPLIER", 1.25)
+	cfg.MaxHops = getEnvAsInt("MAX_HOPS", 3)
+	for _, address := range getEnvAsList("START_TOKENS") {
+		cfg.StartTokens = append(cfg.StartTokens, common.HexToAddress(address))
+	}
+	if len(cfg.StartTokens) == 0 && cfg.WETHAddress != (common.Address{}) {
+		cfg.StartTokens = []common.Address{cfg.WETHAddress}
+	}
+
+	// Pricing
+	cfg.PriceMinLiquidityETH = parseEther(getEnv("PRICE_MIN_LIQUIDITY_ETH", "1"))
+	cfg.PriceFallbackETH = getEnvAsAmountMap("PRICE_FALLBACK_ETH")
+
+	// Strategies
+	cfg.EnableCycleStrategy = getEnvAsBool("ENABLE_CYCLE_STRATEGY", true)
+	cfg.EnableCrossDEXStrategy = getEnvAsBool("ENABLE_CROSS_DEX_STRATEGY", true)
+	cfg.EnableBackrunStrategy = getEnvAsBool("ENABLE_BACKRUN_STRATEGY", true)
+	cfg.StrategyTimeoutMs = getEnvAsInt("STRATEGY_TIMEOUT_MS", 2000)
+
+	// Mempool
+	cfg.EnableMempool = getEnvAsBool("ENABLE_MEMPOOL", false)
+	cfg.MempoolMaxAgeSec = getEnvAsInt("MEMPOOL_MAX_AGE", 60)
 
 	// Monitoring Configuration
 	cfg.LogLevel = getEnv("LOG_LEVEL", "info")
@@ -127,6 +276,18 @@ func LoadConfig() (*Config, error) {
 	cfg.MaxRetryAttempts = getEnvAsInt("MAX_RETRY_ATTEMPTS", 3)
 	cfg.PoolMonitorInterval = getEnvAsInt("POOL_MONITOR_INTERVAL", 12)
 
+	// RPC Endpoint Pool
+	cfg.RPCHealthCheckInterval = getEnvAsInt("RPC_HEALTH_CHECK_INTERVAL", 15)
+	cfg.RPCMaxBlockLag = uint64(getEnvAsInt("RPC_MAX_BLOCK_LAG", 3))
+	cfg.RPCHedgeDelayMs = getEnvAsInt("RPC_HEDGE_DELAY_MS", 200)
+
+	// RPC Rate Limiting
+	cfg.RPCRateLimitRPS = getEnvAsFloat64("RPC_RATE_LIMIT_RPS", 25)
+	cfg.RPCComputeUnits = getEnvAsFloat64("RPC_COMPUTE_UNITS_PER_SECOND", 330)
+	cfg.RPCMethodCU = getEnvAsIntMap("RPC_METHOD_CU")
+	cfg.RPCStatsInterval = getEnvAsInt("RPC_STATS_INTERVAL", 300)
+	cfg.RPCCacheFile = getEnv("RPC_CACHE_FILE", "")
+
 	// Setup logging
 	setupLogging(cfg.LogLevel)
 
@@ -186,6 +347,54 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return valueStr == "true" || valueStr == "1" || valueStr == "yes"
 }
 
+func getEnvAsList(key string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return nil
+	}
+
+	values := make([]string, 0)
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsIntMap parses "key:value,key:value" pairs
+func getEnvAsIntMap(key string) map[string]int {
+	values := make(map[string]int)
+	for _, pair := range getEnvAsList(key) {
+		name, valueStr, found := strings.Cut(pair, ":")
+		if !found {
+			log.Warnf("Invalid entry %q in %s, expected name:value", pair, key)
+			continue
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(valueStr))
+		if err != nil {
+			log.Warnf("Invalid integer value in %s for %s", key, name)
+			continue
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	return values
+}
+
+// getEnvAsAmountMap parses "address:amount,address:amount" decimal pairs
+func getEnvAsAmountMap(key string) map[common.Address]*big.Float {
+	values := make(map[common.Address]*big.Float)
+	for _, pair := range getEnvAsList(key) {
+		address, valueStr, found := strings.Cut(pair, ":")
+		if !found || !common.IsHexAddress(strings.TrimSpace(address)) {
+			log.Warnf("Invalid entry %q in %s, expected address:amount", pair, key)
+			continue
+		}
+		values[common.HexToAddress(strings.TrimSpace(address))] = parseEther(strings.TrimSpace(valueStr))
+	}
+	return values
+}
+
 func parseEther(value string) *big.Float {
 	amount, ok := new(big.Float).SetString(value)
 	if !ok {
@@ -222,15 +431,43 @@ func (c *Config) PrintConfig() {
 	log.Infof("Network: %s", c.Network)
 	log.Infof("RPC HTTPS: %s", maskURL(c.RPCHTTPSUrl))
 	log.Infof("RPC WSS: %s", maskURL(c.RPCWSSUrl))
+	log.Infof("RPC Endpoints: %d HTTPS, %d WSS", len(c.RPCHTTPSUrls), len(c.RPCWSSUrls))
 	log.Infof("Public Address: %s", c.PublicAddress.Hex())
 	log.Infof("Arbitrage Contract: %s", c.ArbitrageContract.Hex())
 	log.Infof("Min Profit BPS: %d (%.2f%%)", c.MinProfitBps, float64(c.MinProfitBps)/100)
 	log.Infof("Max Trade Amount: %s ETH", c.MaxTradeAmountETH.Text('f', 2))
+	log.Infof("Min Net Profit: %s ETH", c.MinProfitETH.Text('f', 4))
+	log.Infof("Pricing: min liquidity %s ETH, %d fallback prices",
+		c.PriceMinLiquidityETH.Text('f', 2), len(c.PriceFallbackETH))
+	log.Infof("Max Hops: %d, Start Tokens: %d", c.MaxHops, len(c.StartTokens))
+	log.Infof("Strategies: cycle=%v, cross_dex=%v, backrun=%v (timeout %d ms)",
+		c.EnableCycleStrategy, c.EnableCrossDEXStrategy, c.EnableBackrunStrategy, c.StrategyTimeoutMs)
+	log.Infof("Mempool: %v (max age %d s)", c.EnableMempool, c.MempoolMaxAgeSec)
+	log.Infof("Token Safety Check: %v (max tax %d bps)", c.TokenSafetyCheck, c.TokenMaxTaxBps)
+	log.Infof("Local Simulation: %v", c.LocalSimulation)
+	log.Infof("Execution Mode: %s (capital contract %s, %d inventory targets)",
+		c.ExecutionMode, c.CapitalContract.Hex(), len(c.InventoryTargets))
+	log.Infof("Risk Limits: daily loss %s ETH, %d reverts, gas %s ETH/hour, min wallet %s ETH, %d exposure caps",
+		c.RiskMaxDailyLossETH.Text('f', 4), c.RiskMaxConsecutiveReverts, c.RiskMaxGasPerHourETH.Text('f', 4),
+		c.RiskMinWalletBalanceETH.Text('f', 4), len(c.RiskExposureCaps))
+	log.Infof("Admin API: %s", valueOrOff(c.AdminAPIAddr))
+	log.Infof("Route Tracking: pending %d blocks, backoff after %d reverts (%d..%d blocks)",
+		c.OpportunityPendingBlocks, c.RevertBackoffAfter, c.RevertBackoffBlocks, c.RevertBackoffMaxBlocks)
+	log.Infof("Slippage Policy: %s (max %d bps, 0 = policy default)", c.SlippagePolicy, c.SlippageMaxBps)
+	log.Infof("Flash-Loan Providers: %s", strings.Join(c.FlashLoanProviders, ", "))
 	log.Infof("Enable Flashbots: %v", c.EnableFlashbots)
 	log.Infof("Dry Run Mode: %v", c.DryRun)
 	log.Info("======================================================")
 }
 
+// valueOrOff returns value, or "off" if it is empty
+func valueOrOff(value string) string {
+	if value == "" {
+		return "off"
+	}
+	return value
+}
+
 // maskURL masks sensitive parts of a URL
 func maskURL(url string) string {
 	if len(url) < 10 {
@@ -31,6 +31,36 @@ const (
 	AverageBlockTime = 12
 )
 
+// Pair contract init code hashes used for CREATE2 pair address derivation
+const (
+	UniswapV2InitCodeHash = "0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"
+	SushiSwapInitCodeHash = "0xe18a34eb0e04b04f7a0ac29a6e80748dca96319b42c520b3f9d0a5c15d8c4f05"
+)
+
+// Balancer V2 Vault (same address on all chains)
+const BalancerVaultAddress = "0xBA12222222228d8Ba445958a75a0704d566BF2C8"
+
+// Multicall3 (same address on all chains)
+const Multicall3Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
+// Execution modes: how the start amount of a trade is funded
+const (
+	ExecutionModeFlashLoan = "flashloan" // Always borrow
+	ExecutionModeCapital   = "capital"   // Always trade the capital contract's inventory
+	ExecutionModeAuto      = "auto"      // Inventory when sufficient, flash loan otherwise
+)
+
+// Slippage policies: how much each hop's output may fall short of the
+// quote before the swap reverts
+const (
+	SlippagePolicyConservative = "conservative" // Tight minimums; more reverts, smallest losses
+	SlippagePolicyBalanced     = "balanced"
+	SlippagePolicyAggressive   = "aggressive" // Loose minimums; lands more trades in busy blocks
+)
+
+// DefaultSlippageBps is the tolerance of single-swap quotes
+const DefaultSlippageBps = 50
+
 // Network Chain IDs
 const (
 	MainnetChainID = 1
@@ -38,13 +68,9 @@ const (
 	GoerliChainID  = 5
 )
 
-// Common token decimals
+// Native currency decimals (ERC-20 decimals come from the token registry)
 const (
-	ETHDecimals  = 18
-	WETHDecimals = 18
-	USDCDecimals = 6
-	DAIDecimals  = 18
-	USDTDecimals = 6
+	ETHDecimals = 18
 )
 
 // Pre-calculated big.Int values for performance
@@ -0,0 +1,26 @@
+package dex
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SortTokens returns the two tokens in the order a V2 pair stores them
+// (token0 < token1 by address)
+func SortTokens(tokenA, tokenB common.Address) (common.Address, common.Address) {
+	if bytes.Compare(tokenA.Bytes(), tokenB.Bytes()) < 0 {
+		return tokenA, tokenB
+	}
+	return tokenB, tokenA
+}
+
+// ComputePairAddress derives a V2-style pair address offline via CREATE2:
+// keccak256(0xff ++ factory ++ keccak256(token0 ++ token1) ++ initCodeHash)
+func ComputePairAddress(factory, tokenA, tokenB common.Address, initCodeHash common.Hash) common.Address {
+	token0, token1 := SortTokens(tokenA, tokenB)
+
+	salt := crypto.Keccak256Hash(token0.Bytes(), token1.Bytes())
+	return crypto.CreateAddress2(factory, salt, initCodeHash.Bytes())
+}
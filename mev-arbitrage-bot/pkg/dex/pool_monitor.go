@@ -3,6 +3,7 @@ package dex
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
 )
 
@@ -18,11 +20,23 @@ import (
 type PoolMonitor struct {
 	client   *ethclient.Client
 	adapters map[DEXType]DEXAdapter
+	routers  map[DEXType]common.Address // Routers of DEXes without an adapter (replayed pools)
 	pools    map[common.Address]*Pool
 	mu       sync.RWMutex
 	ctx      context.Context
 	cancel   context.CancelFunc
 	interval time.Duration
+	safety   *TokenSafetyChecker // Optional; screens tokens before pools are added
+
+	lastBlock     uint64      // Latest block seen from the block feed
+	lastBlockHash common.Hash // Its hash; pool reserves are read at this block
+	lastBlockTime time.Time   // When that block arrived
+}
+
+// blockRef identifies the block pool reserves are read at (zero = latest)
+type blockRef struct {
+	number uint64
+	hash   common.Hash
 }
 
 // NewPoolMonitor creates a new pool monitor
@@ -32,6 +46,7 @@ func NewPoolMonitor(client *ethclient.Client, cfg *config.Config) *PoolMonitor {
 	monitor := &PoolMonitor{
 		client:   client,
 		adapters: make(map[DEXType]DEXAdapter),
+		routers:  make(map[DEXType]common.Address),
 		pools:    make(map[common.Address]*Pool),
 		ctx:      ctx,
 		cancel:   cancel,
@@ -50,6 +65,78 @@ func (pm *PoolMonitor) RegisterAdapter(adapter DEXAdapter) {
 	log.Infof("Registered DEX adapter: %s", adapter.GetName())
 }
 
+// GetRouter returns the router address of a registered DEX
+func (pm *PoolMonitor) GetRouter(dexType DEXType) (common.Address, error) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	adapter, exists := pm.adapters[dexType]
+	if !exists {
+		if router, exists := pm.routers[dexType]; exists {
+			return router, nil
+		}
+		return common.Address{}, fmt.Errorf("no adapter registered for DEX type: %s", dexType)
+	}
+	return adapter.GetRouterAddress(), nil
+}
+
+// RegisterRouter registers the router of a DEX whose pools are supplied
+// without an adapter, e.g. replayed from recorded history
+func (pm *PoolMonitor) RegisterRouter(dexType DEXType, router common.Address) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.routers[dexType] = router
+}
+
+// SetTokenSafety enables token safety checks for discovered pools
+func (pm *PoolMonitor) SetTokenSafety(checker *TokenSafetyChecker) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.safety = checker
+}
+
+// DiscoverPool fetches the pool of a token pair from its DEX and starts
+// monitoring it. Pairs with a honeypot or over-taxed token are rejected.
+func (pm *PoolMonitor) DiscoverPool(tokenA, tokenB common.Address, dexType DEXType) (*Pool, error) {
+	pm.mu.RLock()
+	adapter, exists := pm.adapters[dexType]
+	safety := pm.safety
+	pm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("no adapter registered for DEX type: %s", dexType)
+	}
+
+	if safety != nil {
+		for _, token := range []common.Address{tokenA, tokenB} {
+			report, err := safety.Check(pm.ctx, token)
+			if err != nil {
+				return nil, fmt.Errorf("safety check of %s failed: %w", token.Hex(), err)
+			}
+			if !report.Safe {
+				return nil, fmt.Errorf("token %s is unsafe: %s", token.Hex(), report.Reason)
+			}
+		}
+	}
+
+	pool, err := adapter.GetPool(tokenA, tokenB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pool: %w", err)
+	}
+
+	// Read at the latest state: the block is unknown until the next refresh
+	pool.BlockNumber = 0
+
+	if err := pm.AddPool(pool); err != nil {
+		return nil, err
+	}
+
+	poolCopy := *pool
+	return &poolCopy, nil
+}
+
 // AddPool adds a pool to monitor
 func (pm *PoolMonitor) AddPool(pool *Pool) error {
 	pm.mu.Lock()
@@ -95,31 +182,50 @@ func (pm *PoolMonitor) GetAllPools() []*Pool {
 	return pools
 }
 
-// UpdatePool updates a pool's reserves
+// UpdatePool updates a pool's reserves as of the latest head from the block
+// feed. The reserves are fetched without holding the monitor lock so pools
+// can be refreshed concurrently.
 func (pm *PoolMonitor) UpdatePool(address common.Address) error {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	pm.mu.RLock()
+	head := blockRef{number: pm.lastBlock, hash: pm.lastBlockHash}
+	pm.mu.RUnlock()
+
+	return pm.updatePool(address, head)
+}
 
+// updatePool updates a pool's reserves as of head
+func (pm *PoolMonitor) updatePool(address common.Address, head blockRef) error {
+	pm.mu.RLock()
 	pool, exists := pm.pools[address]
 	if !exists {
+		pm.mu.RUnlock()
 		return fmt.Errorf("pool not found: %s", address.Hex())
 	}
-
 	adapter, exists := pm.adapters[pool.DEX]
+	pm.mu.RUnlock()
+
 	if !exists {
 		return fmt.Errorf("adapter not found for DEX: %s", pool.DEX)
 	}
 
 	// Fetch new reserves
-	reserve0, reserve1, err := adapter.GetReserves(address)
+	reserve0, reserve1, blockNumber, err := fetchReserves(adapter, address, head)
 	if err != nil {
 		return fmt.Errorf("failed to fetch reserves: %w", err)
 	}
 
 	// Update pool
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pool, exists = pm.pools[address]
+	if !exists {
+		return fmt.Errorf("pool not found: %s", address.Hex())
+	}
 	pool.Reserve0 = reserve0
 	pool.Reserve1 = reserve1
 	pool.LastUpdated = time.Now().Unix()
+	pool.BlockNumber = blockNumber
 
 	log.Debugf("Updated pool %s: Reserve0=%s, Reserve1=%s",
 		address.Hex(), reserve0.String(), reserve1.String())
@@ -127,6 +233,23 @@ func (pm *PoolMonitor) UpdatePool(address common.Address) error {
 	return nil
 }
 
+// fetchReserves reads a pool's reserves at head and returns the block they
+// reflect. Without a head, or if the adapter or node cannot serve that
+// block, the reserves are read at the latest state and the block is
+// unknown (0).
+func fetchReserves(adapter DEXAdapter, address common.Address, head blockRef) (*big.Int, *big.Int, uint64, error) {
+	if reader, ok := adapter.(BlockReservesReader); ok && head.hash != (common.Hash{}) {
+		reserve0, reserve1, err := reader.GetReservesAt(address, head.hash)
+		if err == nil {
+			return reserve0, reserve1, head.number, nil
+		}
+		log.Debugf("Reserves of %s at block %d unavailable, reading latest: %v", address.Hex(), head.number, err)
+	}
+
+	reserve0, reserve1, err := adapter.GetReserves(address)
+	return reserve0, reserve1, 0, err
+}
+
 // Start begins monitoring pools
 func (pm *PoolMonitor) Start() {
 	log.Info("Starting pool monitor...")
@@ -142,7 +265,8 @@ func (pm *PoolMonitor) Stop() {
 	pm.cancel()
 }
 
-// monitorLoop periodically updates all pools
+// monitorLoop periodically updates all pools. When block-driven updates
+// are active it only acts as a fallback while the block feed is stalled.
 func (pm *PoolMonitor) monitorLoop() {
 	ticker := time.NewTicker(pm.interval)
 	defer ticker.Stop()
@@ -154,13 +278,20 @@ func (pm *PoolMonitor) monitorLoop() {
 			return
 
 		case <-ticker.C:
-			pm.updateAllPools()
+			pm.mu.RLock()
+			sinceBlock := time.Since(pm.lastBlockTime)
+			pm.mu.RUnlock()
+
+			if sinceBlock < pm.interval {
+				continue
+			}
+			pm.updateAllPools(blockRef{})
 		}
 	}
 }
 
-// updateAllPools updates reserves for all monitored pools
-func (pm *PoolMonitor) updateAllPools() {
+// updateAllPools updates reserves for all monitored pools as of head
+func (pm *PoolMonitor) updateAllPools(head blockRef) {
 	pm.mu.RLock()
 	addresses := make([]common.Address, 0, len(pm.pools))
 	for addr := range pm.pools {
@@ -174,7 +305,7 @@ func (pm *PoolMonitor) updateAllPools() {
 		go func(address common.Address) {
 			defer wg.Done()
 
-			if err := pm.UpdatePool(address); err != nil {
+			if err := pm.updatePool(address, head); err != nil {
 				log.Warnf("Failed to update pool %s: %v", address.Hex(), err)
 			}
 		}(addr)
@@ -184,37 +315,100 @@ func (pm *PoolMonitor) updateAllPools() {
 	log.Debugf("Updated %d pools", len(addresses))
 }
 
-// SubscribeNewBlocks subscribes to new blocks and updates pools
-func (pm *PoolMonitor) SubscribeNewBlocks(wssClient *ethclient.Client) error {
-	headers := make(chan *types.Header)
-
-	sub, err := wssClient.SubscribeNewHead(pm.ctx, headers)
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to new heads: %w", err)
-	}
+// SubscribeNewBlocks refreshes all pools on every head from the feed and
+// forwards the head on the returned channel once pool state reflects it
+func (pm *PoolMonitor) SubscribeNewBlocks(heads <-chan *types.Header) <-chan *types.Header {
+	updated := make(chan *types.Header, 1)
 
 	log.Info("Subscribed to new blocks for pool monitoring")
 
 	go func() {
-		defer sub.Unsubscribe()
+		defer close(updated)
 
 		for {
 			select {
 			case <-pm.ctx.Done():
 				return
 
-			case err := <-sub.Err():
-				log.Errorf("Block subscription error: %v", err)
-				return
+			case header, ok := <-heads:
+				if !ok {
+					log.Warn("Block feed closed, pool monitor falls back to polling")
+					return
+				}
+
+				// Reserves are read at the latest state, so heads queued
+				// behind this one (e.g. back-filled after a gap) are
+				// coalesced into a single refresh
+				header = latestHead(header, heads)
 
-			case header := <-headers:
 				log.Debugf("New block %d, updating pools...", header.Number.Uint64())
-				pm.updateAllPools()
+
+				head := blockRef{number: header.Number.Uint64(), hash: header.Hash()}
+				pm.mu.Lock()
+				pm.lastBlock = head.number
+				pm.lastBlockHash = head.hash
+				pm.lastBlockTime = time.Now()
+				pm.mu.Unlock()
+
+				pm.updateAllPools(head)
+
+				// Keep only the newest head if the consumer is behind
+				select {
+				case <-updated:
+				default:
+				}
+				updated <- header
 			}
 		}
 	}()
 
-	return nil
+	return updated
+}
+
+// latestHead drains the heads already queued on the feed and returns the
+// newest one
+func latestHead(header *types.Header, heads <-chan *types.Header) *types.Header {
+	for {
+		select {
+		case next, ok := <-heads:
+			if !ok {
+				return header
+			}
+			header = next
+		default:
+			return header
+		}
+	}
+}
+
+// HandleReorg marks pools whose state was read from a block orphaned by a
+// chain reorganization as read at an unknown block. It makes no RPC calls:
+// it runs on the head subscriber's goroutine, and the new chain's heads are
+// published right after it, so the coalesced head refresh re-reads every
+// pool at the new head.
+func (pm *PoolMonitor) HandleReorg(event *blockchain.ReorgEvent) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	affected := 0
+	for _, pool := range pm.pools {
+		if pool.BlockNumber > event.CommonAncestor {
+			pool.BlockNumber = 0
+			affected++
+		}
+	}
+	pm.lastBlock = event.NewHead.Number.Uint64()
+	pm.lastBlockHash = event.NewHead.Hash()
+
+	log.Warnf("Reorg of depth %d: %d pools read from orphaned blocks, re-read at the next head", event.Depth, affected)
+}
+
+// LastBlock returns the latest block number pools were refreshed for
+func (pm *PoolMonitor) LastBlock() uint64 {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return pm.lastBlock
 }
 
 // GetPoolsByDEX returns all pools for a specific DEX
@@ -0,0 +1,196 @@
+package dex
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+)
+
+// fakeAdapter serves reserves from memory and counts reads
+type fakeAdapter struct {
+	reserve0 *big.Int
+	reserve1 *big.Int
+	gate     chan struct{} // If set, GetReserves waits for it
+	pinned   bool          // Whether reads at a block hash are served
+	calls    int
+	readAt   []common.Hash // Block hash of each read (zero = latest)
+	mu       sync.Mutex
+}
+
+func (a *fakeAdapter) GetName() string                                       { return "Fake" }
+func (a *fakeAdapter) GetType() DEXType                                      { return UniswapV2 }
+func (a *fakeAdapter) GetPool(common.Address, common.Address) (*Pool, error) { return nil, nil }
+func (a *fakeAdapter) GetAmountOut(*big.Int, *big.Int, *big.Int) *big.Int    { return nil }
+func (a *fakeAdapter) GetAmountIn(*big.Int, *big.Int, *big.Int) *big.Int     { return nil }
+func (a *fakeAdapter) GetRouterAddress() common.Address                      { return testRouter }
+func (a *fakeAdapter) GetFactoryAddress() common.Address                     { return testFactory }
+func (a *fakeAdapter) Quote(*big.Int, common.Address, common.Address) (*QuoteResult, error) {
+	return nil, nil
+}
+
+func (a *fakeAdapter) GetReserves(pair common.Address) (*big.Int, *big.Int, error) {
+	return a.read(common.Hash{})
+}
+
+func (a *fakeAdapter) GetReservesAt(pair common.Address, blockHash common.Hash) (*big.Int, *big.Int, error) {
+	if !a.pinned {
+		return nil, nil, fmt.Errorf("header not found")
+	}
+	return a.read(blockHash)
+}
+
+func (a *fakeAdapter) read(blockHash common.Hash) (*big.Int, *big.Int, error) {
+	if a.gate != nil {
+		<-a.gate
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	a.readAt = append(a.readAt, blockHash)
+	return a.reserve0, a.reserve1, nil
+}
+
+func (a *fakeAdapter) readCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.calls
+}
+
+func newTestMonitor(t *testing.T, adapter *fakeAdapter) *PoolMonitor {
+	monitor := NewPoolMonitor(nil, &config.Config{PoolMonitorInterval: 60})
+	t.Cleanup(monitor.Stop)
+
+	monitor.RegisterAdapter(adapter)
+	err := monitor.AddPool(&Pool{
+		Address:  testPair,
+		DEX:      UniswapV2,
+		Reserve0: big.NewInt(1),
+		Reserve1: big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return monitor
+}
+
+func testHeader(number int64) *types.Header {
+	return &types.Header{Number: big.NewInt(number)}
+}
+
+func TestQueuedHeadsCoalesceIntoOneRefresh(t *testing.T) {
+	adapter := &fakeAdapter{reserve0: big.NewInt(5), reserve1: big.NewInt(7), pinned: true}
+	monitor := newTestMonitor(t, adapter)
+
+	// A back-filled gap arrives as a burst of heads
+	heads := make(chan *types.Header, 8)
+	var newest *types.Header
+	for number := int64(101); number <= 105; number++ {
+		newest = testHeader(number)
+		heads <- newest
+	}
+
+	updated := monitor.SubscribeNewBlocks(heads)
+	select {
+	case header := <-updated:
+		if header.Number.Int64() != 105 {
+			t.Fatalf("forwarded block %d, want the newest 105", header.Number.Int64())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no head forwarded")
+	}
+
+	if calls := adapter.readCount(); calls != 1 {
+		t.Fatalf("%d reserve reads for a burst of 5 heads, want 1", calls)
+	}
+	pool, err := monitor.GetPool(testPair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.Reserve0.Int64() != 5 || pool.BlockNumber != 105 || adapter.readAt[0] != newest.Hash() {
+		t.Fatalf("pool not refreshed at block 105: %+v", pool)
+	}
+}
+
+func TestPoolBlockMatchesReadState(t *testing.T) {
+	adapter := &fakeAdapter{reserve0: big.NewInt(5), reserve1: big.NewInt(7)}
+	monitor := newTestMonitor(t, adapter)
+
+	heads := make(chan *types.Header, 1)
+	updated := monitor.SubscribeNewBlocks(heads)
+
+	// The node cannot serve the head block: the read falls back to latest
+	// and the pool's block is unknown rather than labeled with the head
+	heads <- testHeader(200)
+	<-updated
+	pool, _ := monitor.GetPool(testPair)
+	if pool.BlockNumber != 0 {
+		t.Fatalf("reserves read at latest labeled block %d", pool.BlockNumber)
+	}
+
+	adapter.mu.Lock()
+	adapter.pinned = true
+	adapter.reserve0 = big.NewInt(9)
+	adapter.mu.Unlock()
+	heads <- testHeader(201)
+	<-updated
+
+	// A reorg marks pools of orphaned blocks without reading them; the new
+	// chain's head re-reads them at its hash
+	calls := adapter.readCount()
+	monitor.HandleReorg(&blockchain.ReorgEvent{CommonAncestor: 200, Depth: 1, NewHead: testHeader(201)})
+	pool, _ = monitor.GetPool(testPair)
+	if adapter.readCount() != calls || pool.BlockNumber != 0 {
+		t.Fatalf("reorg read reserves or kept the orphaned block: %+v", pool)
+	}
+
+	adapter.mu.Lock()
+	adapter.reserve0 = big.NewInt(11)
+	adapter.mu.Unlock()
+	newHead := testHeader(202)
+	heads <- newHead
+	<-updated
+	pool, _ = monitor.GetPool(testPair)
+	if pool.Reserve0.Int64() != 11 || pool.BlockNumber != 202 || adapter.readAt[len(adapter.readAt)-1] != newHead.Hash() {
+		t.Fatalf("pool not re-read at the new head: %+v", pool)
+	}
+
+	// A pool read at a block below the common ancestor is still valid
+	monitor.HandleReorg(&blockchain.ReorgEvent{CommonAncestor: 202, Depth: 1, NewHead: testHeader(203)})
+	if pool, _ = monitor.GetPool(testPair); pool.BlockNumber != 202 {
+		t.Fatal("pool outside the reorg marked as orphaned")
+	}
+}
+
+func TestUpdatePoolDoesNotBlockReaders(t *testing.T) {
+	adapter := &fakeAdapter{reserve0: big.NewInt(5), reserve1: big.NewInt(7), gate: make(chan struct{})}
+	monitor := newTestMonitor(t, adapter)
+
+	done := make(chan error, 1)
+	go func() { done <- monitor.UpdatePool(testPair) }()
+
+	// The refresh is stuck in its RPC; snapshots must still be served
+	read := make(chan int, 1)
+	go func() { read <- len(monitor.GetAllPools()) }()
+	select {
+	case n := <-read:
+		if n != 1 {
+			t.Fatalf("got %d pools, want 1", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GetAllPools blocked by an in-flight reserve fetch")
+	}
+
+	close(adapter.gate)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,314 @@
+package dex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
+)
+
+// ERC20 metadata ABI (string variants; bytes32 variants are decoded manually)
+const ERC20MetadataABI = `[
+	{"constant":true,"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"}
+]`
+
+// tokenRetryInterval is how long a failed metadata lookup is remembered
+// before the token is queried again
+const tokenRetryInterval = 10 * time.Minute
+
+// failedLookup is a remembered metadata lookup error
+type failedLookup struct {
+	err error
+	at  time.Time
+}
+
+// tokenList is the Uniswap token list format
+type tokenList struct {
+	Tokens []struct {
+		ChainID  uint64 `json:"chainId"`
+		Address  string `json:"address"`
+		Symbol   string `json:"symbol"`
+		Name     string `json:"name"`
+		Decimals uint8  `json:"decimals"`
+	} `json:"tokens"`
+}
+
+// TokenRegistry fetches and caches ERC-20 token metadata
+type TokenRegistry struct {
+	client   *ethclient.Client
+	erc20ABI abi.ABI
+	tokens   map[common.Address]*Token
+	failed   map[common.Address]failedLookup // Negative cache of failed lookups
+	mu       sync.RWMutex
+}
+
+// NewTokenRegistry creates a token registry. If tokenListFile is set, its
+// entries for chainID override on-chain metadata.
+func NewTokenRegistry(client *ethclient.Client, chainID uint64, tokenListFile string) (*TokenRegistry, error) {
+	erc20ABI, err := abi.JSON(strings.NewReader(ERC20MetadataABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+
+	registry := &TokenRegistry{
+		client:   client,
+		erc20ABI: erc20ABI,
+		tokens:   make(map[common.Address]*Token),
+		failed:   make(map[common.Address]failedLookup),
+	}
+
+	if tokenListFile != "" {
+		if err := registry.loadTokenList(tokenListFile, chainID); err != nil {
+			return nil, fmt.Errorf("failed to load token list: %w", err)
+		}
+	}
+
+	return registry, nil
+}
+
+// loadTokenList registers tokens from a local JSON token list
+func (r *TokenRegistry) loadTokenList(path string, chainID uint64) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var list tokenList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("invalid token list: %w", err)
+	}
+
+	count := 0
+	for _, entry := range list.Tokens {
+		if entry.ChainID != 0 && entry.ChainID != chainID {
+			continue
+		}
+		if !common.IsHexAddress(entry.Address) {
+			log.Warnf("Skipping token list entry with invalid address %q", entry.Address)
+			continue
+		}
+
+		address := common.HexToAddress(entry.Address)
+		r.tokens[address] = &Token{
+			Address:  address,
+			Symbol:   entry.Symbol,
+			Name:     entry.Name,
+			Decimals: entry.Decimals,
+		}
+		count++
+	}
+
+	log.Infof("Loaded %d tokens from %s", count, path)
+	return nil
+}
+
+// Register adds or replaces token metadata
+func (r *TokenRegistry) Register(token *Token) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.Address] = token
+	delete(r.failed, token.Address)
+}
+
+// GetToken returns token metadata, fetching it on first use. A failed
+// lookup is not retried for tokenRetryInterval.
+func (r *TokenRegistry) GetToken(address common.Address) (*Token, error) {
+	r.mu.RLock()
+	token, exists := r.tokens[address]
+	failure, failed := r.failed[address]
+	r.mu.RUnlock()
+
+	if exists {
+		tokenCopy := *token
+		return &tokenCopy, nil
+	}
+	if failed && time.Since(failure.at) < tokenRetryInterval {
+		return nil, failure.err
+	}
+
+	token, err := r.fetchToken(address)
+	if err != nil {
+		r.mu.Lock()
+		r.failed[address] = failedLookup{err: err, at: time.Now()}
+		r.mu.Unlock()
+		return nil, err
+	}
+
+	r.Register(token)
+
+	tokenCopy := *token
+	return &tokenCopy, nil
+}
+
+// fetchToken reads ERC-20 metadata from the chain
+func (r *TokenRegistry) fetchToken(address common.Address) (*Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	decimalsData, err := r.call(ctx, address, "decimals")
+	if err != nil {
+		return nil, fmt.Errorf("decimals call failed for %s: %w", address.Hex(), err)
+	}
+	if len(decimalsData) < 32 {
+		return nil, fmt.Errorf("%s is not an ERC20 token (no decimals)", address.Hex())
+	}
+	decimals := new(big.Int).SetBytes(decimalsData[:32])
+	if !decimals.IsUint64() || decimals.Uint64() > 255 {
+		return nil, fmt.Errorf("invalid decimals for %s: %s", address.Hex(), decimals.String())
+	}
+
+	// Symbol and name are optional in ERC-20; fall back to the address
+	symbol := shortAddress(address)
+	if data, err := r.call(ctx, address, "symbol"); err == nil {
+		if decoded := r.decodeString("symbol", data); decoded != "" {
+			symbol = decoded
+		}
+	}
+
+	name := symbol
+	if data, err := r.call(ctx, address, "name"); err == nil {
+		if decoded := r.decodeString("name", data); decoded != "" {
+			name = decoded
+		}
+	}
+
+	token := &Token{
+		Address:  address,
+		Symbol:   symbol,
+		Name:     name,
+		Decimals: uint8(decimals.Uint64()),
+	}
+
+	log.Debugf("Fetched token metadata: %s (%s, %d decimals)", token.Symbol, address.Hex(), token.Decimals)
+	return token, nil
+}
+
+// call performs a raw eth_call of a no-argument metadata method
+func (r *TokenRegistry) call(ctx context.Context, address common.Address, method string) ([]byte, error) {
+	input, err := r.erc20ABI.Pack(method)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.client.CallContract(ctx, ethereum.CallMsg{To: &address, Data: input}, nil)
+}
+
+// decodeString decodes a string return value, accepting the bytes32
+// encoding used by older tokens such as MKR
+func (r *TokenRegistry) decodeString(method string, data []byte) string {
+	if len(data) == 32 {
+		return strings.TrimSpace(string(bytes.TrimRight(data, "\x00")))
+	}
+
+	values, err := r.erc20ABI.Unpack(method, data)
+	if err != nil || len(values) == 0 {
+		return ""
+	}
+
+	value, ok := values[0].(string)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+// Symbol returns the token symbol, or a shortened address if unknown
+func (r *TokenRegistry) Symbol(address common.Address) string {
+	token, err := r.GetToken(address)
+	if err != nil {
+		return shortAddress(address)
+	}
+	return token.Symbol
+}
+
+// Decimals returns the token decimals
+func (r *TokenRegistry) Decimals(address common.Address) (uint8, error) {
+	token, err := r.GetToken(address)
+	if err != nil {
+		return 0, err
+	}
+	return token.Decimals, nil
+}
+
+// ToUnits converts a raw token amount to whole token units
+func (r *TokenRegistry) ToUnits(address common.Address, amount *big.Int) (*big.Float, error) {
+	decimals, err := r.Decimals(address)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ToDecimal(amount, decimals), nil
+}
+
+// FromUnits converts whole token units to a raw token amount
+func (r *TokenRegistry) FromUnits(address common.Address, units *big.Float) (*big.Int, error) {
+	decimals, err := r.Decimals(address)
+	if err != nil {
+		return nil, err
+	}
+	return utils.FromDecimal(units, decimals), nil
+}
+
+// FormatAmount formats a raw token amount for logs, e.g. "1.234567 USDC"
+func (r *TokenRegistry) FormatAmount(address common.Address, amount *big.Int) string {
+	token, err := r.GetToken(address)
+	if err != nil {
+		return fmt.Sprintf("%s (raw) %s", amount.String(), shortAddress(address))
+	}
+
+	return fmt.Sprintf("%s %s", utils.ToDecimal(amount, token.Decimals).Text('f', 6), token.Symbol)
+}
+
+// PairName returns "SYM0/SYM1" for a pool
+func (r *TokenRegistry) PairName(pool *Pool) string {
+	return r.Symbol(pool.Token0) + "/" + r.Symbol(pool.Token1)
+}
+
+// MidPrice returns the decimal-adjusted spot price of base in quote units
+// implied by the pool reserves (before fees)
+func (r *TokenRegistry) MidPrice(pool *Pool, base common.Address) (*big.Float, error) {
+	reserveBase, reserveQuote, err := pool.GetReservesFor(base)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := pool.Token1
+	if base == pool.Token1 {
+		quote = pool.Token0
+	}
+
+	baseUnits, err := r.ToUnits(base, reserveBase)
+	if err != nil {
+		return nil, err
+	}
+	quoteUnits, err := r.ToUnits(quote, reserveQuote)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseUnits.Sign() == 0 {
+		return nil, fmt.Errorf("pool %s has no %s liquidity", pool.Address.Hex(), r.Symbol(base))
+	}
+
+	return new(big.Float).Quo(quoteUnits, baseUnits), nil
+}
+
+// shortAddress returns the first bytes of an address for display
+func shortAddress(address common.Address) string {
+	return address.Hex()[:10]
+}
@@ -0,0 +1,213 @@
+package dex
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	testMKR  = common.HexToAddress("0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+	testUSDC = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	testEOA  = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+)
+
+// fakeTokenNode is a JSON-RPC server answering ERC-20 metadata calls from
+// canned return data per token and selector
+type fakeTokenNode struct {
+	t       *testing.T
+	returns map[common.Address]map[string][]byte // token -> selector -> return data
+	calls   map[common.Address]int
+	mu      sync.Mutex
+}
+
+func newFakeTokenNode(t *testing.T) (*fakeTokenNode, *ethclient.Client) {
+	node := &fakeTokenNode{
+		t:       t,
+		returns: make(map[common.Address]map[string][]byte),
+		calls:   make(map[common.Address]int),
+	}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatalf("dial fake node: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return node, client
+}
+
+func (n *fakeTokenNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "eth_call" {
+		n.t.Errorf("unexpected request %q: %v", req.Method, err)
+		return
+	}
+
+	var msg struct {
+		To    common.Address `json:"to"`
+		Input hexutil.Bytes  `json:"input"`
+	}
+	if err := json.Unmarshal(req.Params[0], &msg); err != nil {
+		n.t.Errorf("decode call: %v", err)
+		return
+	}
+
+	n.mu.Lock()
+	n.calls[msg.To]++
+	result := n.returns[msg.To][hex.EncodeToString(msg.Input[:4])]
+	n.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"result":  hexutil.Bytes(result),
+	})
+}
+
+// setToken stores the return data of decimals(), symbol() and name()
+func (n *fakeTokenNode) setToken(token common.Address, decimals int64, symbol, name []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.returns[token] = map[string][]byte{
+		"313ce567": common.LeftPadBytes(big.NewInt(decimals).Bytes(), 32), // decimals()
+		"95d89b41": symbol,                                                // symbol()
+		"06fdde03": name,                                                  // name()
+	}
+}
+
+func (n *fakeTokenNode) callCount(token common.Address) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls[token]
+}
+
+// bytes32String encodes s the way MKR returns its symbol and name
+func bytes32String(s string) []byte {
+	return common.RightPadBytes([]byte(s), 32)
+}
+
+// abiString encodes s as an ABI string return value
+func abiString(t *testing.T, s string) []byte {
+	stringType, _ := abi.NewType("string", "", nil)
+	data, err := abi.Arguments{{Type: stringType}}.Pack(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestTokenRegistryDecodesMetadata(t *testing.T) {
+	node, client := newFakeTokenNode(t)
+	node.setToken(testMKR, 18, bytes32String("MKR"), bytes32String("Maker"))
+	node.setToken(testUSDC, 6, abiString(t, "USDC"), abiString(t, "USD Coin"))
+
+	registry, err := NewTokenRegistry(client, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		token    common.Address
+		symbol   string
+		name     string
+		decimals uint8
+	}{
+		{testMKR, "MKR", "Maker", 18},
+		{testUSDC, "USDC", "USD Coin", 6},
+	}
+	for _, c := range cases {
+		token, err := registry.GetToken(c.token)
+		if err != nil {
+			t.Fatalf("GetToken(%s): %v", c.token.Hex(), err)
+		}
+		if token.Symbol != c.symbol || token.Name != c.name || token.Decimals != c.decimals {
+			t.Errorf("got %s/%s/%d, want %s/%s/%d", token.Symbol, token.Name, token.Decimals, c.symbol, c.name, c.decimals)
+		}
+	}
+
+	// Metadata is fetched once
+	calls := node.callCount(testMKR)
+	registry.Symbol(testMKR)
+	if node.callCount(testMKR) != calls {
+		t.Fatal("cached token fetched again")
+	}
+}
+
+func TestTokenRegistryListOverridesChain(t *testing.T) {
+	node, client := newFakeTokenNode(t)
+	node.setToken(testUSDC, 18, abiString(t, "WRONG"), abiString(t, "Wrong"))
+
+	list := `{"tokens":[
+		{"chainId":1,"address":"` + testUSDC.Hex() + `","symbol":"USDC","name":"USD Coin","decimals":6},
+		{"chainId":5,"address":"` + testMKR.Hex() + `","symbol":"gMKR","name":"Goerli Maker","decimals":18}
+	]}`
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(path, []byte(list), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := NewTokenRegistry(client, 1, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := registry.GetToken(testUSDC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Symbol != "USDC" || token.Decimals != 6 || node.callCount(testUSDC) != 0 {
+		t.Fatalf("token list entry not used: %+v (%d calls)", token, node.callCount(testUSDC))
+	}
+
+	// Entries of other chains are ignored
+	registry.GetToken(testMKR)
+	if node.callCount(testMKR) == 0 {
+		t.Fatal("entry for another chain used")
+	}
+}
+
+func TestTokenRegistryRemembersFailures(t *testing.T) {
+	node, client := newFakeTokenNode(t)
+	registry, err := NewTokenRegistry(client, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An address without code returns no decimals
+	for i := 0; i < 3; i++ {
+		if _, err := registry.GetToken(testEOA); err == nil || !strings.Contains(err.Error(), "not an ERC20") {
+			t.Fatalf("got %v, want a not-an-ERC20 error", err)
+		}
+	}
+	if calls := node.callCount(testEOA); calls != 1 {
+		t.Fatalf("failed lookup made %d calls, want 1", calls)
+	}
+	if symbol := registry.Symbol(testEOA); symbol != shortAddress(testEOA) {
+		t.Fatalf("symbol %q, want the short address", symbol)
+	}
+
+	// Registering the token clears the failure
+	registry.Register(&Token{Address: testEOA, Symbol: "DEAD", Decimals: 18})
+	if token, err := registry.GetToken(testEOA); err != nil || token.Symbol != "DEAD" {
+		t.Fatalf("registered token not served: %v", err)
+	}
+}
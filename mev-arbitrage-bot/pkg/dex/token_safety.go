@@ -0,0 +1,447 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+)
+
+// Uniswap V2 Router swap functions used for the safety round trip
+const UniswapV2RouterSwapABI = `[
+	{"inputs":[{"name":"amountIn","type":"uint256"},{"name":"path","type":"address[]"}],"name":"getAmountsOut","outputs":[{"name":"amounts","type":"uint256[]"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"name":"swapExactETHForTokensSupportingFeeOnTransferTokens","outputs":[],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"name":"swapExactTokensForETHSupportingFeeOnTransferTokens","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+
+// Multicall3 aggregate3Value (sequential calls in one transaction, so later
+// calls see the state changes of earlier ones) and getEthBalance
+const Multicall3ABI = `[
+	{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"value","type":"uint256"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3Value","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"name":"addr","type":"address"}],"name":"getEthBalance","outputs":[{"name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+// ERC20 transfer ABI
+const ERC20ABI = `[
+	{"inputs":[{"name":"account","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"name":"approve","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"}
+]`
+
+const (
+	// safetyRecheckInterval is how long a report stays valid; token owners
+	// can change taxes or blacklist the pair at any time
+	safetyRecheckInterval = time.Hour
+
+	// safetyDeadline is the swap deadline used in the round trip
+	safetyDeadline = 1 << 62
+)
+
+var (
+	// safetyProbeAmount is the ETH spent on the simulated buy (0.1 ETH)
+	safetyProbeAmount = new(big.Int).Div(config.WeiPerEtherBigInt, big.NewInt(10))
+
+	// Fake accounts: the caller is funded by a state override, the
+	// receiver only holds what the round trip sends it
+	safetyBuyer    = common.BytesToAddress(crypto.Keccak256([]byte("mev-arbitrage-bot/token-safety/buyer")))
+	safetyReceiver = common.BytesToAddress(crypto.Keccak256([]byte("mev-arbitrage-bot/token-safety/receiver")))
+)
+
+// TokenSafetyReport is the result of a buy/transfer/sell round trip
+type TokenSafetyReport struct {
+	Token          common.Address
+	BuyTaxBps      int    // Shortfall of tokens received vs getAmountsOut on buy
+	TransferTaxBps int    // Shortfall on a wallet-to-wallet transfer
+	SellTaxBps     int    // Shortfall of ETH received vs getAmountsOut on sell
+	CanSell        bool   // False for honeypots (sell reverts)
+	Safe           bool   // Sellable and every tax within the configured maximum
+	Reason         string // Why the token is unsafe
+	BlockNumber    uint64 // Block the round trip ran at
+	CheckedAt      time.Time
+}
+
+// EffectiveTaxBps returns the largest tax a token in transit can incur
+func (r *TokenSafetyReport) EffectiveTaxBps() int {
+	tax := r.BuyTaxBps
+	if r.TransferTaxBps > tax {
+		tax = r.TransferTaxBps
+	}
+	if r.SellTaxBps > tax {
+		tax = r.SellTaxBps
+	}
+	return tax
+}
+
+// TokenSafetyChecker detects fee-on-transfer and honeypot tokens by running
+// a buy, transfer and sell through the V2 router inside a single eth_call to
+// Multicall3, funded with a balance state override
+type TokenSafetyChecker struct {
+	client       *ethclient.Client
+	caller       *gethclient.Client // eth_call with state overrides
+	multicall    common.Address
+	router       common.Address
+	weth         common.Address
+	maxTaxBps    int
+	routerABI    abi.ABI
+	erc20ABI     abi.ABI
+	multicallABI abi.ABI
+	reports      map[common.Address]*TokenSafetyReport
+	mu           sync.RWMutex
+}
+
+// probeCall is one step of a round trip (Multicall3 Call3Value)
+type probeCall struct {
+	Target       common.Address
+	AllowFailure bool
+	Value        *big.Int
+	CallData     []byte
+}
+
+// probeResult is the outcome of a step (Multicall3 Result)
+type probeResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// probeBuilder packs round-trip steps, keeping the first packing error
+type probeBuilder struct {
+	calls []probeCall
+	err   error
+}
+
+// add appends a step that may fail and returns its index
+func (b *probeBuilder) add(target common.Address, contractABI abi.ABI, value *big.Int, method string, args ...interface{}) int {
+	input, err := contractABI.Pack(method, args...)
+	if err != nil && b.err == nil {
+		b.err = fmt.Errorf("failed to pack %s: %w", method, err)
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	b.calls = append(b.calls, probeCall{Target: target, AllowFailure: true, Value: value, CallData: input})
+	return len(b.calls) - 1
+}
+
+// NewTokenSafetyChecker creates a checker that swaps against WETH through
+// the given router. Tokens taxed above maxTaxBps are reported unsafe.
+func NewTokenSafetyChecker(client *ethclient.Client, router, weth common.Address, maxTaxBps int) (*TokenSafetyChecker, error) {
+	routerABI, err := abi.JSON(strings.NewReader(UniswapV2RouterSwapABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse router ABI: %w", err)
+	}
+
+	erc20ABI, err := abi.JSON(strings.NewReader(ERC20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+
+	multicallABI, err := abi.JSON(strings.NewReader(Multicall3ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Multicall3 ABI: %w", err)
+	}
+
+	return &TokenSafetyChecker{
+		client:       client,
+		caller:       gethclient.New(client.Client()),
+		multicall:    common.HexToAddress(config.Multicall3Address),
+		router:       router,
+		weth:         weth,
+		maxTaxBps:    maxTaxBps,
+		routerABI:    routerABI,
+		erc20ABI:     erc20ABI,
+		multicallABI: multicallABI,
+		reports:      make(map[common.Address]*TokenSafetyReport),
+	}, nil
+}
+
+// Check returns the safety report of a token, running the round trip if
+// there is no fresh cached report
+func (c *TokenSafetyChecker) Check(ctx context.Context, token common.Address) (*TokenSafetyReport, error) {
+	if report := c.Report(token); report != nil && time.Since(report.CheckedAt) < safetyRecheckInterval {
+		return report, nil
+	}
+
+	var report *TokenSafetyReport
+	if token == c.weth {
+		report = &TokenSafetyReport{Token: token, CanSell: true, Safe: true, CheckedAt: time.Now()}
+	} else {
+		var err error
+		report, err = c.roundTrip(blockchain.WithPriority(ctx, blockchain.PrioritySimulation), token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	c.reports[token] = report
+	c.mu.Unlock()
+
+	if !report.Safe {
+		log.Warnf("⚠️  Unsafe token %s: %s", token.Hex(), report.Reason)
+	} else if report.EffectiveTaxBps() > 0 {
+		log.Infof("Token %s charges transfer tax: buy %d bps, transfer %d bps, sell %d bps",
+			token.Hex(), report.BuyTaxBps, report.TransferTaxBps, report.SellTaxBps)
+	}
+
+	return report, nil
+}
+
+// Report returns the cached report of a token, or nil if never checked
+func (c *TokenSafetyChecker) Report(token common.Address) *TokenSafetyReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	report, exists := c.reports[token]
+	if !exists {
+		return nil
+	}
+	reportCopy := *report
+	return &reportCopy
+}
+
+// IsSafe reports whether a token may be traded: it passed its last check
+// or was never checked. Unchecked tokens are unknown, not unsafe.
+func (c *TokenSafetyChecker) IsSafe(token common.Address) bool {
+	report := c.Report(token)
+	return report == nil || report.Safe
+}
+
+// EffectiveTaxBps returns the cached effective tax of a token (0 if unchecked)
+func (c *TokenSafetyChecker) EffectiveTaxBps(token common.Address) int {
+	report := c.Report(token)
+	if report == nil {
+		return 0
+	}
+	return report.EffectiveTaxBps()
+}
+
+// roundTrip buys the token with ETH, transfers half of it to a wallet and
+// sells the other half, comparing each amount with the router's quote. Both
+// eth_calls run at the same block, so the second one replays the buy of the
+// first exactly.
+func (c *TokenSafetyChecker) roundTrip(ctx context.Context, token common.Address) (*TokenSafetyReport, error) {
+	blockNumber, err := c.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number: %w", err)
+	}
+	block := new(big.Int).SetUint64(blockNumber)
+
+	report := &TokenSafetyReport{
+		Token:       token,
+		BlockNumber: blockNumber,
+		CheckedAt:   time.Now(),
+	}
+
+	buyPath := []common.Address{c.weth, token}
+	sellPath := []common.Address{token, c.weth}
+	deadline := big.NewInt(safetyDeadline)
+
+	// 1. Buy with ETH
+	var buy probeBuilder
+	quoteBuy := buy.add(c.router, c.routerABI, nil, "getAmountsOut", safetyProbeAmount, buyPath)
+	swapBuy := buy.add(c.router, c.routerABI, safetyProbeAmount,
+		"swapExactETHForTokensSupportingFeeOnTransferTokens", big.NewInt(0), buyPath, c.multicall, deadline)
+	balance := buy.add(token, c.erc20ABI, nil, "balanceOf", c.multicall)
+
+	results, err := c.execute(ctx, block, &buy)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedBuy, err := c.lastAmountOut(results[quoteBuy])
+	if err != nil {
+		return nil, err
+	}
+	if !results[swapBuy].Success {
+		report.Reason = "buy reverted: " + revertReason(results[swapBuy].ReturnData)
+		return report, nil
+	}
+	bought, err := c.uint(c.erc20ABI, "balanceOf", results[balance])
+	if err != nil {
+		return nil, err
+	}
+	if bought.Sign() == 0 {
+		report.Reason = "buy returned no tokens"
+		return report, nil
+	}
+	report.BuyTaxBps = taxBps(expectedBuy, bought)
+
+	// 2. Replay the buy, transfer half to a wallet and sell the rest
+	sent := new(big.Int).Rsh(bought, 1)
+	kept := new(big.Int).Sub(bought, sent)
+
+	var trip probeBuilder
+	trip.add(c.router, c.routerABI, safetyProbeAmount,
+		"swapExactETHForTokensSupportingFeeOnTransferTokens", big.NewInt(0), buyPath, c.multicall, deadline)
+	transfer := trip.add(token, c.erc20ABI, nil, "transfer", safetyReceiver, sent)
+	received := trip.add(token, c.erc20ABI, nil, "balanceOf", safetyReceiver)
+	approve := trip.add(token, c.erc20ABI, nil, "approve", c.router, kept)
+	quoteSell := trip.add(c.router, c.routerABI, nil, "getAmountsOut", kept, sellPath)
+	ethBefore := trip.add(c.multicall, c.multicallABI, nil, "getEthBalance", safetyReceiver)
+	sell := trip.add(c.router, c.routerABI, nil,
+		"swapExactTokensForETHSupportingFeeOnTransferTokens", kept, big.NewInt(0), sellPath, safetyReceiver, deadline)
+	ethAfter := trip.add(c.multicall, c.multicallABI, nil, "getEthBalance", safetyReceiver)
+
+	results, err = c.execute(ctx, block, &trip)
+	if err != nil {
+		return nil, err
+	}
+
+	if sent.Sign() > 0 {
+		if !results[transfer].Success {
+			report.Reason = "transfer reverted: " + revertReason(results[transfer].ReturnData)
+			return report, nil
+		}
+		delivered, err := c.uint(c.erc20ABI, "balanceOf", results[received])
+		if err != nil {
+			return nil, err
+		}
+		if delivered.Sign() == 0 {
+			report.Reason = "transfer delivered no tokens"
+			return report, nil
+		}
+		report.TransferTaxBps = taxBps(sent, delivered)
+	}
+
+	// 3. Sell back to ETH
+	if !results[approve].Success {
+		report.Reason = "approve reverted: " + revertReason(results[approve].ReturnData)
+		return report, nil
+	}
+	expectedSell, err := c.lastAmountOut(results[quoteSell])
+	if err != nil {
+		return nil, err
+	}
+	if !results[sell].Success {
+		report.Reason = "sell reverted (honeypot): " + revertReason(results[sell].ReturnData)
+		return report, nil
+	}
+
+	before, err := c.uint(c.multicallABI, "getEthBalance", results[ethBefore])
+	if err != nil {
+		return nil, err
+	}
+	after, err := c.uint(c.multicallABI, "getEthBalance", results[ethAfter])
+	if err != nil {
+		return nil, err
+	}
+	sold := new(big.Int).Sub(after, before)
+	if sold.Sign() <= 0 {
+		report.Reason = "sell returned no ETH (honeypot)"
+		return report, nil
+	}
+
+	report.CanSell = true
+	report.SellTaxBps = taxBps(expectedSell, sold)
+
+	if tax := report.EffectiveTaxBps(); tax > c.maxTaxBps {
+		report.Reason = fmt.Sprintf("transfer tax %d bps > maximum %d bps", tax, c.maxTaxBps)
+		return report, nil
+	}
+
+	report.Safe = true
+	return report, nil
+}
+
+// execute runs the steps in order through Multicall3 in one eth_call. The
+// caller is funded with the ETH the steps spend by a balance override.
+func (c *TokenSafetyChecker) execute(ctx context.Context, block *big.Int, probe *probeBuilder) ([]probeResult, error) {
+	if probe.err != nil {
+		return nil, probe.err
+	}
+
+	value := new(big.Int)
+	for _, call := range probe.calls {
+		value.Add(value, call.Value)
+	}
+
+	input, err := c.multicallABI.Pack("aggregate3Value", probe.calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack aggregate3Value: %w", err)
+	}
+
+	msg := ethereum.CallMsg{From: safetyBuyer, To: &c.multicall, Value: value, Data: input}
+	overrides := map[common.Address]gethclient.OverrideAccount{
+		safetyBuyer: {Balance: value},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	output, err := c.caller.CallContract(ctx, msg, block, &overrides)
+	if err != nil {
+		return nil, fmt.Errorf("round-trip call failed: %w", err)
+	}
+
+	values, err := c.multicallABI.Unpack("aggregate3Value", output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack aggregate3Value: %w", err)
+	}
+
+	results := *abi.ConvertType(values[0], new([]probeResult)).(*[]probeResult)
+	if len(results) != len(probe.calls) {
+		return nil, fmt.Errorf("round trip returned %d results for %d calls", len(results), len(probe.calls))
+	}
+	return results, nil
+}
+
+// lastAmountOut decodes the final amount of a getAmountsOut step
+func (c *TokenSafetyChecker) lastAmountOut(result probeResult) (*big.Int, error) {
+	if !result.Success {
+		return nil, fmt.Errorf("getAmountsOut reverted: %s", revertReason(result.ReturnData))
+	}
+
+	values, err := c.routerABI.Unpack("getAmountsOut", result.ReturnData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack getAmountsOut: %w", err)
+	}
+
+	amounts := values[0].([]*big.Int)
+	return amounts[len(amounts)-1], nil
+}
+
+// uint decodes the uint256 returned by a successful step
+func (c *TokenSafetyChecker) uint(contractABI abi.ABI, method string, result probeResult) (*big.Int, error) {
+	if !result.Success {
+		return nil, fmt.Errorf("%s reverted: %s", method, revertReason(result.ReturnData))
+	}
+
+	values, err := contractABI.Unpack(method, result.ReturnData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+	return values[0].(*big.Int), nil
+}
+
+// revertReason decodes an Error(string) revert reason, if any
+func revertReason(data []byte) string {
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason
+	}
+	return "execution reverted"
+}
+
+// taxBps returns the shortfall of actual vs expected in basis points
+func taxBps(expected, actual *big.Int) int {
+	if expected.Sign() == 0 || actual.Cmp(expected) >= 0 {
+		return 0
+	}
+
+	shortfall := new(big.Int).Sub(expected, actual)
+	shortfall.Mul(shortfall, config.BigInt10000)
+	return int(shortfall.Div(shortfall, expected).Int64())
+}
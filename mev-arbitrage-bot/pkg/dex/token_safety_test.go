@@ -0,0 +1,338 @@
+package dex
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
+)
+
+var (
+	testWETH      = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	testTaxToken  = common.HexToAddress("0x00000000000000000000000000000000000070c3")
+	testMulticall = common.HexToAddress(config.Multicall3Address)
+)
+
+// fakeTokenChain answers the round-trip eth_call by running each Multicall3
+// step against a WETH/token pool and a token that may tax or block transfers
+type fakeTokenChain struct {
+	t              *testing.T
+	buyTaxBps      int64
+	transferTaxBps int64
+	sellTaxBps     int64
+	honeypot       bool // Sells to the pair revert
+	blocks         []string
+	routerABI      abi.ABI
+	erc20ABI       abi.ABI
+	multicallABI   abi.ABI
+	mu             sync.Mutex
+}
+
+// tradeState is the state of a single eth_call
+type tradeState struct {
+	reserveETH   *big.Int
+	reserveToken *big.Int
+	tokens       map[common.Address]*big.Int
+	eth          map[common.Address]*big.Int
+}
+
+func newFakeTokenChain(t *testing.T) (*fakeTokenChain, *ethclient.Client) {
+	chain := &fakeTokenChain{t: t}
+	for _, parse := range []struct {
+		target *abi.ABI
+		json   string
+	}{
+		{&chain.routerABI, UniswapV2RouterSwapABI},
+		{&chain.erc20ABI, ERC20ABI},
+		{&chain.multicallABI, Multicall3ABI},
+	} {
+		parsed, err := abi.JSON(strings.NewReader(parse.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		*parse.target = parsed
+	}
+
+	server := httptest.NewServer(chain)
+	t.Cleanup(server.Close)
+
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatalf("dial fake node: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return chain, client
+}
+
+func (c *fakeTokenChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.t.Errorf("decode request: %v", err)
+		return
+	}
+
+	var result interface{}
+	switch req.Method {
+	case "eth_blockNumber":
+		result = "0x64"
+	case "eth_call":
+		output, err := c.call(req.Params)
+		if err != nil {
+			c.t.Errorf("eth_call: %v", err)
+			return
+		}
+		result = hexutil.Bytes(output)
+	default:
+		c.t.Errorf("unexpected request %q", req.Method)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+// call runs an aggregate3Value batch, checking the caller's ETH comes from
+// a balance override
+func (c *fakeTokenChain) call(params []json.RawMessage) ([]byte, error) {
+	var msg struct {
+		From  common.Address `json:"from"`
+		To    common.Address `json:"to"`
+		Value *hexutil.Big   `json:"value"`
+		Input hexutil.Bytes  `json:"input"`
+	}
+	if err := json.Unmarshal(params[0], &msg); err != nil {
+		return nil, err
+	}
+	if msg.To != testMulticall || len(params) != 3 {
+		c.t.Errorf("call to %s with %d params, want Multicall3 with a state override", msg.To.Hex(), len(params))
+	}
+
+	var overrides map[common.Address]struct {
+		Balance *hexutil.Big `json:"balance"`
+	}
+	if err := json.Unmarshal(params[2], &overrides); err != nil {
+		return nil, err
+	}
+	if funded := overrides[msg.From].Balance; funded == nil || funded.ToInt().Cmp(msg.Value.ToInt()) < 0 {
+		c.t.Errorf("caller %s not funded for %s wei", msg.From.Hex(), msg.Value.ToInt())
+	}
+
+	var block string
+	json.Unmarshal(params[1], &block)
+	c.mu.Lock()
+	c.blocks = append(c.blocks, block)
+	c.mu.Unlock()
+
+	values, err := c.multicallABI.Methods["aggregate3Value"].Inputs.Unpack(msg.Input[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(values[0], new([]probeCall)).(*[]probeCall)
+
+	state := &tradeState{
+		reserveETH:   new(big.Int).Mul(big.NewInt(1000), config.WeiPerEtherBigInt),
+		reserveToken: new(big.Int).Mul(big.NewInt(1000000), config.WeiPerEtherBigInt),
+		tokens:       make(map[common.Address]*big.Int),
+		eth:          make(map[common.Address]*big.Int),
+	}
+	results := make([]probeResult, len(calls))
+	for i, call := range calls {
+		output, ok := c.step(state, call)
+		results[i] = probeResult{Success: ok, ReturnData: output}
+	}
+
+	return c.multicallABI.Methods["aggregate3Value"].Outputs.Pack(results)
+}
+
+// step executes one call as the Multicall3 contract
+func (c *fakeTokenChain) step(state *tradeState, call probeCall) ([]byte, bool) {
+	contractABI := c.erc20ABI
+	switch call.Target {
+	case testRouter:
+		contractABI = c.routerABI
+	case testMulticall:
+		contractABI = c.multicallABI
+	}
+	method, err := contractABI.MethodById(call.CallData[:4])
+	if err != nil {
+		c.t.Errorf("unknown call to %s: %v", call.Target.Hex(), err)
+		return nil, false
+	}
+	args, _ := method.Inputs.Unpack(call.CallData[4:])
+
+	balance := func(holder common.Address) *big.Int {
+		if state.tokens[holder] == nil {
+			state.tokens[holder] = new(big.Int)
+		}
+		return state.tokens[holder]
+	}
+
+	switch method.Name {
+	case "getAmountsOut":
+		amountIn, path := args[0].(*big.Int), args[1].([]common.Address)
+		out := utils.CalculateAmountOut(amountIn, state.reserveETH, state.reserveToken, 30)
+		if path[0] != testWETH {
+			out = utils.CalculateAmountOut(amountIn, state.reserveToken, state.reserveETH, 30)
+		}
+		data, _ := method.Outputs.Pack([]*big.Int{amountIn, out})
+		return data, true
+
+	case "swapExactETHForTokensSupportingFeeOnTransferTokens":
+		out := utils.CalculateAmountOut(call.Value, state.reserveETH, state.reserveToken, 30)
+		state.reserveETH.Add(state.reserveETH, call.Value)
+		state.reserveToken.Sub(state.reserveToken, out)
+		balance(args[2].(common.Address)).Add(balance(args[2].(common.Address)), afterTax(out, c.buyTaxBps))
+		return nil, true
+
+	case "swapExactTokensForETHSupportingFeeOnTransferTokens":
+		amountIn, to := args[0].(*big.Int), args[3].(common.Address)
+		if c.honeypot || balance(testMulticall).Cmp(amountIn) < 0 {
+			return revertData("TransferHelper: TRANSFER_FROM_FAILED"), false
+		}
+		balance(testMulticall).Sub(balance(testMulticall), amountIn)
+		net := afterTax(amountIn, c.sellTaxBps)
+		out := utils.CalculateAmountOut(net, state.reserveToken, state.reserveETH, 30)
+		state.reserveToken.Add(state.reserveToken, net)
+		state.reserveETH.Sub(state.reserveETH, out)
+		if state.eth[to] == nil {
+			state.eth[to] = new(big.Int)
+		}
+		state.eth[to].Add(state.eth[to], out)
+		return nil, true
+
+	case "balanceOf":
+		data, _ := method.Outputs.Pack(balance(args[0].(common.Address)))
+		return data, true
+
+	case "transfer":
+		to, amount := args[0].(common.Address), args[1].(*big.Int)
+		if balance(testMulticall).Cmp(amount) < 0 {
+			return revertData("insufficient balance"), false
+		}
+		balance(testMulticall).Sub(balance(testMulticall), amount)
+		balance(to).Add(balance(to), afterTax(amount, c.transferTaxBps))
+		data, _ := method.Outputs.Pack(true)
+		return data, true
+
+	case "approve":
+		data, _ := method.Outputs.Pack(true)
+		return data, true
+
+	case "getEthBalance":
+		eth := state.eth[args[0].(common.Address)]
+		if eth == nil {
+			eth = new(big.Int)
+		}
+		data, _ := method.Outputs.Pack(eth)
+		return data, true
+	}
+
+	c.t.Errorf("unhandled call %s", method.Name)
+	return nil, false
+}
+
+func afterTax(amount *big.Int, taxBps int64) *big.Int {
+	kept := new(big.Int).Mul(amount, big.NewInt(10000-taxBps))
+	return kept.Div(kept, big.NewInt(10000))
+}
+
+func revertData(reason string) []byte {
+	stringType, _ := abi.NewType("string", "", nil)
+	data, _ := abi.Arguments{{Type: stringType}}.Pack(reason)
+	return append([]byte{0x08, 0xc3, 0x79, 0xa0}, data...)
+}
+
+func newTestSafetyChecker(t *testing.T, client *ethclient.Client, maxTaxBps int) *TokenSafetyChecker {
+	checker, err := NewTokenSafetyChecker(client, testRouter, testWETH, maxTaxBps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return checker
+}
+
+func TestTokenSafetyMeasuresTaxes(t *testing.T) {
+	chain, client := newFakeTokenChain(t)
+	chain.buyTaxBps, chain.transferTaxBps, chain.sellTaxBps = 200, 100, 500
+
+	report, err := newTestSafetyChecker(t, client, 1000).Check(context.Background(), testTaxToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Safe || !report.CanSell || report.BlockNumber != 100 {
+		t.Fatalf("taxed token within the limit not safe: %+v", report)
+	}
+	if report.BuyTaxBps != 200 || report.TransferTaxBps != 100 {
+		t.Errorf("buy/transfer tax %d/%d bps, want 200/100", report.BuyTaxBps, report.TransferTaxBps)
+	}
+	// The sell quote is taken before the taxed tokens reach the pair
+	if report.SellTaxBps < 495 || report.SellTaxBps > 500 {
+		t.Errorf("sell tax %d bps, want about 500", report.SellTaxBps)
+	}
+	if report.EffectiveTaxBps() != report.SellTaxBps {
+		t.Errorf("effective tax %d, want the largest (sell) tax", report.EffectiveTaxBps())
+	}
+
+	// Both calls run at the same block so the second replays the first buy
+	if len(chain.blocks) != 2 || chain.blocks[0] != "0x64" || chain.blocks[1] != "0x64" {
+		t.Errorf("round trip ran at blocks %v, want both at 0x64", chain.blocks)
+	}
+}
+
+func TestTokenSafetyRejectsOverTaxedToken(t *testing.T) {
+	chain, client := newFakeTokenChain(t)
+	chain.transferTaxBps = 300
+
+	report, err := newTestSafetyChecker(t, client, 100).Check(context.Background(), testTaxToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Safe || !report.CanSell || !strings.Contains(report.Reason, "transfer tax") {
+		t.Fatalf("over-taxed token not rejected: %+v", report)
+	}
+}
+
+func TestTokenSafetyDetectsHoneypot(t *testing.T) {
+	chain, client := newFakeTokenChain(t)
+	chain.honeypot = true
+
+	checker := newTestSafetyChecker(t, client, 100)
+	report, err := checker.Check(context.Background(), testTaxToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Safe || report.CanSell || !strings.Contains(report.Reason, "honeypot") ||
+		!strings.Contains(report.Reason, "TRANSFER_FROM_FAILED") {
+		t.Fatalf("honeypot not detected: %+v", report)
+	}
+	if checker.IsSafe(testTaxToken) {
+		t.Fatal("honeypot reported safe")
+	}
+}
+
+func TestTokenSafetyUncheckedTokenIsUnknown(t *testing.T) {
+	_, client := newFakeTokenChain(t)
+	checker := newTestSafetyChecker(t, client, 100)
+
+	if !checker.IsSafe(testTaxToken) || checker.Report(testTaxToken) != nil {
+		t.Fatal("unchecked token treated as unsafe")
+	}
+	if checker.EffectiveTaxBps(testTaxToken) != 0 {
+		t.Fatal("unchecked token has a tax")
+	}
+}
@@ -1,6 +1,7 @@
 package dex
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -19,12 +20,25 @@ const (
 type Pool struct {
 	Address     common.Address // Pool contract address
 	DEX         DEXType        // DEX type
-	Token0      common.Address // First token address
-	Token1      common.Address // Second token address
+	Token0      common.Address // Lower token address (pair order)
+	Token1      common.Address // Higher token address (pair order)
 	Reserve0    *big.Int       // Reserve of token0
 	Reserve1    *big.Int       // Reserve of token1
 	Fee         int            // Fee in basis points (30 = 0.3%)
 	LastUpdated int64          // Unix timestamp of last update
+	BlockNumber uint64         // Block the reserves were fetched at (0 if unknown)
+}
+
+// GetReservesFor returns the pool's reserves oriented for a swap from tokenIn
+func (p *Pool) GetReservesFor(tokenIn common.Address) (reserveIn, reserveOut *big.Int, err error) {
+	switch tokenIn {
+	case p.Token0:
+		return p.Reserve0, p.Reserve1, nil
+	case p.Token1:
+		return p.Reserve1, p.Reserve0, nil
+	default:
+		return nil, nil, fmt.Errorf("token %s not in pool %s", tokenIn.Hex(), p.Address.Hex())
+	}
 }
 
 // Token represents an ERC20 token
@@ -105,3 +119,18 @@ type DEXAdapter interface {
 	// GetFactoryAddress returns the factory contract address
 	GetFactoryAddress() common.Address
 }
+
+// PairAddressComputer is implemented by V2-style adapters that can derive
+// pair addresses offline (CREATE2) instead of querying the factory
+type PairAddressComputer interface {
+	// ComputePairAddress returns the pair address for two tokens, or false
+	// if offline derivation is unavailable
+	ComputePairAddress(token0, token1 common.Address) (common.Address, bool)
+}
+
+// BlockReservesReader is implemented by adapters that can read reserves as
+// of a specific block, so pool state carries the block it reflects
+type BlockReservesReader interface {
+	// GetReservesAt fetches reserves of a pool as of the block with the given hash
+	GetReservesAt(pairAddress common.Address, blockHash common.Hash) (*big.Int, *big.Int, error)
+}
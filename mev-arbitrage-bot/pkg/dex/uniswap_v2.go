@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -77,8 +78,10 @@ const UniswapV2PairABI = `[
 	}
 ]`
 
-// UniswapV2Adapter implements DEXAdapter for Uniswap V2
+// UniswapV2Adapter implements DEXAdapter for Uniswap V2 and its forks
 type UniswapV2Adapter struct {
+	name           string
+	dexType        DEXType
 	client         *ethclient.Client
 	routerAddress  common.Address
 	factoryAddress common.Address
@@ -86,10 +89,36 @@ type UniswapV2Adapter struct {
 	factoryABI     abi.ABI
 	pairABI        abi.ABI
 	fee            int // 30 basis points (0.3%)
+	slippageBps    int // Quote tolerance for MinAmountOut
+
+	// Offline pair address derivation
+	initCodeHash    common.Hash // Zero disables derivation
+	derivationState int32       // derivationUnverified / Verified / Disabled
+	mu              sync.Mutex
+}
+
+// Pair address derivation states
+const (
+	derivationUnverified int32 = iota
+	derivationVerified
+	derivationDisabled
+)
+
+// NewUniswapV2Adapter creates a new Uniswap V2 adapter. initCodeHash is the
+// pair init code hash used to derive pair addresses offline (zero = always
+// query the factory).
+func NewUniswapV2Adapter(client *ethclient.Client, routerAddress common.Address, initCodeHash common.Hash) (*UniswapV2Adapter, error) {
+	return newV2Adapter(client, "Uniswap V2", UniswapV2, config.UniswapV2FeeBps, routerAddress, initCodeHash)
+}
+
+// NewSushiSwapAdapter creates an adapter for SushiSwap, a Uniswap V2 fork
+// with its own factory, router and pair init code hash
+func NewSushiSwapAdapter(client *ethclient.Client, routerAddress common.Address, initCodeHash common.Hash) (*UniswapV2Adapter, error) {
+	return newV2Adapter(client, "SushiSwap", SushiSwap, config.SushiSwapFeeBps, routerAddress, initCodeHash)
 }
 
-// NewUniswapV2Adapter creates a new Uniswap V2 adapter
-func NewUniswapV2Adapter(client *ethclient.Client, routerAddress common.Address) (*UniswapV2Adapter, error) {
+// newV2Adapter creates an adapter for a Uniswap V2 compatible DEX
+func newV2Adapter(client *ethclient.Client, name string, dexType DEXType, feeBps int, routerAddress common.Address, initCodeHash common.Hash) (*UniswapV2Adapter, error) {
 	// Parse ABIs
 	routerABI, err := abi.JSON(strings.NewReader(UniswapV2RouterABI))
 	if err != nil {
@@ -107,12 +136,16 @@ func NewUniswapV2Adapter(client *ethclient.Client, routerAddress common.Address)
 	}
 
 	adapter := &UniswapV2Adapter{
+		name:          name,
+		dexType:       dexType,
 		client:        client,
 		routerAddress: routerAddress,
 		routerABI:     routerABI,
 		factoryABI:    factoryABI,
 		pairABI:       pairABI,
-		fee:           config.UniswapV2FeeBps,
+		fee:           feeBps,
+		slippageBps:   config.DefaultSlippageBps,
+		initCodeHash:  initCodeHash,
 	}
 
 	// Get factory address from router
@@ -122,20 +155,20 @@ func NewUniswapV2Adapter(client *ethclient.Client, routerAddress common.Address)
 	}
 	adapter.factoryAddress = factoryAddr
 
-	log.Infof("Uniswap V2 adapter initialized (Router: %s, Factory: %s)",
-		routerAddress.Hex(), factoryAddr.Hex())
+	log.Infof("%s adapter initialized (Router: %s, Factory: %s)",
+		name, routerAddress.Hex(), factoryAddr.Hex())
 
 	return adapter, nil
 }
 
 // GetName returns the name of the DEX
 func (u *UniswapV2Adapter) GetName() string {
-	return "Uniswap V2"
+	return u.name
 }
 
 // GetType returns the type of DEX
 func (u *UniswapV2Adapter) GetType() DEXType {
-	return UniswapV2
+	return u.dexType
 }
 
 // GetRouterAddress returns the router contract address
@@ -175,8 +208,68 @@ func (u *UniswapV2Adapter) getFactoryFromRouter() (common.Address, error) {
 	return factoryAddr, nil
 }
 
-// GetPairAddress fetches the pair address for two tokens
+// GetPairAddress returns the pair address for two tokens. The address is
+// derived offline via CREATE2; the first derivation is verified against the
+// factory, and on mismatch the adapter falls back to querying the factory.
+// Once verified no RPC is made: a pair that was never created is reported by
+// the first call to its address (GetPool fails reading its tokens).
 func (u *UniswapV2Adapter) GetPairAddress(token0, token1 common.Address) (common.Address, error) {
+	if u.initCodeHash == (common.Hash{}) {
+		return u.getPairFromFactory(token0, token1)
+	}
+
+	u.mu.Lock()
+	state := u.derivationState
+	u.mu.Unlock()
+
+	switch state {
+	case derivationVerified:
+		return ComputePairAddress(u.factoryAddress, token0, token1, u.initCodeHash), nil
+	case derivationDisabled:
+		return u.getPairFromFactory(token0, token1)
+	}
+
+	// First use: verify the derivation against the factory
+	onChain, err := u.getPairFromFactory(token0, token1)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	derived := ComputePairAddress(u.factoryAddress, token0, token1, u.initCodeHash)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if derived == onChain {
+		u.derivationState = derivationVerified
+		log.Infof("%s pair address derivation verified (init code hash %s)", u.GetName(), u.initCodeHash.Hex()[:10])
+	} else {
+		u.derivationState = derivationDisabled
+		log.Warnf("%s init code hash %s does not match factory (derived %s, factory %s), using getPair",
+			u.GetName(), u.initCodeHash.Hex()[:10], derived.Hex(), onChain.Hex())
+	}
+
+	return onChain, nil
+}
+
+// ComputePairAddress derives the pair address for two tokens without RPC.
+// Returns false until the init code hash has been verified against the
+// factory (the first GetPairAddress), or if derivation is unavailable. The
+// pair at the returned address may not exist.
+func (u *UniswapV2Adapter) ComputePairAddress(token0, token1 common.Address) (common.Address, bool) {
+	u.mu.Lock()
+	state := u.derivationState
+	u.mu.Unlock()
+
+	if state != derivationVerified {
+		return common.Address{}, false
+	}
+
+	return ComputePairAddress(u.factoryAddress, token0, token1, u.initCodeHash), true
+}
+
+// getPairFromFactory fetches the pair address for two tokens from the factory
+func (u *UniswapV2Adapter) getPairFromFactory(token0, token1 common.Address) (common.Address, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -209,6 +302,12 @@ func (u *UniswapV2Adapter) GetPairAddress(token0, token1 common.Address) (common
 
 // GetReserves fetches current reserves of a pair
 func (u *UniswapV2Adapter) GetReserves(pairAddress common.Address) (*big.Int, *big.Int, error) {
+	return u.GetReservesAt(pairAddress, common.Hash{})
+}
+
+// GetReservesAt fetches reserves of a pair as of the block with the given
+// hash (zero = latest)
+func (u *UniswapV2Adapter) GetReservesAt(pairAddress common.Address, blockHash common.Hash) (*big.Int, *big.Int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -217,7 +316,7 @@ func (u *UniswapV2Adapter) GetReserves(pairAddress common.Address) (*big.Int, *b
 
 	// Call getReserves()
 	var result []interface{}
-	err := contract.Call(&bind.CallOpts{Context: ctx}, &result, "getReserves")
+	err := contract.Call(&bind.CallOpts{Context: ctx, BlockHash: blockHash}, &result, "getReserves")
 	if err != nil {
 		return nil, nil, fmt.Errorf("getReserves call failed: %w", err)
 	}
@@ -239,14 +338,57 @@ func (u *UniswapV2Adapter) GetReserves(pairAddress common.Address) (*big.Int, *b
 	return reserve0, reserve1, nil
 }
 
-// GetPool fetches pool information
-func (u *UniswapV2Adapter) GetPool(token0, token1 common.Address) (*Pool, error) {
+// GetPairTokens fetches a pair's token0 and token1 (sorted by address)
+func (u *UniswapV2Adapter) GetPairTokens(pairAddress common.Address) (common.Address, common.Address, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Create bound contract
+	contract := bind.NewBoundContract(pairAddress, u.pairABI, u.client, u.client, u.client)
+
+	tokens := make([]common.Address, 2)
+	for i, method := range []string{"token0", "token1"} {
+		var result []interface{}
+		err := contract.Call(&bind.CallOpts{Context: ctx}, &result, method)
+		if err != nil {
+			return common.Address{}, common.Address{}, fmt.Errorf("%s call failed: %w", method, err)
+		}
+
+		if len(result) == 0 {
+			return common.Address{}, common.Address{}, fmt.Errorf("%s returned no results", method)
+		}
+
+		token, ok := result[0].(common.Address)
+		if !ok {
+			return common.Address{}, common.Address{}, fmt.Errorf("invalid %s address type", method)
+		}
+		tokens[i] = token
+	}
+
+	return tokens[0], tokens[1], nil
+}
+
+// GetPool fetches pool information. Token0/Token1 of the returned pool are
+// in the pair's canonical order (matching getReserves), regardless of the
+// order the tokens are passed in.
+func (u *UniswapV2Adapter) GetPool(tokenA, tokenB common.Address) (*Pool, error) {
 	// Get pair address
-	pairAddr, err := u.GetPairAddress(token0, token1)
+	pairAddr, err := u.GetPairAddress(tokenA, tokenB)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pair address: %w", err)
 	}
 
+	// Get canonical token order
+	token0, token1, err := u.GetPairTokens(pairAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pair tokens: %w", err)
+	}
+
+	if !((token0 == tokenA && token1 == tokenB) || (token0 == tokenB && token1 == tokenA)) {
+		return nil, fmt.Errorf("pair %s holds %s/%s, not the requested tokens",
+			pairAddr.Hex(), token0.Hex(), token1.Hex())
+	}
+
 	// Get reserves
 	reserve0, reserve1, err := u.GetReserves(pairAddr)
 	if err != nil {
@@ -255,7 +397,7 @@ func (u *UniswapV2Adapter) GetPool(token0, token1 common.Address) (*Pool, error)
 
 	pool := &Pool{
 		Address:     pairAddr,
-		DEX:         UniswapV2,
+		DEX:         u.dexType,
 		Token0:      token0,
 		Token1:      token1,
 		Reserve0:    reserve0,
@@ -277,6 +419,11 @@ func (u *UniswapV2Adapter) GetAmountIn(amountOut *big.Int, reserveIn, reserveOut
 	return utils.CalculateAmountIn(amountOut, reserveIn, reserveOut, u.fee)
 }
 
+// SetSlippageBps sets the tolerance Quote applies to MinAmountOut
+func (u *UniswapV2Adapter) SetSlippageBps(bps int) {
+	u.slippageBps = bps
+}
+
 // Quote provides a price quote for a swap
 func (u *UniswapV2Adapter) Quote(amountIn *big.Int, tokenIn, tokenOut common.Address) (*QuoteResult, error) {
 	// Get pool
@@ -286,13 +433,9 @@ func (u *UniswapV2Adapter) Quote(amountIn *big.Int, tokenIn, tokenOut common.Add
 	}
 
 	// Determine direction
-	var reserveIn, reserveOut *big.Int
-	if pool.Token0 == tokenIn {
-		reserveIn = pool.Reserve0
-		reserveOut = pool.Reserve1
-	} else {
-		reserveIn = pool.Reserve1
-		reserveOut = pool.Reserve0
+	reserveIn, reserveOut, err := pool.GetReservesFor(tokenIn)
+	if err != nil {
+		return nil, err
 	}
 
 	// Calculate amount out
@@ -305,8 +448,8 @@ func (u *UniswapV2Adapter) Quote(amountIn *big.Int, tokenIn, tokenOut common.Add
 	fee := new(big.Int).Mul(amountIn, big.NewInt(int64(u.fee)))
 	fee.Div(fee, config.BigInt10000)
 
-	// Calculate min amount out (with 0.5% slippage tolerance)
-	slippage := big.NewInt(50) // 0.5%
+	// Calculate min amount out (with the slippage tolerance)
+	slippage := big.NewInt(int64(u.slippageBps))
 	minAmountOut := new(big.Int).Mul(amountOut, new(big.Int).Sub(config.BigInt10000, slippage))
 	minAmountOut.Div(minAmountOut, config.BigInt10000)
 
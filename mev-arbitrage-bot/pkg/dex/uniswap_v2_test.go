@@ -0,0 +1,332 @@
+package dex
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+)
+
+var (
+	testRouter  = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	testFactory = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+	testPair    = common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+)
+
+// fakePairNode is a JSON-RPC server answering the eth_calls a V2 adapter
+// makes for a single pair
+type fakePairNode struct {
+	t        *testing.T
+	pairABI  abi.ABI
+	pair     common.Address // Returned by getPair and the only address with code
+	token0   common.Address
+	token1   common.Address
+	reserve0 *big.Int
+	reserve1 *big.Int
+	requests int // JSON-RPC requests served
+	mu       sync.Mutex
+}
+
+func newFakePairNode(t *testing.T) (*fakePairNode, *ethclient.Client) {
+	pairABI, err := abi.JSON(strings.NewReader(UniswapV2PairABI))
+	if err != nil {
+		t.Fatalf("parse pair ABI: %v", err)
+	}
+
+	node := &fakePairNode{t: t, pairABI: pairABI, pair: testPair}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatalf("dial fake node: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	return node, client
+}
+
+// setPair stores a pair's tokens in canonical order with its reserves
+func (n *fakePairNode) setPair(tokenA, tokenB common.Address, reserveA, reserveB *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if bytes.Compare(tokenA.Bytes(), tokenB.Bytes()) < 0 {
+		n.token0, n.token1, n.reserve0, n.reserve1 = tokenA, tokenB, reserveA, reserveB
+	} else {
+		n.token0, n.token1, n.reserve0, n.reserve1 = tokenB, tokenA, reserveB, reserveA
+	}
+}
+
+func (n *fakePairNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		n.t.Errorf("decode request: %v", err)
+		return
+	}
+
+	n.mu.Lock()
+	n.requests++
+	n.mu.Unlock()
+
+	switch req.Method {
+	case "eth_call":
+	case "eth_getCode":
+		n.serveCode(w, req.ID, req.Params)
+		return
+	default:
+		n.t.Errorf("unexpected request %q", req.Method)
+		return
+	}
+
+	var msg struct {
+		To    common.Address `json:"to"`
+		Input hexutil.Bytes  `json:"input"`
+	}
+	if err := json.Unmarshal(req.Params[0], &msg); err != nil {
+		n.t.Errorf("decode call: %v", err)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var result []byte
+	switch selector := hex.EncodeToString(msg.Input[:4]); {
+	case msg.To != testRouter && msg.To != testFactory && msg.To != n.pair:
+		// No code at the address: the call succeeds with empty output
+	case selector == "c45a0155": // factory()
+		result = common.LeftPadBytes(testFactory.Bytes(), 32)
+	case selector == "e6a43905": // getPair(address,address)
+		result = common.LeftPadBytes(n.pair.Bytes(), 32)
+	case selector == "0dfe1681": // token0()
+		result = common.LeftPadBytes(n.token0.Bytes(), 32)
+	case selector == "d21220a7": // token1()
+		result = common.LeftPadBytes(n.token1.Bytes(), 32)
+	case selector == "0902f1ac": // getReserves()
+		packed, err := n.pairABI.Methods["getReserves"].Outputs.Pack(n.reserve0, n.reserve1, uint32(0))
+		if err != nil {
+			n.t.Errorf("pack reserves: %v", err)
+			return
+		}
+		result = packed
+	default:
+		n.t.Errorf("unexpected call to %s: %x", msg.To.Hex(), msg.Input)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"result":  hexutil.Bytes(result),
+	})
+}
+
+// serveCode answers eth_getCode: only the pair has code
+func (n *fakePairNode) serveCode(w http.ResponseWriter, id json.RawMessage, params []json.RawMessage) {
+	var address common.Address
+	if err := json.Unmarshal(params[0], &address); err != nil {
+		n.t.Errorf("decode address: %v", err)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	code := hexutil.Bytes{}
+	if address == n.pair && n.pair != (common.Address{}) {
+		code = hexutil.Bytes{0x60, 0x80}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"result":  code,
+	})
+}
+
+// refGetAmountsOut mirrors UniswapV2Router02.getAmountsOut for a single hop:
+// reserves are looked up in sorted token order, fee is 0.3%
+func refGetAmountsOut(amountIn *big.Int, tokenIn, tokenOut common.Address, reserves map[common.Address]*big.Int) *big.Int {
+	reserveIn, reserveOut := reserves[tokenIn], reserves[tokenOut]
+
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(997))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(1000))
+	denominator.Add(denominator, amountInWithFee)
+
+	return numerator.Div(numerator, denominator)
+}
+
+func randomAddress(rng *rand.Rand) common.Address {
+	var addr common.Address
+	rng.Read(addr[:])
+	return addr
+}
+
+// randomAmount returns a value in [1, 10^digits)
+func randomAmount(rng *rand.Rand, digits int) *big.Int {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	amount := new(big.Int).Rand(rng, limit)
+	return amount.Add(amount, big.NewInt(1))
+}
+
+func newTestAdapter(t *testing.T, client *ethclient.Client) *UniswapV2Adapter {
+	adapter, err := NewUniswapV2Adapter(client, testRouter, common.Hash{})
+	if err != nil {
+		t.Fatalf("create adapter: %v", err)
+	}
+	return adapter
+}
+
+func TestGetPoolCanonicalOrdering(t *testing.T) {
+	node, client := newFakePairNode(t)
+	adapter := newTestAdapter(t, client)
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		tokenA, tokenB := randomAddress(rng), randomAddress(rng)
+		reserveA, reserveB := randomAmount(rng, 30), randomAmount(rng, 30)
+		node.setPair(tokenA, tokenB, reserveA, reserveB)
+
+		for _, order := range [][2]common.Address{{tokenA, tokenB}, {tokenB, tokenA}} {
+			pool, err := adapter.GetPool(order[0], order[1])
+			if err != nil {
+				t.Fatalf("GetPool: %v", err)
+			}
+
+			if bytes.Compare(pool.Token0.Bytes(), pool.Token1.Bytes()) >= 0 {
+				t.Fatalf("pool tokens not sorted: %s >= %s", pool.Token0.Hex(), pool.Token1.Hex())
+			}
+
+			gotA, _, err := pool.GetReservesFor(tokenA)
+			if err != nil {
+				t.Fatalf("GetReservesFor: %v", err)
+			}
+			if gotA.Cmp(reserveA) != 0 {
+				t.Fatalf("reserve of tokenA = %s, want %s (order %s/%s)",
+					gotA, reserveA, order[0].Hex(), order[1].Hex())
+			}
+		}
+	}
+}
+
+func TestQuoteMatchesGetAmountsOut(t *testing.T) {
+	node, client := newFakePairNode(t)
+	adapter := newTestAdapter(t, client)
+	rng := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 200; i++ {
+		tokenA, tokenB := randomAddress(rng), randomAddress(rng)
+		reserves := map[common.Address]*big.Int{
+			tokenA: randomAmount(rng, 1+rng.Intn(30)),
+			tokenB: randomAmount(rng, 1+rng.Intn(30)),
+		}
+		node.setPair(tokenA, tokenB, reserves[tokenA], reserves[tokenB])
+
+		amountIn := randomAmount(rng, 1+rng.Intn(30))
+
+		for _, dir := range [][2]common.Address{{tokenA, tokenB}, {tokenB, tokenA}} {
+			tokenIn, tokenOut := dir[0], dir[1]
+
+			quote, err := adapter.Quote(amountIn, tokenIn, tokenOut)
+			if err != nil {
+				t.Fatalf("Quote: %v", err)
+			}
+
+			want := refGetAmountsOut(amountIn, tokenIn, tokenOut, reserves)
+			if quote.AmountOut.Cmp(want) != 0 {
+				t.Fatalf("Quote(%s, %s->%s) = %s, getAmountsOut = %s",
+					amountIn, tokenIn.Hex(), tokenOut.Hex(), quote.AmountOut, want)
+			}
+
+			if quote.MinAmountOut.Cmp(quote.AmountOut) > 0 {
+				t.Fatalf("MinAmountOut %s > AmountOut %s", quote.MinAmountOut, quote.AmountOut)
+			}
+		}
+	}
+}
+
+func TestGetReservesForUnknownToken(t *testing.T) {
+	pool := &Pool{
+		Token0:   common.HexToAddress("0x01"),
+		Token1:   common.HexToAddress("0x02"),
+		Reserve0: big.NewInt(1),
+		Reserve1: big.NewInt(2),
+	}
+
+	if _, _, err := pool.GetReservesFor(common.HexToAddress("0x03")); err == nil {
+		t.Fatal("expected error for token not in pool")
+	}
+}
+
+func TestComputePairAddressKnownAnswer(t *testing.T) {
+	weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	initCodeHash := common.HexToHash(config.UniswapV2InitCodeHash)
+
+	for _, order := range [][2]common.Address{{weth, usdc}, {usdc, weth}} {
+		if got := ComputePairAddress(testFactory, order[0], order[1], initCodeHash); got != testPair {
+			t.Fatalf("derived %s, want the WETH/USDC pair %s", got.Hex(), testPair.Hex())
+		}
+	}
+}
+
+func TestPairDerivationRequiresVerification(t *testing.T) {
+	weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	dai := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+
+	node, client := newFakePairNode(t)
+	node.setPair(weth, usdc, big.NewInt(1), big.NewInt(1))
+	adapter, err := NewUniswapV2Adapter(client, testRouter, common.HexToHash(config.UniswapV2InitCodeHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := adapter.ComputePairAddress(weth, usdc); ok {
+		t.Fatal("derivation offered before the init code hash was verified")
+	}
+
+	// The first lookup verifies the derivation against the factory
+	pair, err := adapter.GetPairAddress(weth, usdc)
+	if err != nil || pair != testPair {
+		t.Fatalf("GetPairAddress = %s, %v", pair.Hex(), err)
+	}
+	if derived, ok := adapter.ComputePairAddress(usdc, weth); !ok || derived != testPair {
+		t.Fatalf("derivation not available after verification: %s", derived.Hex())
+	}
+
+	// Once verified, addresses are derived without RPC
+	node.mu.Lock()
+	node.requests = 0
+	node.mu.Unlock()
+	derived, err := adapter.GetPairAddress(weth, dai)
+	if err != nil || derived != ComputePairAddress(testFactory, weth, dai, common.HexToHash(config.UniswapV2InitCodeHash)) {
+		t.Fatalf("GetPairAddress = %s, %v", derived.Hex(), err)
+	}
+	if node.requests != 0 {
+		t.Fatalf("verified derivation made %d requests", node.requests)
+	}
+
+	// A pair the factory never created has no code: reading it fails
+	if pool, err := adapter.GetPool(weth, dai); err == nil {
+		t.Fatalf("pool %s returned for a pair that does not exist", pool.Address.Hex())
+	}
+}
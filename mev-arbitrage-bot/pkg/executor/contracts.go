@@ -0,0 +1,235 @@
+package executor
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/flashloan"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/strategy"
+)
+
+// FlashArbitrageABI is the capital-based contract (FlashArbitrage.sol)
+const FlashArbitrageABI = `[
+	{"inputs":[{"name":"routers","type":"address[3]"},{"name":"tokens","type":"address[3]"},{"name":"amountIn","type":"uint256"},{"name":"minProfitBps","type":"uint256"}],"name":"executeArbitrage","outputs":[{"name":"finalAmount","type":"uint256"}],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"routers","type":"address[3]"},{"name":"tokens","type":"address[3]"},{"name":"amountIn","type":"uint256"},{"name":"minAmountsOut","type":"uint256[3]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeArbitrageWithMinOut","outputs":[{"name":"finalAmount","type":"uint256"}],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"routers","type":"address[]"},{"name":"tokens","type":"address[]"},{"name":"amountIn","type":"uint256"},{"name":"minAmountsOut","type":"uint256[]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeArbitragePath","outputs":[{"name":"finalAmount","type":"uint256"}],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"token","type":"address"}],"name":"withdrawProfit","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"token","type":"address"},{"name":"amount","type":"uint256"}],"name":"withdraw","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+
+// FlashLoanArbitrageABI is the flash-loan contract (FlashLoanArbitrage.sol).
+// The Balancer and flash-swap entry points take the lender (Vault, pair) first.
+const FlashLoanArbitrageABI = `[
+	{"inputs":[{"name":"asset","type":"address"},{"name":"loanAmount","type":"uint256"},{"name":"routers","type":"address[3]"},{"name":"tokens","type":"address[3]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeFlashLoanArbitrage","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"asset","type":"address"},{"name":"loanAmount","type":"uint256"},{"name":"routers","type":"address[3]"},{"name":"tokens","type":"address[3]"},{"name":"minAmountsOut","type":"uint256[3]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeFlashLoanArbitrageWithMinOut","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"asset","type":"address"},{"name":"loanAmount","type":"uint256"},{"name":"routers","type":"address[]"},{"name":"tokens","type":"address[]"},{"name":"minAmountsOut","type":"uint256[]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeFlashLoanArbitragePath","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"vault","type":"address"},{"name":"asset","type":"address"},{"name":"loanAmount","type":"uint256"},{"name":"routers","type":"address[3]"},{"name":"tokens","type":"address[3]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeBalancerFlashLoan","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"vault","type":"address"},{"name":"asset","type":"address"},{"name":"loanAmount","type":"uint256"},{"name":"routers","type":"address[3]"},{"name":"tokens","type":"address[3]"},{"name":"minAmountsOut","type":"uint256[3]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeBalancerFlashLoanWithMinOut","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"vault","type":"address"},{"name":"asset","type":"address"},{"name":"loanAmount","type":"uint256"},{"name":"routers","type":"address[]"},{"name":"tokens","type":"address[]"},{"name":"minAmountsOut","type":"uint256[]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeBalancerFlashLoanPath","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"pair","type":"address"},{"name":"asset","type":"address"},{"name":"loanAmount","type":"uint256"},{"name":"routers","type":"address[3]"},{"name":"tokens","type":"address[3]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeFlashSwap","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"pair","type":"address"},{"name":"asset","type":"address"},{"name":"loanAmount","type":"uint256"},{"name":"routers","type":"address[3]"},{"name":"tokens","type":"address[3]"},{"name":"minAmountsOut","type":"uint256[3]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeFlashSwapWithMinOut","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"pair","type":"address"},{"name":"asset","type":"address"},{"name":"loanAmount","type":"uint256"},{"name":"routers","type":"address[]"},{"name":"tokens","type":"address[]"},{"name":"minAmountsOut","type":"uint256[]"},{"name":"minProfitBps","type":"uint256"}],"name":"executeFlashSwapPath","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+
+// contractHops is the cycle length of the fixed-array entry points
+const contractHops = 3
+
+// minPathHops is the shortest cycle the contracts execute
+const minPathHops = 2
+
+// withMinOut is the suffix of the entry points taking per-hop minimum outputs
+const withMinOut = "WithMinOut"
+
+// anyLength is the suffix of the entry points taking cycles of any length
+const anyLength = "Path"
+
+// flashLoanEntryPoints are the flash-loan sources FlashLoanArbitrage.sol can
+// borrow from
+var flashLoanEntryPoints = []flashloan.EntryPoint{flashloan.EntryAave, flashloan.EntryBalancer, flashloan.EntryFlashSwap}
+
+// FlashLoanEntryPoints returns the flash-loan entry points the executor can
+// encode; the flash-loan selector should only compare these sources
+// FlashLoanEntryPoints 返回执行器能编码的闪电贷入口（闪电贷来源选择只应比较这些来源）
+func FlashLoanEntryPoints() []flashloan.EntryPoint {
+	return append([]flashloan.EntryPoint(nil), flashLoanEntryPoints...)
+}
+
+// ContractCall is an encoded arbitrage contract call
+type ContractCall struct {
+	To     common.Address
+	Data   []byte
+	Method string
+}
+
+// Contracts encodes calls to the deployed arbitrage contracts
+// Contracts 编码对已部署套利合约的调用
+type Contracts struct {
+	capitalABI   abi.ABI
+	flashLoanABI abi.ABI
+	capital      common.Address // FlashArbitrage
+	flashLoan    common.Address // FlashLoanArbitrage
+}
+
+// NewContracts parses the contract ABIs
+// NewContracts 解析合约 ABI
+func NewContracts(capital, flashLoan common.Address) (*Contracts, error) {
+	capitalABI, err := abi.JSON(strings.NewReader(FlashArbitrageABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FlashArbitrage ABI: %w", err)
+	}
+	flashLoanABI, err := abi.JSON(strings.NewReader(FlashLoanArbitrageABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FlashLoanArbitrage ABI: %w", err)
+	}
+
+	return &Contracts{
+		capitalABI:   capitalABI,
+		flashLoanABI: flashLoanABI,
+		capital:      capital,
+		flashLoan:    flashLoan,
+	}, nil
+}
+
+// EncodeArbitrage encodes the call executing a path: executeArbitrage on
+// the capital contract, or the flash-loan source's entry point
+// EncodeArbitrage 编码执行路径的调用
+//
+// 每跳通过路由器 swapExactTokensForTokens。3 跳循环 (A -> B -> C -> A) 使用固定数组入口，
+// 路径带有每跳最小输出时使用 ...WithMinOut 入口；其他长度（如 2 跳跨 DEX 路径）使用
+// 接受动态数组的 ...Path 入口，最小输出未设置时传 0
+func (c *Contracts) EncodeArbitrage(path *strategy.ArbitragePath, minProfitBps int) (*ContractCall, error) {
+	hops := len(path.Pools)
+	if hops < minPathHops || len(path.Routers) != hops || len(path.Tokens) != hops+1 {
+		return nil, fmt.Errorf("contracts execute cycles of %d or more hops, path has %d hops", minPathHops, hops)
+	}
+	if hops != contractHops {
+		return c.encodePath(path, minProfitBps)
+	}
+
+	var routers, tokens [contractHops]common.Address
+	copy(routers[:], path.Routers)
+	copy(tokens[:], path.Tokens[:contractHops])
+	minProfit := big.NewInt(int64(minProfitBps))
+
+	// 每跳最小输出（未设置时不传，合约接受任意输出）
+	var minAmountsOut [contractHops]*big.Int
+	hasMinOut := len(path.MinAmountsOut) == contractHops
+	if hasMinOut {
+		copy(minAmountsOut[:], path.MinAmountsOut)
+	}
+
+	if !path.FlashLoan {
+		if c.capital == (common.Address{}) {
+			return nil, fmt.Errorf("capital contract not configured")
+		}
+		method := "executeArbitrage"
+		args := []interface{}{routers, tokens, path.StartAmount, minProfit}
+		if hasMinOut {
+			method += withMinOut
+			args = []interface{}{routers, tokens, path.StartAmount, minAmountsOut, minProfit}
+		}
+		data, err := c.capitalABI.Pack(method, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", method, err)
+		}
+		return &ContractCall{To: c.capital, Data: data, Method: method}, nil
+	}
+
+	entryPoint, err := flashLoanEntryPoint(path)
+	if err != nil {
+		return nil, err
+	}
+
+	method := string(entryPoint)
+	args := []interface{}{path.StartToken, path.StartAmount, routers, tokens, minProfit}
+	if hasMinOut {
+		method += withMinOut
+		args = []interface{}{path.StartToken, path.StartAmount, routers, tokens, minAmountsOut, minProfit}
+	}
+	data, err := c.flashLoanABI.Pack(method, lenderArgs(path, entryPoint, args)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", method, err)
+	}
+	return &ContractCall{To: c.flashLoan, Data: data, Method: method}, nil
+}
+
+// encodePath encodes a cycle that is not 3 hops through the ...Path entry
+// points, which take dynamic arrays
+// encodePath 通过接受动态数组的 ...Path 入口编码非 3 跳的循环
+func (c *Contracts) encodePath(path *strategy.ArbitragePath, minProfitBps int) (*ContractCall, error) {
+	hops := len(path.Pools)
+	tokens := path.Tokens[:hops]
+	minProfit := big.NewInt(int64(minProfitBps))
+
+	minAmountsOut := make([]*big.Int, hops)
+	for i := range minAmountsOut {
+		minAmountsOut[i] = new(big.Int)
+		if len(path.MinAmountsOut) == hops && path.MinAmountsOut[i] != nil {
+			minAmountsOut[i] = path.MinAmountsOut[i]
+		}
+	}
+
+	if !path.FlashLoan {
+		if c.capital == (common.Address{}) {
+			return nil, fmt.Errorf("capital contract not configured")
+		}
+		method := "executeArbitrage" + anyLength
+		data, err := c.capitalABI.Pack(method, path.Routers, tokens, path.StartAmount, minAmountsOut, minProfit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", method, err)
+		}
+		return &ContractCall{To: c.capital, Data: data, Method: method}, nil
+	}
+
+	entryPoint, err := flashLoanEntryPoint(path)
+	if err != nil {
+		return nil, err
+	}
+	method := string(entryPoint) + anyLength
+	args := []interface{}{path.StartToken, path.StartAmount, path.Routers, tokens, minAmountsOut, minProfit}
+	data, err := c.flashLoanABI.Pack(method, lenderArgs(path, entryPoint, args)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", method, err)
+	}
+	return &ContractCall{To: c.flashLoan, Data: data, Method: method}, nil
+}
+
+// flashLoanEntryPoint returns the flash-loan contract entry point of the
+// path's loan source (Aave if none was quoted)
+func flashLoanEntryPoint(path *strategy.ArbitragePath) (flashloan.EntryPoint, error) {
+	entryPoint := flashloan.EntryAave
+	if path.LoanSource != nil {
+		entryPoint = path.LoanSource.EntryPoint
+	}
+	for _, supported := range flashLoanEntryPoints {
+		if entryPoint != supported {
+			continue
+		}
+		if entryPoint != flashloan.EntryAave && path.LoanSource.Lender == (common.Address{}) {
+			return "", fmt.Errorf("%s loan has no lender", path.LoanSource.Provider)
+		}
+		return entryPoint, nil
+	}
+	return "", fmt.Errorf("flash-loan contract has no %s entry point", entryPoint)
+}
+
+// lenderArgs prepends the lender to the arguments of entry points that
+// borrow from a lender given per call (Balancer Vault, V2 pair); Aave's pool
+// is fixed at deployment
+func lenderArgs(path *strategy.ArbitragePath, entryPoint flashloan.EntryPoint, args []interface{}) []interface{} {
+	if entryPoint == flashloan.EntryAave {
+		return args
+	}
+	return append([]interface{}{path.LoanSource.Lender}, args...)
+}
+
+// EncodeWithdraw encodes withdraw(token, amount) on the capital contract,
+// which sends amount of token to the owner
+// EncodeWithdraw 编码资金合约的 withdraw 调用（提取指定数量）
+func (c *Contracts) EncodeWithdraw(token common.Address, amount *big.Int) ([]byte, error) {
+	return c.capitalABI.Pack("withdraw", token, amount)
+}
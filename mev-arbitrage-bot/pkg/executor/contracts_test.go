@@ -0,0 +1,270 @@
+package executor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/flashloan"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/strategy"
+)
+
+var (
+	testCapital   = common.HexToAddress("0xc0")
+	testFlashLoan = common.HexToAddress("0xf1")
+)
+
+// testContractPath builds a cycle of hops pools starting from token 1
+func testContractPath(hops int, flashLoan bool) *strategy.ArbitragePath {
+	path := &strategy.ArbitragePath{
+		StartToken:  common.BigToAddress(big.NewInt(1)),
+		StartAmount: big.NewInt(1e18),
+		FlashLoan:   flashLoan,
+	}
+	for i := 0; i < hops; i++ {
+		path.Pools = append(path.Pools, &dex.Pool{Address: common.BigToAddress(big.NewInt(int64(0x100 + i)))})
+		path.Routers = append(path.Routers, common.BigToAddress(big.NewInt(int64(0x200+i))))
+		path.Tokens = append(path.Tokens, common.BigToAddress(big.NewInt(int64(1+i))))
+	}
+	path.Tokens = append(path.Tokens, path.StartToken)
+	return path
+}
+
+// decodeCall unpacks the arguments of an encoded call
+func decodeCall(t *testing.T, contract abi.ABI, call *ContractCall) []interface{} {
+	t.Helper()
+	method, err := contract.MethodById(call.Data[:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method.Name != call.Method {
+		t.Fatalf("selector of %s, reported %s", method.Name, call.Method)
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return args
+}
+
+func TestEncodeArbitrageTwoHopPath(t *testing.T) {
+	contracts, err := NewContracts(testCapital, testFlashLoan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Capital: the variable-length entry point, zero minimums when unset
+	path := testContractPath(2, false)
+	call, err := contracts.EncodeArbitrage(path, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.To != testCapital || call.Method != "executeArbitragePath" {
+		t.Fatalf("encoded %s to %s", call.Method, call.To.Hex())
+	}
+	args := decodeCall(t, contracts.capitalABI, call)
+	routers, tokens, minAmountsOut := args[0].([]common.Address), args[1].([]common.Address), args[3].([]*big.Int)
+	if len(routers) != 2 || routers[1] != path.Routers[1] || len(tokens) != 2 || tokens[1] != path.Tokens[1] {
+		t.Fatalf("wrong routers %v or tokens %v", routers, tokens)
+	}
+	if len(minAmountsOut) != 2 || minAmountsOut[0].Sign() != 0 || args[4].(*big.Int).Int64() != 5 {
+		t.Fatalf("wrong minimums %v or min profit %v", minAmountsOut, args[4])
+	}
+
+	// Flash loan: the Aave entry point's variable-length variant
+	path = testContractPath(2, true)
+	path.MinAmountsOut = []*big.Int{big.NewInt(7), big.NewInt(9)}
+	call, err = contracts.EncodeArbitrage(path, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.To != testFlashLoan || call.Method != string(flashloan.EntryAave)+"Path" {
+		t.Fatalf("encoded %s to %s", call.Method, call.To.Hex())
+	}
+	args = decodeCall(t, contracts.flashLoanABI, call)
+	if args[0].(common.Address) != path.StartToken || args[1].(*big.Int).Cmp(path.StartAmount) != 0 {
+		t.Fatalf("wrong loan %v %v", args[0], args[1])
+	}
+	if minAmountsOut := args[4].([]*big.Int); minAmountsOut[1].Int64() != 9 {
+		t.Fatalf("wrong minimums %v", minAmountsOut)
+	}
+
+	// A single pool is not a cycle
+	if _, err := contracts.EncodeArbitrage(testContractPath(1, false), 5); err == nil {
+		t.Fatal("1-hop path encoded")
+	}
+}
+
+func TestEncodeArbitrageThreeHopEntryPoints(t *testing.T) {
+	contracts, err := NewContracts(testCapital, testFlashLoan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minAmountsOut := []*big.Int{big.NewInt(3), big.NewInt(5), big.NewInt(7)}
+	cases := []struct {
+		name      string
+		flashLoan bool
+		minOut    bool
+		to        common.Address
+		method    string
+	}{
+		{"capital", false, false, testCapital, "executeArbitrage"},
+		{"capital with minimums", false, true, testCapital, "executeArbitrageWithMinOut"},
+		{"flash loan", true, false, testFlashLoan, "executeFlashLoanArbitrage"},
+		{"flash loan with minimums", true, true, testFlashLoan, "executeFlashLoanArbitrageWithMinOut"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := testContractPath(3, tc.flashLoan)
+			if tc.minOut {
+				path.MinAmountsOut = minAmountsOut
+			}
+			call, err := contracts.EncodeArbitrage(path, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if call.To != tc.to || call.Method != tc.method {
+				t.Fatalf("encoded %s to %s, want %s to %s", call.Method, call.To.Hex(), tc.method, tc.to.Hex())
+			}
+
+			contract := contracts.capitalABI
+			if tc.flashLoan {
+				contract = contracts.flashLoanABI
+			}
+			args := decodeCall(t, contract, call)
+
+			// Flash-loan entry points take the asset and loan amount first
+			if tc.flashLoan {
+				if args[0].(common.Address) != path.StartToken || args[1].(*big.Int).Cmp(path.StartAmount) != 0 {
+					t.Fatalf("wrong loan %v %v", args[0], args[1])
+				}
+				args = args[2:]
+			} else {
+				if args[2].(*big.Int).Cmp(path.StartAmount) != 0 {
+					t.Fatalf("wrong amount %v", args[2])
+				}
+				args = append(args[:2:2], args[3:]...)
+			}
+
+			routers, tokens := args[0].([3]common.Address), args[1].([3]common.Address)
+			if routers[2] != path.Routers[2] || tokens[0] != path.StartToken || tokens[2] != path.Tokens[2] {
+				t.Fatalf("wrong routers %v or tokens %v", routers, tokens)
+			}
+			if tc.minOut {
+				if got := args[2].([3]*big.Int); got[2].Int64() != 7 {
+					t.Fatalf("wrong minimums %v", got)
+				}
+			}
+			if minProfit := args[len(args)-1].(*big.Int); minProfit.Int64() != 5 {
+				t.Fatalf("wrong min profit %v", minProfit)
+			}
+		})
+	}
+}
+
+func TestEncodeArbitrageLenderEntryPoints(t *testing.T) {
+	contracts, err := NewContracts(testCapital, testFlashLoan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vault, pair := common.HexToAddress("0xba"), common.HexToAddress("0x5a")
+	cases := []struct {
+		name   string
+		hops   int
+		minOut bool
+		source *flashloan.Quote
+		method string
+	}{
+		{"balancer", 3, false, &flashloan.Quote{Provider: "balancer", Lender: vault, EntryPoint: flashloan.EntryBalancer}, "executeBalancerFlashLoan"},
+		{"balancer with minimums", 3, true, &flashloan.Quote{Provider: "balancer", Lender: vault, EntryPoint: flashloan.EntryBalancer}, "executeBalancerFlashLoanWithMinOut"},
+		{"balancer 2-hop", 2, false, &flashloan.Quote{Provider: "balancer", Lender: vault, EntryPoint: flashloan.EntryBalancer}, "executeBalancerFlashLoanPath"},
+		{"flash swap", 3, false, &flashloan.Quote{Provider: "uniswap_v2_flash_swap", Lender: pair, EntryPoint: flashloan.EntryFlashSwap}, "executeFlashSwap"},
+		{"flash swap with minimums", 3, true, &flashloan.Quote{Provider: "uniswap_v2_flash_swap", Lender: pair, EntryPoint: flashloan.EntryFlashSwap}, "executeFlashSwapWithMinOut"},
+		{"flash swap 2-hop", 2, false, &flashloan.Quote{Provider: "uniswap_v2_flash_swap", Lender: pair, EntryPoint: flashloan.EntryFlashSwap}, "executeFlashSwapPath"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := testContractPath(tc.hops, true)
+			path.LoanSource = tc.source
+			if tc.minOut {
+				path.MinAmountsOut = []*big.Int{big.NewInt(3), big.NewInt(5), big.NewInt(7)}
+			}
+			call, err := contracts.EncodeArbitrage(path, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if call.To != testFlashLoan || call.Method != tc.method {
+				t.Fatalf("encoded %s to %s, want %s", call.Method, call.To.Hex(), tc.method)
+			}
+
+			// The lender comes first, then the loan as for Aave
+			args := decodeCall(t, contracts.flashLoanABI, call)
+			if args[0].(common.Address) != tc.source.Lender {
+				t.Fatalf("lender %v, want %s", args[0], tc.source.Lender.Hex())
+			}
+			if args[1].(common.Address) != path.StartToken || args[2].(*big.Int).Cmp(path.StartAmount) != 0 {
+				t.Fatalf("wrong loan %v %v", args[1], args[2])
+			}
+			if minProfit := args[len(args)-1].(*big.Int); minProfit.Int64() != 5 {
+				t.Fatalf("wrong min profit %v", minProfit)
+			}
+		})
+	}
+}
+
+func TestEncodeArbitrageRejectsUnsupportedLoanSource(t *testing.T) {
+	contracts, err := NewContracts(testCapital, testFlashLoan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := testContractPath(3, true)
+	path.LoanSource = &flashloan.Quote{Provider: "maker", EntryPoint: "executeMakerFlashMint"}
+	if _, err := contracts.EncodeArbitrage(path, 5); err == nil {
+		t.Fatal("loan from a source without an entry point encoded")
+	}
+
+	// Balancer and flash swaps borrow from the quoted lender
+	path.LoanSource = &flashloan.Quote{Provider: "balancer", EntryPoint: flashloan.EntryBalancer}
+	if _, err := contracts.EncodeArbitrage(path, 5); err == nil {
+		t.Fatal("Balancer loan encoded without a Vault")
+	}
+
+	path.LoanSource = &flashloan.Quote{Provider: "aave", EntryPoint: flashloan.EntryAave}
+	if _, err := contracts.EncodeArbitrage(path, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	// Capital paths need the capital contract
+	noCapital, err := NewContracts(common.Address{}, testFlashLoan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := noCapital.EncodeArbitrage(testContractPath(3, false), 5); err == nil {
+		t.Fatal("capital path encoded without a capital contract")
+	}
+}
+
+func TestEncodeWithdraw(t *testing.T) {
+	contracts, err := NewContracts(testCapital, testFlashLoan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := common.HexToAddress("0x70")
+	data, err := contracts.EncodeWithdraw(token, big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := decodeCall(t, contracts.capitalABI, &ContractCall{To: testCapital, Data: data, Method: "withdraw"})
+	if args[0].(common.Address) != token || args[1].(*big.Int).Int64() != 42 {
+		t.Fatalf("wrong withdraw arguments %v", args)
+	}
+}
@@ -5,17 +5,24 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/flashbots"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/risk"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/simulator"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/strategy"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
 )
 
 // Executor handles transaction execution
@@ -29,11 +36,20 @@ import (
 type Executor struct {
 	ethClient       *ethclient.Client
 	flashbotsClient *flashbots.FlashbotsClient
+	tokenRegistry   *dex.TokenRegistry
 	privateKey      *ecdsa.PrivateKey
 	publicAddress   common.Address
 	config          *config.Config
+	tracker         *TxTracker
+	gasModel        *strategy.GasModel
+	contracts       *Contracts
+	inventory       *Inventory           // Capital contract balances (nil without capital contract)
+	risk            *risk.Manager        // Optional; limits and circuit breaker
+	simulator       *simulator.Simulator // Optional; local pre-flight simulation
 	nonce           uint64
 	gasPrice        *big.Int
+	capitalTrades   map[common.Address]int // Capital-funded trades in flight per start token
+	mu              sync.Mutex             // Guards nonce, gasPrice and capitalTrades (rebalancer runs concurrently)
 }
 
 // NewExecutor creates a new executor
@@ -41,6 +57,7 @@ type Executor struct {
 func NewExecutor(
 	ethClient *ethclient.Client,
 	flashbotsClient *flashbots.FlashbotsClient,
+	tokenRegistry *dex.TokenRegistry,
 	cfg *config.Config,
 ) (*Executor, error) {
 	// 解析私钥
@@ -49,12 +66,21 @@ func NewExecutor(
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
+	contracts, err := NewContracts(cfg.CapitalContract, cfg.ArbitrageContract)
+	if err != nil {
+		return nil, err
+	}
+
 	executor := &Executor{
 		ethClient:       ethClient,
 		flashbotsClient: flashbotsClient,
+		tokenRegistry:   tokenRegistry,
 		privateKey:      privateKey,
 		publicAddress:   cfg.PublicAddress,
 		config:          cfg,
+		tracker:         NewTxTracker(ethClient),
+		contracts:       contracts,
+		capitalTrades:   make(map[common.Address]int),
 	}
 
 	// 初始化 nonce
@@ -71,6 +97,41 @@ func NewExecutor(
 	return executor, nil
 }
 
+// SetGasModel sets the model used for gas limits and fed with our gas usage
+// SetGasModel 设置用于 Gas 限制的模型，并用实际消耗的 Gas 训练它
+func (e *Executor) SetGasModel(model *strategy.GasModel) {
+	e.gasModel = model
+}
+
+// SetInventory sets the capital contract inventory checked before
+// capital-funded trades
+// SetInventory 设置资金合约库存（资金模式交易前检查）
+func (e *Executor) SetInventory(inventory *Inventory) {
+	e.inventory = inventory
+}
+
+// SetRiskManager sets the risk manager consulted before every execution
+// SetRiskManager 设置风控管理器（每次执行前检查，熔断后强制模拟模式）
+func (e *Executor) SetRiskManager(manager *risk.Manager) {
+	e.risk = manager
+}
+
+// Supports reports whether an opportunity can be executed: the deployed
+// contracts must support its path, and back-runs need Flashbots
+// Supports 判断能否执行该机会：合约需支持该路径，跟随交易需要 Flashbots
+func (e *Executor) Supports(opportunity *strategy.ArbitrageOpportunity) error {
+	if opportunity.Backrun != nil && !e.flashbotsEnabled() {
+		return fmt.Errorf("back-running requires Flashbots")
+	}
+	_, err := e.contracts.EncodeArbitrage(opportunity.Path, e.config.MinProfitBps)
+	return err
+}
+
+// flashbotsEnabled reports whether transactions are sent as Flashbots bundles
+func (e *Executor) flashbotsEnabled() bool {
+	return e.config.EnableFlashbots && e.flashbotsClient != nil
+}
+
 // ExecuteArbitrage executes an arbitrage opportunity
 // ExecuteArbitrage 执行套利机会
 //
@@ -80,20 +141,40 @@ func NewExecutor(
 // 3. 如果启用 Flashbots，通过 Flashbots 发送
 // 4. 否则通过普通方式发送
 // 5. 等待交易确认
-// 6. 返回执行结果
-func (e *Executor) ExecuteArbitrage(ctx context.Context, opportunity *strategy.ArbitrageOpportunity) error {
+// 6. 返回执行结果（已提交 / 已打包 / 回滚 / 未发送）
+func (e *Executor) ExecuteArbitrage(ctx context.Context, opportunity *strategy.ArbitrageOpportunity) (strategy.AttemptOutcome, error) {
 	log.Infof("Executing arbitrage opportunity: %s", opportunity.Path.ID[:8])
 
-	// 检查是否为 Dry Run 模式
-	if e.config.DryRun {
+	// 执行相关的 RPC 调用走最高优先级通道
+	ctx = blockchain.WithPriority(ctx, blockchain.PriorityExecution)
+
+	// 检查是否为 Dry Run 模式（熔断器打开时强制模拟）
+	if e.config.DryRun || e.riskTripped(ctx) {
 		log.Warn("🧪 DRY RUN MODE - Transaction not sent")
 		e.logArbitrageDetails(opportunity)
-		return nil
+		return strategy.AttemptNotSent, nil
+	}
+
+	// 风控：每个代币的在途金额上限
+	if e.risk != nil {
+		path := opportunity.Path
+		if err := e.risk.Acquire(path.StartToken, path.StartAmount); err != nil {
+			return strategy.AttemptFailed, fmt.Errorf("risk check failed: %w", err)
+		}
+		defer e.risk.Release(path.StartToken, path.StartAmount)
+	}
+
+	// 资金模式：发送前用最新余额确认库存足够；交易在途期间再平衡不动该代币
+	if !opportunity.Path.FlashLoan {
+		defer e.holdCapital(opportunity.Path.StartToken)()
+		if err := e.checkInventory(ctx, opportunity.Path); err != nil {
+			return strategy.AttemptFailed, err
+		}
 	}
 
 	// 更新 Gas 价格
 	if err := e.updateGasPrice(); err != nil {
-		return fmt.Errorf("failed to update gas price: %w", err)
+		return strategy.AttemptFailed, fmt.Errorf("failed to update gas price: %w", err)
 	}
 
 	// 检查 Gas 价格是否超过最大值
@@ -102,21 +183,25 @@ func (e *Executor) ExecuteArbitrage(ctx context.Context, opportunity *strategy.A
 		big.NewInt(1e9),
 	)
 	if e.gasPrice.Cmp(maxGasPrice) > 0 {
-		return fmt.Errorf("gas price %s exceeds maximum %s",
+		return strategy.AttemptFailed, fmt.Errorf("gas price %s exceeds maximum %s",
 			e.gasPrice.String(), maxGasPrice.String())
 	}
 
 	// 构建交易
-	tx, err := e.buildArbitrageTx(opportunity)
+	tx, estimate, err := e.buildArbitrageTx(opportunity)
 	if err != nil {
-		return fmt.Errorf("failed to build transaction: %w", err)
+		return strategy.AttemptFailed, fmt.Errorf("failed to build transaction: %w", err)
 	}
 
-	// 选择发送方式
-	if e.config.EnableFlashbots && e.flashbotsClient != nil {
-		return e.sendViaFlashbots(ctx, tx, opportunity)
+	// 选择发送方式（跟随交易只能放在 Bundle 中，紧跟目标交易）
+	if e.flashbotsEnabled() {
+		return e.sendViaFlashbots(ctx, tx, opportunity, estimate)
+	}
+	if opportunity.Backrun != nil {
+		return strategy.AttemptFailed, fmt.Errorf("back-running requires Flashbots")
 	}
 
+	e.observeEstimate(opportunity.Path, estimate)
 	return e.sendViaMempool(ctx, tx, opportunity)
 }
 
@@ -124,33 +209,48 @@ func (e *Executor) ExecuteArbitrage(ctx context.Context, opportunity *strategy.A
 // buildArbitrageTx 构建套利交易
 //
 // 交易内容:
-// - To: 套利合约地址
-// - Data: 编码的函数调用（包含交易路径、金额等）
-// - Value: 0 (使用闪电贷，不需要自有资金)
+// - To: 资金合约 (executeArbitrage) 或闪电贷合约 (闪电贷来源的入口函数)
+// - Data: 编码的函数调用（路由器、代币路径、金额、最低利润）
+// - Value: 0
 // - Gas: 估算的 Gas 限制
 // - GasPrice: 当前 Gas 价格
-func (e *Executor) buildArbitrageTx(opportunity *strategy.ArbitrageOpportunity) (*types.Transaction, error) {
+//
+// 同时返回 eth_estimateGas 的结果（不可用时为 0），由发送方式决定是否用于训练 Gas 模型
+func (e *Executor) buildArbitrageTx(opportunity *strategy.ArbitrageOpportunity) (*types.Transaction, uint64, error) {
 	log.Debug("Building arbitrage transaction")
+	path := opportunity.Path
+
+	// 闪电贷来源决定调用合约的哪个入口函数
+	if source := path.LoanSource; path.FlashLoan && source != nil {
+		log.Infof("Flash loan: %s (fee %s, %d bps) via %s",
+			source.Provider, source.Fee.String(), source.FeeBps, source.EntryPoint)
+	}
 
-	// TODO: 实现完整的交易构建
-	// 实际需要:
-	// 1. 调用智能合约的套利函数
-	// 2. 编码函数参数（路径、金额等）
-	// 3. 估算 Gas
-	// 4. 签名交易
+	call, err := e.contracts.EncodeArbitrage(path, e.config.MinProfitBps)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	gasLimit, estimate := e.estimateGasLimit(path, call)
+	tx, err := e.signTx(call.To, call.Data, gasLimit)
+	return tx, estimate, err
+}
+
+// signTx builds and signs a transaction with the next nonce
+// signTx 使用下一个 nonce 构建并签名交易
+func (e *Executor) signTx(to common.Address, data []byte, gasLimit uint64) (*types.Transaction, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	// 当前为示例实现
 	nonce := e.nonce
-	gasLimit := uint64(500000) // 套利交易通常需要较高 Gas
 
-	// 构建交易
 	tx := types.NewTransaction(
 		nonce,
-		e.config.ArbitrageContract,
-		big.NewInt(0), // Value = 0 (使用闪电贷)
+		to,
+		big.NewInt(0),
 		gasLimit,
 		e.gasPrice,
-		[]byte{}, // TODO: 编码实际的函数调用
+		data,
 	)
 
 	// 签名交易
@@ -171,6 +271,177 @@ func (e *Executor) buildArbitrageTx(opportunity *strategy.ArbitrageOpportunity)
 	return signedTx, nil
 }
 
+// riskTripped checks the wallet balance and reports whether the circuit
+// breaker is open
+// riskTripped 检查钱包余额，返回熔断器是否已打开
+func (e *Executor) riskTripped(ctx context.Context) bool {
+	if e.risk == nil {
+		return false
+	}
+
+	balance, err := e.ethClient.BalanceAt(ctx, e.publicAddress, nil)
+	if err != nil {
+		log.Debugf("Failed to read wallet balance: %v", err)
+	} else {
+		e.risk.CheckWallet(balance)
+	}
+
+	if e.risk.Tripped() {
+		log.Warn("🚨 Circuit breaker tripped - forcing dry run")
+		return true
+	}
+	return false
+}
+
+// gasCost returns the wei paid for a mined transaction
+func gasCost(tx *types.Transaction, receipt *types.Receipt) *big.Int {
+	price := receipt.EffectiveGasPrice
+	if price == nil {
+		price = tx.GasPrice()
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), price)
+}
+
+// recordTrade reports a mined arbitrage to the risk manager. The realized
+// profit is the expected net profit corrected by the actual gas cost.
+// recordTrade 向风控管理器记录已上链的套利（回滚时亏损为 Gas 费用）
+func (e *Executor) recordTrade(path *strategy.ArbitragePath, tx *types.Transaction, receipt *types.Receipt) {
+	if e.risk == nil {
+		return
+	}
+
+	cost := gasCost(tx, receipt)
+	reverted := receipt.Status != types.ReceiptStatusSuccessful
+	profit := new(big.Int).Neg(cost)
+	if !reverted {
+		profit = utils.EtherToWei(path.NetProfitETH)
+		if path.GasCostEst != nil {
+			profit.Add(profit, path.GasCostEst)
+		}
+		profit.Sub(profit, cost)
+	}
+	e.risk.RecordTrade(profit, cost, reverted)
+}
+
+// holdCapital marks a capital-funded trade of token in flight until the
+// returned function is called
+// holdCapital 标记资金交易在途，调用返回的函数后解除
+func (e *Executor) holdCapital(token common.Address) func() {
+	e.mu.Lock()
+	e.capitalTrades[token]++
+	e.mu.Unlock()
+
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if e.capitalTrades[token]--; e.capitalTrades[token] <= 0 {
+			delete(e.capitalTrades, token)
+		}
+	}
+}
+
+// capitalInFlight reports whether a capital-funded trade of token is in flight
+// capitalInFlight 判断该代币是否有在途的资金交易
+func (e *Executor) capitalInFlight(token common.Address) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.capitalTrades[token] > 0
+}
+
+// checkInventory verifies the capital contract holds the start amount
+// checkInventory 确认资金合约持有足够的起始代币
+func (e *Executor) checkInventory(ctx context.Context, path *strategy.ArbitragePath) error {
+	if e.inventory == nil {
+		return fmt.Errorf("capital-funded trade without inventory tracking")
+	}
+
+	balance, err := e.inventory.BalanceOf(ctx, path.StartToken, e.config.CapitalContract)
+	if err != nil {
+		return fmt.Errorf("failed to read inventory: %w", err)
+	}
+	if balance.Cmp(path.StartAmount) < 0 {
+		return fmt.Errorf("inventory %s below start amount %s",
+			e.tokenRegistry.FormatAmount(path.StartToken, balance),
+			e.tokenRegistry.FormatAmount(path.StartToken, path.StartAmount))
+	}
+	return nil
+}
+
+// sendCall sends a plain contract call through the mempool and waits for it
+// sendCall 通过交易池发送普通合约调用并等待确认（用于库存再平衡）
+func (e *Executor) sendCall(ctx context.Context, to common.Address, data []byte, method string) error {
+	if e.config.DryRun || e.riskTripped(ctx) {
+		log.Warnf("🧪 DRY RUN MODE - %s on %s not sent", method, to.Hex())
+		return nil
+	}
+
+	if err := e.updateGasPrice(); err != nil {
+		return fmt.Errorf("failed to update gas price: %w", err)
+	}
+
+	gas, err := e.ethClient.EstimateGas(ctx, ethereum.CallMsg{From: e.publicAddress, To: &to, Data: data})
+	if err != nil {
+		return fmt.Errorf("%s would fail: %w", method, err)
+	}
+
+	tx, err := e.signTx(to, data, uint64(float64(gas)*e.config.GasLimitMultiplier))
+	if err != nil {
+		return err
+	}
+	if err := e.ethClient.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+	e.tracker.Track(tx)
+
+	receipt, err := e.waitForReceipt(ctx, tx.Hash())
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	e.tracker.MarkIncluded(receipt)
+	if e.risk != nil {
+		e.risk.RecordGas(gasCost(tx, receipt))
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("%s reverted: %s", method, tx.Hash().Hex())
+	}
+
+	log.Infof("✅ %s confirmed: %s", method, tx.Hash().Hex())
+	return nil
+}
+
+// estimateGasLimit returns the gas limit for an arbitrage call and the
+// eth_estimateGas result it is based on (0 if the model was used)
+// estimateGasLimit 返回套利调用的 Gas 限制及其依据的 eth_estimateGas 结果
+//
+// 优先使用 eth_estimateGas，失败时退回模型估算。这里不训练 Gas 模型：
+// 每次尝试只由发送路径采样一次（见 observeEstimate）
+func (e *Executor) estimateGasLimit(path *strategy.ArbitragePath, call *ContractCall) (uint64, uint64) {
+	gas, err := e.ethClient.EstimateGas(context.Background(), ethereum.CallMsg{
+		From: e.publicAddress,
+		To:   &call.To,
+		Data: call.Data,
+	})
+	if err == nil {
+		return uint64(float64(gas) * e.config.GasLimitMultiplier), gas
+	}
+	log.Debugf("eth_estimateGas of %s failed, using gas model: %v", call.Method, err)
+
+	if e.gasModel == nil {
+		return config.FlashLoanGasLimit, 0
+	}
+	return e.gasModel.GasLimit(path), 0
+}
+
+// observeEstimate trains the gas model with a pre-send sample of our own
+// transaction (ignored if 0)
+// observeEstimate 用我们自己交易的发送前 Gas 样本训练模型（0 表示没有样本）
+func (e *Executor) observeEstimate(path *strategy.ArbitragePath, gas uint64) {
+	if e.gasModel != nil && gas > 0 {
+		e.gasModel.ObserveEstimate(path, gas)
+	}
+}
+
 // sendViaFlashbots sends transaction via Flashbots
 // sendViaFlashbots 通过 Flashbots 发送交易
 //
@@ -182,46 +453,97 @@ func (e *Executor) sendViaFlashbots(
 	ctx context.Context,
 	tx *types.Transaction,
 	opportunity *strategy.ArbitrageOpportunity,
-) error {
+	estimate uint64,
+) (strategy.AttemptOutcome, error) {
 	log.Info("📡 Sending transaction via Flashbots")
 
+	// 交易已签名：未被打包时它的 nonce 没有在链上消耗，重新读取
+	included := false
+	defer func() {
+		if !included {
+			e.resyncNonce()
+		}
+	}()
+
 	// 获取当前区块号
 	blockNumber, err := e.ethClient.BlockNumber(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get block number: %w", err)
+		return strategy.AttemptFailed, fmt.Errorf("failed to get block number: %w", err)
 	}
 
 	// 目标下一个区块
 	targetBlock := blockNumber + 1
 
-	// 构建 Bundle
+	// 构建 Bundle；跟随交易排在目标交易之后，目标交易过期后 Bundle 失效
 	bundle := e.flashbotsClient.BuildBundle([]*types.Transaction{tx}, targetBlock)
+	if swap := opportunity.Backrun; swap != nil {
+		bundle, err = flashbots.NewBackrunBundle(swap.Tx, []*types.Transaction{tx}, targetBlock)
+		if err != nil {
+			return strategy.AttemptFailed, fmt.Errorf("failed to build back-run bundle: %w", err)
+		}
+		bundle.MaxTimestamp = swap.Deadline
+		log.Infof("Back-running %s swap %s from %s", swap.Method, swap.Hash.Hex(), swap.Sender.Hex())
+	}
 
-	// 先模拟
+	// 先通过 Relay 模拟；配置了本地分叉模拟器时，发送前总是再本地模拟一次，
+	// Relay 模拟失败时以本地结果为准，本地回滚时不发送
 	simResult, err := e.flashbotsClient.SimulateBundle(ctx, bundle)
 	if err != nil {
-		return fmt.Errorf("bundle simulation failed: %w", err)
+		if e.simulator == nil {
+			return strategy.AttemptFailed, fmt.Errorf("bundle simulation failed: %w", err)
+		}
+		log.Warnf("Relay simulation failed, simulating locally: %v", err)
+		simResult = nil
+	}
+	if e.simulator != nil {
+		local, err := e.simulateLocally(ctx, bundle)
+		switch {
+		case err != nil && simResult == nil:
+			return strategy.AttemptFailed, fmt.Errorf("bundle simulation failed: %w", err)
+		case err != nil:
+			log.Debugf("Local simulation unavailable: %v", err)
+		case simResult == nil || !local.Success:
+			simResult = local
+		}
 	}
 
 	if !simResult.Success {
-		return fmt.Errorf("bundle simulation failed: not profitable")
+		return strategy.AttemptReverted, fmt.Errorf("bundle simulation failed: not profitable")
 	}
 
 	log.Infof("Simulation successful: gas=%d, profit=%s ETH",
 		simResult.GasUsed, simResult.CoinbaseDiff.String())
 
+	// 只用我们自己交易的 Gas 训练模型：优先取它在 Bundle 中的模拟结果（跟随交易
+	// 在目标交易之后执行），否则用 eth_estimateGas 的结果；整个 Bundle 的 Gas 不可用
+	if own := simResult.Result(tx.Hash()); own != nil && own.Error == "" {
+		estimate = own.GasUsed
+	}
+	e.observeEstimate(opportunity.Path, estimate)
+
 	// 发送 Bundle
 	response, err := e.flashbotsClient.SendBundle(ctx, bundle)
 	if err != nil {
-		return fmt.Errorf("failed to send bundle: %w", err)
+		return strategy.AttemptFailed, fmt.Errorf("failed to send bundle: %w", err)
 	}
 
 	if !response.Success {
-		return fmt.Errorf("bundle rejected: %s", response.Error)
+		return strategy.AttemptFailed, fmt.Errorf("bundle rejected: %s", response.Error)
 	}
 
 	log.Infof("✅ Bundle sent successfully: hash=%s", response.BundleHash.Hex())
-	return nil
+
+	// 等待目标区块：交易被打包才记录结果，在途金额保持占用直到确认
+	receipt, err := e.waitForInclusion(ctx, tx.Hash(), targetBlock)
+	if err != nil {
+		return strategy.AttemptSubmitted, fmt.Errorf("bundle inclusion unknown: %w", err)
+	}
+	if receipt == nil {
+		// 未被打包：不消耗 Gas
+		return strategy.AttemptFailed, fmt.Errorf("bundle not included in block %d", targetBlock)
+	}
+	included = true
+	return e.settle(opportunity.Path, tx, receipt)
 }
 
 // sendViaMempool sends transaction via normal mempool
@@ -232,39 +554,67 @@ func (e *Executor) sendViaMempool(
 	ctx context.Context,
 	tx *types.Transaction,
 	opportunity *strategy.ArbitrageOpportunity,
-) error {
+) (strategy.AttemptOutcome, error) {
 	log.Warn("⚠️  Sending transaction via public mempool (may be front-run)")
 
+	// 本地预检：会回滚的交易不发送（公开交易池中回滚也要支付 Gas）
+	if e.simulator != nil {
+		result, err := e.preflight(ctx, []*types.Transaction{tx}, nil)
+		if err != nil {
+			log.Debugf("Pre-flight unavailable, sending anyway: %v", err)
+		} else if !result.Success() {
+			e.resyncNonce()
+			return strategy.AttemptFailed, fmt.Errorf("pre-flight reverted: %s", result.Failed.RevertReason())
+		}
+	}
+
 	// 发送交易
 	err := e.ethClient.SendTransaction(ctx, tx)
 	if err != nil {
-		return fmt.Errorf("failed to send transaction: %w", err)
+		// 未进入交易池：nonce 没有被消耗
+		e.resyncNonce()
+		return strategy.AttemptFailed, fmt.Errorf("failed to send transaction: %w", err)
 	}
 
 	log.Infof("Transaction sent: %s", tx.Hash().Hex())
+	e.tracker.Track(tx)
 
 	// 等待确认
 	receipt, err := e.waitForReceipt(ctx, tx.Hash())
 	if err != nil {
-		return fmt.Errorf("transaction failed: %w", err)
+		// 未拿到收据：交易可能仍在交易池中
+		return strategy.AttemptSubmitted, fmt.Errorf("transaction failed: %w", err)
 	}
+	return e.settle(opportunity.Path, tx, receipt)
+}
 
-	if receipt.Status == 1 {
-		log.Infof("✅ Transaction confirmed: block=%d, gas=%d",
-			receipt.BlockNumber.Uint64(), receipt.GasUsed)
-	} else {
+// settle records a mined arbitrage transaction and returns its outcome
+// settle 记录已上链的套利交易（风控、Gas 模型）并返回执行结果
+func (e *Executor) settle(path *strategy.ArbitragePath, tx *types.Transaction, receipt *types.Receipt) (strategy.AttemptOutcome, error) {
+	e.tracker.MarkIncluded(receipt)
+	e.recordTrade(path, tx, receipt)
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
 		log.Errorf("❌ Transaction reverted: block=%d", receipt.BlockNumber.Uint64())
-		return fmt.Errorf("transaction reverted")
+		return strategy.AttemptReverted, fmt.Errorf("transaction reverted")
 	}
 
-	return nil
+	log.Infof("✅ Transaction confirmed: block=%d, gas=%d",
+		receipt.BlockNumber.Uint64(), receipt.GasUsed)
+	if e.gasModel != nil {
+		e.gasModel.ObserveReceipt(path, receipt.GasUsed)
+	}
+	return strategy.AttemptLanded, nil
 }
 
+// receiptPollInterval is how often waitForReceipt polls for a receipt
+var receiptPollInterval = 2 * time.Second
+
 // waitForReceipt waits for transaction receipt
 // waitForReceipt 等待交易收据
 func (e *Executor) waitForReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
 	timeout := time.After(2 * time.Minute)
-	ticker := time.NewTicker(2 * time.Second)
+	ticker := time.NewTicker(receiptPollInterval)
 	defer ticker.Stop()
 
 	for {
@@ -280,6 +630,43 @@ func (e *Executor) waitForReceipt(ctx context.Context, txHash common.Hash) (*typ
 	}
 }
 
+// waitForInclusion waits until our transaction is mined or targetBlock
+// passes without it; a nil receipt means the bundle was not included
+// waitForInclusion 等待 Bundle 的目标区块：返回交易收据，未被打包时返回 nil
+func (e *Executor) waitForInclusion(ctx context.Context, txHash common.Hash, targetBlock uint64) (*types.Receipt, error) {
+	timeout := time.After(2 * time.Minute)
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, fmt.Errorf("timeout waiting for block %d", targetBlock)
+		case <-ticker.C:
+			// 先读区块号再查收据：目标区块已出而仍无收据，说明未被打包
+			blockNumber, err := e.ethClient.BlockNumber(ctx)
+			if err != nil {
+				continue
+			}
+			receipt, err := e.ethClient.TransactionReceipt(ctx, txHash)
+			if err == nil {
+				return receipt, nil
+			}
+			if blockNumber >= targetBlock {
+				return nil, nil
+			}
+		}
+	}
+}
+
+// Tracker returns the transaction tracker
+// Tracker 返回交易跟踪器
+func (e *Executor) Tracker() *TxTracker {
+	return e.tracker
+}
+
 // updateNonce updates the account nonce
 // updateNonce 更新账户 nonce
 func (e *Executor) updateNonce() error {
@@ -300,12 +687,9 @@ func (e *Executor) updateGasPrice() error {
 	}
 
 	// 应用倍数
-	multiplier := big.NewFloat(e.config.GasPriceMultiplier)
-	gasPriceFloat := new(big.Float).SetInt(gasPrice)
-	gasPriceFloat.Mul(gasPriceFloat, multiplier)
-
-	adjustedGasPrice, _ := gasPriceFloat.Int(nil)
-	e.gasPrice = adjustedGasPrice
+	e.mu.Lock()
+	e.gasPrice = utils.ApplyMultiplier(gasPrice, e.config.GasPriceMultiplier)
+	e.mu.Unlock()
 
 	return nil
 }
@@ -322,13 +706,24 @@ func (e *Executor) logArbitrageDetails(opportunity *strategy.ArbitrageOpportunit
 		path.ProfitETH.Text('f', 6),
 		float64(path.ProfitBps)/100)
 	log.Infof("Net Profit: %s ETH (%.2f%%)",
-		path.ProfitETH.Text('f', 6),
+		path.NetProfitETH.Text('f', 6),
 		float64(path.NetProfitBps)/100)
-	log.Infof("Start Amount: %s", path.StartAmount.String())
-	log.Infof("End Amount: %s", path.EndAmount.String())
+	log.Infof("Start Amount: %s", e.tokenRegistry.FormatAmount(path.StartToken, path.StartAmount))
+	if !path.FlashLoan {
+		log.Info("Funding: capital contract inventory")
+	} else if path.LoanSource != nil {
+		log.Infof("Funding: %s flash loan (fee %s)", path.LoanSource.Provider,
+			e.tokenRegistry.FormatAmount(path.StartToken, path.LoanSource.Fee))
+	} else {
+		log.Info("Funding: flash loan")
+	}
+	log.Infof("End Amount: %s", e.tokenRegistry.FormatAmount(path.StartToken, path.EndAmount))
 	log.Info("Path:")
 	for i, token := range path.Tokens {
-		log.Infof("  %d. %s", i+1, token.Hex())
+		log.Infof("  %d. %s (%s)", i+1, e.tokenRegistry.Symbol(token), token.Hex())
+	}
+	for i, minOut := range path.MinAmountsOut {
+		log.Infof("Hop %d min out: %s", i+1, e.tokenRegistry.FormatAmount(path.Tokens[i+1], minOut))
 	}
 	log.Info("========================================")
 }
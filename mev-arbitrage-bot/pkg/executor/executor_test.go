@@ -0,0 +1,233 @@
+package executor
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/flashbots"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/risk"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/strategy"
+)
+
+// fakeRelay simulates every bundle as successful. A sent bundle is mined
+// into the node's next block, left out of it, or rejected.
+type fakeRelay struct {
+	t    *testing.T
+	node *fakeChainNode
+	send string // "mine", "skip" or "reject"
+}
+
+func (r *fakeRelay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var call struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params []struct {
+			Txs []hexutil.Bytes `json:"txs"`
+		} `json:"params"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&call); err != nil {
+		r.t.Errorf("decode relay request: %v", err)
+		return
+	}
+
+	var txs []*types.Transaction
+	for _, raw := range call.Params[0].Txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			r.t.Errorf("decode bundle transaction: %v", err)
+			return
+		}
+		txs = append(txs, tx)
+	}
+
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": call.ID}
+	switch {
+	case call.Method == "eth_callBundle":
+		var results []map[string]interface{}
+		for _, tx := range txs {
+			results = append(results, map[string]interface{}{"txHash": tx.Hash(), "gasUsed": 150000})
+		}
+		response["result"] = map[string]interface{}{"totalGasUsed": 150000 * len(txs), "results": results}
+	case r.send == "reject":
+		response["error"] = map[string]interface{}{"code": -32000, "message": "bundle rejected"}
+	default:
+		if r.send == "mine" {
+			for _, tx := range txs {
+				r.node.mine(tx)
+			}
+		}
+		r.node.advance()
+		response["result"] = map[string]interface{}{"bundleHash": common.HexToHash("0xb0")}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func TestWaitForInclusionAndSettle(t *testing.T) {
+	defer func(interval time.Duration) { receiptPollInterval = interval }(receiptPollInterval)
+	receiptPollInterval = 10 * time.Millisecond
+
+	executor, node := newTestExecutor(t)
+	manager, err := risk.NewManager(risk.Limits{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor.SetRiskManager(manager)
+
+	// The target block passed without our transaction: not included
+	node.mu.Lock()
+	node.block = 5
+	node.mu.Unlock()
+	receipt, err := executor.waitForInclusion(context.Background(), common.HexToHash("0x01"), 5)
+	if err != nil || receipt != nil {
+		t.Fatalf("got receipt %v, err %v; want not included", receipt, err)
+	}
+
+	// Included but reverted: the gas is a realized loss
+	tx := types.NewTransaction(0, testCapital, big.NewInt(0), 100000, big.NewInt(1e9), nil)
+	node.mu.Lock()
+	node.receipts[tx.Hash()] = &types.Receipt{
+		Status:      types.ReceiptStatusFailed,
+		TxHash:      tx.Hash(),
+		GasUsed:     40000,
+		BlockNumber: big.NewInt(5),
+		Logs:        []*types.Log{},
+	}
+	node.mu.Unlock()
+	receipt, err = executor.waitForInclusion(context.Background(), tx.Hash(), 5)
+	if err != nil || receipt == nil {
+		t.Fatalf("included transaction not found: %v", err)
+	}
+
+	path := testContractPath(3, true)
+	path.NetProfitETH = big.NewFloat(0.01)
+	outcome, _ := executor.settle(path, tx, receipt)
+	if outcome != strategy.AttemptReverted {
+		t.Fatalf("outcome %v, want reverted", outcome)
+	}
+	status := manager.Status()
+	if status.ConsecutiveReverts != 1 || status.DailyPnLWei.Cmp(big.NewInt(-40000*1e9)) != 0 {
+		t.Fatalf("reverts %d, P&L %s; want 1 and the gas cost lost", status.ConsecutiveReverts, status.DailyPnLWei)
+	}
+
+	// Included and successful: the expected profit is realized
+	receipt.Status = types.ReceiptStatusSuccessful
+	if outcome, err := executor.settle(path, tx, receipt); outcome != strategy.AttemptLanded || err != nil {
+		t.Fatalf("outcome %v, err %v; want landed", outcome, err)
+	}
+	if status := manager.Status(); status.ConsecutiveReverts != 0 || status.DailyPnLWei.Sign() <= 0 {
+		t.Fatalf("reverts %d, P&L %s; want the profit recorded", status.ConsecutiveReverts, status.DailyPnLWei)
+	}
+}
+
+func TestSendCallHonorsCircuitBreaker(t *testing.T) {
+	executor, node := newTestExecutor(t)
+	manager, err := risk.NewManager(risk.Limits{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.Trip("test")
+	executor.SetRiskManager(manager)
+
+	if err := executor.sendCall(context.Background(), testCapital, []byte{1, 2, 3, 4}, "withdraw"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := node.sentMethods(); len(sent) != 0 {
+		t.Fatalf("sent %v with the circuit breaker tripped", sent)
+	}
+
+	// Arbitrage falls back to a dry run, which releases the route
+	path := testContractPath(3, true)
+	path.ID = "dry-run-route"
+	path.ProfitETH, path.NetProfitETH = big.NewFloat(0.02), big.NewFloat(0.01)
+	path.EndAmount = big.NewInt(1.02e18)
+	for i := range path.Tokens {
+		path.Tokens[i] = testInventoryToken // Registered, so logging needs no RPC
+	}
+	path.StartToken = testInventoryToken
+	outcome, err := executor.ExecuteArbitrage(context.Background(), &strategy.ArbitrageOpportunity{Path: path})
+	if err != nil || outcome != strategy.AttemptNotSent {
+		t.Fatalf("outcome %v (%v), want not sent", outcome, err)
+	}
+}
+
+func TestFlashbotsAttemptResyncsNonceUnlessIncluded(t *testing.T) {
+	defer func(interval time.Duration) { receiptPollInterval = interval }(receiptPollInterval)
+	receiptPollInterval = 10 * time.Millisecond
+
+	cases := []struct {
+		send    string
+		outcome strategy.AttemptOutcome
+		nonce   uint64 // Local nonce after the attempt
+	}{
+		{"reject", strategy.AttemptFailed, 0},
+		{"skip", strategy.AttemptFailed, 0},
+		{"mine", strategy.AttemptLanded, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.send, func(t *testing.T) {
+			executor, node := newTestExecutor(t)
+			relay := httptest.NewServer(&fakeRelay{t: t, node: node, send: tc.send})
+			defer relay.Close()
+
+			key, _ := crypto.GenerateKey()
+			client, err := flashbots.NewFlashbotsClient(executor.ethClient, &config.Config{
+				FlashbotsRelay:      relay.URL,
+				FlashbotsSigningKey: hex.EncodeToString(crypto.FromECDSA(key)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			executor.flashbotsClient = client
+
+			tx, err := executor.signTx(testCapital, []byte{1, 2, 3, 4}, 200000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := testContractPath(3, true)
+			path.NetProfitETH = big.NewFloat(0.01)
+
+			outcome, err := executor.sendViaFlashbots(context.Background(), tx, &strategy.ArbitrageOpportunity{Path: path}, 0)
+			if outcome != tc.outcome {
+				t.Fatalf("outcome %v (%v), want %v", outcome, err, tc.outcome)
+			}
+			// The node reports no sent transactions, so a resync resets the nonce
+			if executor.nonce != tc.nonce {
+				t.Fatalf("nonce %d, want %d", executor.nonce, tc.nonce)
+			}
+		})
+	}
+}
+
+func TestMempoolSendFailureResyncsNonce(t *testing.T) {
+	executor, node := newTestExecutor(t)
+	node.mu.Lock()
+	node.reject = true
+	node.mu.Unlock()
+
+	tx, err := executor.signTx(testCapital, []byte{1, 2, 3, 4}, 200000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := testContractPath(3, false)
+
+	outcome, err := executor.sendViaMempool(context.Background(), tx, &strategy.ArbitrageOpportunity{Path: path})
+	if outcome != strategy.AttemptFailed || err == nil {
+		t.Fatalf("outcome %v (%v), want a failed attempt", outcome, err)
+	}
+	if executor.nonce != 0 {
+		t.Fatalf("nonce %d not resynced after the send failed", executor.nonce)
+	}
+}
@@ -0,0 +1,229 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// Inventory tracks the capital contract's token balances
+// Inventory 跟踪资金合约持有的代币余额
+//
+// 余额每个区块刷新一次并缓存，策略在热路径中只读缓存
+type Inventory struct {
+	ethClient *ethclient.Client
+	contract  common.Address
+	erc20ABI  abi.ABI
+	tokens    []common.Address
+	balances  map[common.Address]*big.Int
+	mu        sync.RWMutex
+}
+
+// NewInventory creates an inventory of tokens held by contract
+// NewInventory 创建资金合约的库存跟踪器
+func NewInventory(ethClient *ethclient.Client, contract common.Address, tokens []common.Address) (*Inventory, error) {
+	erc20ABI, err := abi.JSON(strings.NewReader(dex.ERC20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+
+	return &Inventory{
+		ethClient: ethClient,
+		contract:  contract,
+		erc20ABI:  erc20ABI,
+		tokens:    tokens,
+		balances:  make(map[common.Address]*big.Int),
+	}, nil
+}
+
+// Refresh re-reads the balances of all tracked tokens
+// Refresh 重新读取所有跟踪代币的余额
+func (inv *Inventory) Refresh(ctx context.Context) {
+	for _, token := range inv.tokens {
+		if _, err := inv.BalanceOf(ctx, token, inv.contract); err != nil {
+			log.Debugf("Failed to refresh inventory of %s: %v", token.Hex(), err)
+		}
+	}
+}
+
+// BalanceOf reads the token balance of an account; balances of the
+// contract are cached
+// BalanceOf 读取账户的代币余额（合约余额会被缓存）
+func (inv *Inventory) BalanceOf(ctx context.Context, token, account common.Address) (*big.Int, error) {
+	input, err := inv.erc20ABI.Pack("balanceOf", account)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	output, err := inv.ethClient.CallContract(ctx, ethereum.CallMsg{To: &token, Data: input}, nil)
+	if err != nil {
+		return nil, err
+	}
+	values, err := inv.erc20ABI.Unpack("balanceOf", output)
+	if err != nil {
+		return nil, err
+	}
+	balance := values[0].(*big.Int)
+
+	if account == inv.contract {
+		inv.mu.Lock()
+		inv.balances[token] = balance
+		inv.mu.Unlock()
+	}
+	return balance, nil
+}
+
+// Balance returns the cached contract balance of a token (0 if unknown)
+// Balance 返回缓存的合约代币余额
+func (inv *Inventory) Balance(token common.Address) *big.Int {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+
+	if balance, exists := inv.balances[token]; exists {
+		return balance
+	}
+	return big.NewInt(0)
+}
+
+// CanFund reports whether the cached inventory covers amount of token
+// CanFund 判断缓存的库存是否足以支付交易金额
+func (inv *Inventory) CanFund(token common.Address, amount *big.Int) bool {
+	return inv.Balance(token).Cmp(amount) >= 0
+}
+
+// Rebalancer keeps the capital contract's inventory near target levels
+// Rebalancer 让资金合约的库存保持在目标水平附近
+//
+// 处理逻辑:
+// 1. 余额低于目标（超出容差）→ 从钱包转入差额
+// 2. 余额高于目标（超出容差）→ withdraw 取回超出目标的部分
+// 3. 该代币有在途的资金交易 → 跳过，避免改变交易依赖的余额
+type Rebalancer struct {
+	executor     *Executor
+	inventory    *Inventory
+	targets      map[common.Address]*big.Int // Raw token units
+	toleranceBps int64
+}
+
+// NewRebalancer creates a rebalancer for per-token targets in raw units
+// NewRebalancer 创建库存再平衡任务
+func NewRebalancer(executor *Executor, inventory *Inventory, targets map[common.Address]*big.Int, toleranceBps int) *Rebalancer {
+	return &Rebalancer{
+		executor:     executor,
+		inventory:    inventory,
+		targets:      targets,
+		toleranceBps: int64(toleranceBps),
+	}
+}
+
+// Run rebalances every interval until ctx is done
+// Run 每隔 interval 执行一次再平衡
+func (r *Rebalancer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.Rebalance(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rebalance moves each token towards its target once
+// Rebalance 对每个代币执行一次再平衡
+func (r *Rebalancer) Rebalance(ctx context.Context) {
+	for token, target := range r.targets {
+		if err := r.rebalanceToken(ctx, token, target); err != nil {
+			log.Warnf("Rebalance of %s failed: %v", r.executor.tokenRegistry.Symbol(token), err)
+		}
+	}
+}
+
+// rebalanceToken tops up or sweeps one token
+func (r *Rebalancer) rebalanceToken(ctx context.Context, token common.Address, target *big.Int) error {
+	if r.executor.capitalInFlight(token) {
+		log.Debugf("Rebalance of %s skipped: capital trade in flight", r.executor.tokenRegistry.Symbol(token))
+		return nil
+	}
+
+	balance, err := r.inventory.BalanceOf(ctx, token, r.inventory.contract)
+	if err != nil {
+		return fmt.Errorf("failed to read inventory: %w", err)
+	}
+
+	tolerance := new(big.Int).Mul(target, big.NewInt(r.toleranceBps))
+	tolerance.Div(tolerance, big.NewInt(10000))
+	deviation := new(big.Int).Sub(balance, target)
+	if deviation.CmpAbs(tolerance) <= 0 {
+		return nil
+	}
+
+	registry := r.executor.tokenRegistry
+	log.Infof("Rebalancing %s: inventory %s, target %s",
+		registry.Symbol(token), registry.FormatAmount(token, balance), registry.FormatAmount(token, target))
+
+	if deviation.Sign() < 0 {
+		// 从钱包补足差额
+		deficit := new(big.Int).Neg(deviation)
+		walletBalance, err := r.inventory.BalanceOf(ctx, token, r.executor.publicAddress)
+		if err != nil {
+			return fmt.Errorf("failed to read wallet balance: %w", err)
+		}
+		if walletBalance.Sign() == 0 {
+			return fmt.Errorf("wallet holds no %s to top up", registry.Symbol(token))
+		}
+		amount := deficit
+		if walletBalance.Cmp(deficit) < 0 {
+			amount = walletBalance
+		}
+		return r.transferToContract(ctx, token, amount)
+	}
+
+	// 只取回超出目标的部分
+	data, err := r.executor.contracts.EncodeWithdraw(token, deviation)
+	if err != nil {
+		return err
+	}
+	if err := r.executor.sendCall(ctx, r.inventory.contract, data, "withdraw"); err != nil {
+		return err
+	}
+
+	r.inventory.Refresh(ctx)
+	return nil
+}
+
+// transferToContract sends amount of token from the wallet to the contract
+func (r *Rebalancer) transferToContract(ctx context.Context, token common.Address, amount *big.Int) error {
+	if amount.Sign() == 0 {
+		return nil
+	}
+
+	data, err := r.inventory.erc20ABI.Pack("transfer", r.inventory.contract, amount)
+	if err != nil {
+		return err
+	}
+	if err := r.executor.sendCall(ctx, token, data, "transfer"); err != nil {
+		return err
+	}
+
+	r.inventory.Refresh(ctx)
+	return nil
+}
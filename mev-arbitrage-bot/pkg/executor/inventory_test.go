@@ -0,0 +1,339 @@
+package executor
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+var testInventoryToken = common.HexToAddress("0x70")
+
+// fakeChainNode is a JSON-RPC server holding ERC-20 balances. Sent
+// transactions are mined at once: transfer and the capital contract's
+// withdraw move balances, everything else is recorded only.
+type fakeChainNode struct {
+	t         *testing.T
+	wallet    common.Address
+	contract  common.Address
+	erc20ABI  abi.ABI
+	contracts *Contracts
+	balances  map[common.Address]map[common.Address]*big.Int // token -> account -> balance
+	sent      []string                                       // Method of each sent transaction
+	receipts  map[common.Hash]*types.Receipt
+	block     uint64 // Head block; sent transactions are mined in the next one
+	reject    bool   // eth_sendRawTransaction fails
+	mu        sync.Mutex
+}
+
+func newFakeChainNode(t *testing.T, wallet common.Address) (*fakeChainNode, *ethclient.Client) {
+	contracts, err := NewContracts(testCapital, testFlashLoan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	erc20ABI, err := abi.JSON(strings.NewReader(dex.ERC20ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &fakeChainNode{
+		t:         t,
+		wallet:    wallet,
+		contract:  testCapital,
+		erc20ABI:  erc20ABI,
+		contracts: contracts,
+		balances:  make(map[common.Address]map[common.Address]*big.Int),
+		receipts:  make(map[common.Hash]*types.Receipt),
+	}
+
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatalf("dial fake node: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return node, client
+}
+
+func (n *fakeChainNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		n.t.Errorf("decode request: %v", err)
+		return
+	}
+
+	var result interface{}
+	switch req.Method {
+	case "eth_chainId":
+		result = hexutil.Uint64(1)
+	case "eth_blockNumber":
+		n.mu.Lock()
+		result = hexutil.Uint64(n.block)
+		n.mu.Unlock()
+	case "eth_getTransactionCount":
+		result = hexutil.Uint64(len(n.sentMethods()))
+	case "eth_getBalance":
+		result = (*hexutil.Big)(big.NewInt(1e18))
+	case "eth_gasPrice":
+		result = (*hexutil.Big)(big.NewInt(1e9))
+	case "eth_estimateGas":
+		result = hexutil.Uint64(60000)
+	case "eth_call":
+		result = n.call(req.Params[0])
+	case "eth_sendRawTransaction":
+		n.mu.Lock()
+		reject := n.reject
+		n.mu.Unlock()
+		if reject {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      req.ID,
+				"error":   map[string]interface{}{"code": -32000, "message": "replacement transaction underpriced"},
+			})
+			return
+		}
+		result = n.send(req.Params[0])
+	case "eth_getTransactionReceipt":
+		var hash common.Hash
+		json.Unmarshal(req.Params[0], &hash)
+		n.mu.Lock()
+		result = n.receipts[hash]
+		n.mu.Unlock()
+	default:
+		n.t.Errorf("unexpected request %q", req.Method)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"result":  result,
+	})
+}
+
+// call answers balanceOf
+func (n *fakeChainNode) call(param json.RawMessage) hexutil.Bytes {
+	var msg struct {
+		To    common.Address `json:"to"`
+		Input hexutil.Bytes  `json:"input"`
+	}
+	if err := json.Unmarshal(param, &msg); err != nil {
+		n.t.Errorf("decode call: %v", err)
+		return nil
+	}
+	args, err := n.erc20ABI.Methods["balanceOf"].Inputs.Unpack(msg.Input[4:])
+	if err != nil {
+		n.t.Errorf("decode balanceOf: %v", err)
+		return nil
+	}
+	return common.LeftPadBytes(n.balance(msg.To, args[0].(common.Address)).Bytes(), 32)
+}
+
+// send mines a raw transaction and applies its balance changes
+func (n *fakeChainNode) send(param json.RawMessage) common.Hash {
+	var raw hexutil.Bytes
+	json.Unmarshal(param, &raw)
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		n.t.Errorf("decode transaction: %v", err)
+		return common.Hash{}
+	}
+
+	var method *abi.Method
+	if *tx.To() == n.contract {
+		method, _ = n.contracts.capitalABI.MethodById(tx.Data()[:4])
+	} else {
+		method, _ = n.erc20ABI.MethodById(tx.Data()[:4])
+	}
+	if method == nil {
+		n.t.Errorf("unknown call %s to %s", hex.EncodeToString(tx.Data()[:4]), tx.To().Hex())
+		return common.Hash{}
+	}
+	args, _ := method.Inputs.Unpack(tx.Data()[4:])
+
+	switch method.Name {
+	case "transfer":
+		n.move(*tx.To(), n.wallet, args[0].(common.Address), args[1].(*big.Int))
+	case "withdraw":
+		n.move(args[0].(common.Address), n.contract, n.wallet, args[1].(*big.Int))
+	}
+
+	n.mu.Lock()
+	n.sent = append(n.sent, method.Name)
+	n.mu.Unlock()
+
+	n.mine(tx)
+	return tx.Hash()
+}
+
+// mine includes a successful tx in the next block
+func (n *fakeChainNode) mine(tx *types.Transaction) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.receipts[tx.Hash()] = &types.Receipt{
+		Status:            types.ReceiptStatusSuccessful,
+		TxHash:            tx.Hash(),
+		GasUsed:           50000,
+		EffectiveGasPrice: tx.GasPrice(),
+		BlockNumber:       new(big.Int).SetUint64(n.block + 1),
+		Logs:              []*types.Log{},
+	}
+}
+
+// advance mines the next block
+func (n *fakeChainNode) advance() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.block++
+}
+
+func (n *fakeChainNode) setBalance(token, account common.Address, amount *big.Int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.balances[token] == nil {
+		n.balances[token] = make(map[common.Address]*big.Int)
+	}
+	n.balances[token][account] = amount
+}
+
+func (n *fakeChainNode) balance(token, account common.Address) *big.Int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if balance, exists := n.balances[token][account]; exists {
+		return new(big.Int).Set(balance)
+	}
+	return new(big.Int)
+}
+
+func (n *fakeChainNode) move(token, from, to common.Address, amount *big.Int) {
+	n.setBalance(token, from, new(big.Int).Sub(n.balance(token, from), amount))
+	n.setBalance(token, to, new(big.Int).Add(n.balance(token, to), amount))
+}
+
+func (n *fakeChainNode) sentMethods() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.sent...)
+}
+
+// newTestExecutor returns an executor with a fresh wallet and the node
+// behind it
+func newTestExecutor(t *testing.T) (*Executor, *fakeChainNode) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := crypto.PubkeyToAddress(key.PublicKey)
+	node, client := newFakeChainNode(t, wallet)
+
+	registry, err := dex.NewTokenRegistry(client, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.Register(&dex.Token{Address: testInventoryToken, Symbol: "TKN", Decimals: 18})
+
+	cfg := &config.Config{
+		PrivateKey:         hex.EncodeToString(crypto.FromECDSA(key)),
+		PublicAddress:      wallet,
+		CapitalContract:    testCapital,
+		GasLimitMultiplier: 1.2,
+		GasPriceMultiplier: 1.0,
+	}
+	executor, err := NewExecutor(client, nil, registry, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return executor, node
+}
+
+// newTestRebalancer returns a rebalancer keeping 100 tokens in the capital
+// contract within 5%, and the node behind it
+func newTestRebalancer(t *testing.T) (*Rebalancer, *fakeChainNode) {
+	t.Helper()
+	executor, node := newTestExecutor(t)
+	inventory, err := NewInventory(executor.ethClient, testCapital, []common.Address{testInventoryToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor.SetInventory(inventory)
+
+	targets := map[common.Address]*big.Int{testInventoryToken: tokens(100)}
+	return NewRebalancer(executor, inventory, targets, 500), node
+}
+
+// tokens returns n whole 18-decimal tokens
+func tokens(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+func TestRebalancerMovesInventoryTowardsTarget(t *testing.T) {
+	defer func(interval time.Duration) { receiptPollInterval = interval }(receiptPollInterval)
+	receiptPollInterval = 10 * time.Millisecond
+
+	cases := []struct {
+		name         string
+		contract     int64
+		wallet       int64
+		inFlight     bool
+		sent         []string
+		wantContract int64
+		wantWallet   int64
+	}{
+		{"within tolerance", 104, 50, false, nil, 104, 50},
+		{"top up deficit", 80, 50, false, []string{"transfer"}, 100, 30},
+		{"top up what the wallet holds", 50, 10, false, []string{"transfer"}, 60, 0},
+		{"withdraw excess only", 130, 0, false, []string{"withdraw"}, 100, 30},
+		{"skip during capital trade", 130, 0, true, nil, 130, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rebalancer, node := newTestRebalancer(t)
+
+			node.setBalance(testInventoryToken, testCapital, tokens(tc.contract))
+			node.setBalance(testInventoryToken, node.wallet, tokens(tc.wallet))
+			if tc.inFlight {
+				release := rebalancer.executor.holdCapital(testInventoryToken)
+				defer release()
+			}
+
+			rebalancer.Rebalance(context.Background())
+
+			sent := node.sentMethods()
+			if len(sent) != len(tc.sent) || (len(sent) > 0 && sent[0] != tc.sent[0]) {
+				t.Fatalf("sent %v, want %v", sent, tc.sent)
+			}
+			if got := node.balance(testInventoryToken, testCapital); got.Cmp(tokens(tc.wantContract)) != 0 {
+				t.Fatalf("contract holds %s, want %d tokens", got, tc.wantContract)
+			}
+			if got := node.balance(testInventoryToken, node.wallet); got.Cmp(tokens(tc.wantWallet)) != 0 {
+				t.Fatalf("wallet holds %s, want %d tokens", got, tc.wantWallet)
+			}
+			if tc.sent != nil && rebalancer.inventory.Balance(testInventoryToken).Cmp(tokens(tc.wantContract)) != 0 {
+				t.Fatal("inventory not refreshed after rebalancing")
+			}
+		})
+	}
+}
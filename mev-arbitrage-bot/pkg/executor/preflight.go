@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/flashbots"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/simulator"
+)
+
+// SetSimulator sets the local fork simulator used to pre-flight transactions
+// SetSimulator 设置本地分叉模拟器（发送交易或 Bundle 前预检，Relay 模拟不可用时替代）
+func (e *Executor) SetSimulator(sim *simulator.Simulator) {
+	e.simulator = sim
+}
+
+// preflight simulates transactions in order on a local fork of the latest
+// block. Failed simulations are recorded as fixtures for offline replay
+// when a fixture directory is configured.
+// preflight 在最新区块的本地分叉上按顺序模拟交易；失败时可保存状态快照用于离线复现
+func (e *Executor) preflight(ctx context.Context, txs []*types.Transaction, reverting []common.Hash) (*simulator.BundleResult, error) {
+	ctx = blockchain.WithPriority(ctx, blockchain.PrioritySimulation)
+	session, recorder, err := e.simulator.Record(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := session.SimulateBundle(txs, reverting)
+	if err != nil {
+		return nil, fmt.Errorf("local simulation failed: %w", err)
+	}
+
+	if !result.Success() && e.config.SimulationFixtureDir != "" {
+		path := filepath.Join(e.config.SimulationFixtureDir,
+			fmt.Sprintf("%d-%s.json", session.BlockNumber(), result.Failed.Hash.Hex()[:10]))
+		if err := recorder.Fixture().Save(path); err != nil {
+			log.Warnf("Failed to save simulation fixture: %v", err)
+		} else {
+			log.Infof("Simulation fixture saved: %s", path)
+		}
+	}
+	return result, nil
+}
+
+// simulateLocally simulates a bundle on the local fork, in the shape of a
+// relay simulation result
+// simulateLocally 在本地分叉上模拟 Bundle，返回与 Relay 模拟相同格式的结果
+func (e *Executor) simulateLocally(ctx context.Context, bundle *flashbots.FlashbotsBundle) (*flashbots.SimulationResult, error) {
+	result, err := e.preflight(ctx, bundle.Transactions, bundle.RevertingHashes)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success() {
+		log.Warnf("Local simulation: %s reverted: %s",
+			result.Failed.Hash.Hex(), result.Failed.RevertReason())
+	}
+
+	simResult := &flashbots.SimulationResult{
+		Success:          result.Success(),
+		GasUsed:          result.GasUsed,
+		CoinbaseDiff:     result.CoinbaseDiff,
+		TotalGasFees:     new(big.Int),
+		StateBlockNumber: bundle.BlockNumber - 1,
+	}
+	for _, tx := range result.Results {
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.GasUsed), tx.GasPrice)
+		simResult.TotalGasFees.Add(simResult.TotalGasFees, fee)
+
+		txResult := flashbots.TxResult{TxHash: tx.Hash, GasUsed: tx.GasUsed}
+		if tx.Reverted() {
+			txResult.Error = tx.RevertReason()
+		}
+		simResult.Results = append(simResult.Results, txResult)
+	}
+	if result.GasUsed > 0 {
+		simResult.GasPrice = new(big.Int).Div(simResult.TotalGasFees, new(big.Int).SetUint64(result.GasUsed))
+	}
+	return simResult, nil
+}
+
+// resyncNonce reloads the pending nonce after a signed transaction was
+// dropped without being sent
+// resyncNonce 已签名的交易未发送时，重新读取链上 nonce
+func (e *Executor) resyncNonce() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.updateNonce(); err != nil {
+		log.Warnf("Failed to resync nonce: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
+)
+
+// TxStatus represents the inclusion state of a tracked transaction
+type TxStatus string
+
+const (
+	TxPending  TxStatus = "pending"
+	TxIncluded TxStatus = "included"
+	TxReverted TxStatus = "reverted"
+)
+
+// Tracker retention
+const (
+	txRetainBlocks = 64               // Blocks an included transaction stays tracked (reorg window)
+	txPendingTTL   = 30 * time.Minute // Pending transactions older than this are dropped
+)
+
+// ReceiptReader fetches transaction receipts
+type ReceiptReader interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// TrackedTx is a transaction sent by the bot
+// TrackedTx 表示机器人发送的一笔交易
+type TrackedTx struct {
+	Hash        common.Hash
+	Status      TxStatus
+	BlockNumber uint64
+	BlockHash   common.Hash
+	SentAt      time.Time
+}
+
+// TxTracker keeps track of our transactions and their inclusion blocks
+// TxTracker 跟踪我们发送的交易及其所在区块
+//
+// 发生链重组时，位于孤块中的交易需要重新检查是否仍被打包；
+// 超出重组窗口的已打包交易和长时间未打包的交易会被清理
+type TxTracker struct {
+	receipts ReceiptReader
+	txs      map[common.Hash]*TrackedTx
+	mu       sync.RWMutex
+}
+
+// NewTxTracker creates a new transaction tracker
+// NewTxTracker 创建交易跟踪器
+func NewTxTracker(receipts ReceiptReader) *TxTracker {
+	return &TxTracker{
+		receipts: receipts,
+		txs:      make(map[common.Hash]*TrackedTx),
+	}
+}
+
+// Track registers a sent transaction as pending
+// Track 登记一笔已发送的交易
+func (t *TxTracker) Track(tx *types.Transaction) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.txs[tx.Hash()] = &TrackedTx{
+		Hash:   tx.Hash(),
+		Status: TxPending,
+		SentAt: time.Now(),
+	}
+}
+
+// MarkIncluded records the block a transaction was included in
+// MarkIncluded 记录交易被打包的区块
+func (t *TxTracker) MarkIncluded(receipt *types.Receipt) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked, exists := t.txs[receipt.TxHash]
+	if !exists {
+		tracked = &TrackedTx{Hash: receipt.TxHash, SentAt: time.Now()}
+		t.txs[receipt.TxHash] = tracked
+	}
+
+	tracked.BlockNumber = receipt.BlockNumber.Uint64()
+	tracked.BlockHash = receipt.BlockHash
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		tracked.Status = TxIncluded
+	} else {
+		tracked.Status = TxReverted
+	}
+}
+
+// Get returns a copy of a tracked transaction
+// Get 返回被跟踪交易的副本
+func (t *TxTracker) Get(hash common.Hash) (*TrackedTx, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tracked, exists := t.txs[hash]
+	if !exists {
+		return nil, false
+	}
+
+	trackedCopy := *tracked
+	return &trackedCopy, true
+}
+
+// Prune forgets transactions included more than txRetainBlocks before
+// blockNumber (a reorg can no longer orphan them) and pending transactions
+// older than txPendingTTL
+// Prune 清理超出重组窗口的已打包交易和过期的未打包交易
+func (t *TxTracker) Prune(blockNumber uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for hash, tracked := range t.txs {
+		if tracked.Status == TxPending {
+			if time.Since(tracked.SentAt) > txPendingTTL {
+				delete(t.txs, hash)
+			}
+			continue
+		}
+		if tracked.BlockNumber+txRetainBlocks < blockNumber {
+			delete(t.txs, hash)
+		}
+	}
+}
+
+// Len returns the number of tracked transactions
+// Len 返回被跟踪交易的数量
+func (t *TxTracker) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.txs)
+}
+
+// HandleReorg re-checks inclusion of transactions that were in orphaned blocks
+// HandleReorg 重新检查位于孤块中的交易
+//
+// 处理逻辑:
+// 1. 找出所在区块被孤立的交易，立即恢复为 pending
+// 2. 在后台重新查询收据，不阻塞区块头处理
+// 3. 已在新链上打包 → 更新区块信息；否则 → 保持 pending
+func (t *TxTracker) HandleReorg(event *blockchain.ReorgEvent) {
+	t.mu.Lock()
+	affected := make([]common.Hash, 0)
+	for hash, tracked := range t.txs {
+		if tracked.Status != TxPending && event.IsOrphaned(tracked.BlockHash) {
+			tracked.Status = TxPending
+			tracked.BlockNumber = 0
+			tracked.BlockHash = common.Hash{}
+			affected = append(affected, hash)
+		}
+	}
+	t.mu.Unlock()
+
+	if len(affected) == 0 {
+		return
+	}
+
+	log.Warnf("⚠️  %d of our transactions were in orphaned blocks, re-checking inclusion", len(affected))
+	go t.recheck(affected)
+}
+
+// recheck re-reads receipts of transactions reset by a reorg
+func (t *TxTracker) recheck(hashes []common.Hash) {
+	ctx := blockchain.WithPriority(context.Background(), blockchain.PriorityMonitoring)
+
+	for _, hash := range hashes {
+		callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		receipt, err := t.receipts.TransactionReceipt(callCtx, hash)
+		cancel()
+
+		if err != nil {
+			log.Warnf("Transaction %s no longer included after reorg", hash.Hex())
+			continue
+		}
+
+		t.MarkIncluded(receipt)
+		log.Infof("Transaction %s re-included in block %d", hash.Hex(), receipt.BlockNumber.Uint64())
+	}
+}
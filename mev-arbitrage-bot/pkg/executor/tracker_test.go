@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
+)
+
+// fakeReceipts serves receipts from memory; gate, if set, blocks lookups
+type fakeReceipts struct {
+	receipts map[common.Hash]*types.Receipt
+	gate     chan struct{}
+	mu       sync.Mutex
+}
+
+func (r *fakeReceipts) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if r.gate != nil {
+		select {
+		case <-r.gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	receipt, exists := r.receipts[hash]
+	if !exists {
+		return nil, fmt.Errorf("not found")
+	}
+	return receipt, nil
+}
+
+func testReceipt(hash common.Hash, block int64, blockHash common.Hash) *types.Receipt {
+	return &types.Receipt{
+		TxHash:      hash,
+		Status:      types.ReceiptStatusSuccessful,
+		BlockNumber: big.NewInt(block),
+		BlockHash:   blockHash,
+	}
+}
+
+func TestTxTrackerPrune(t *testing.T) {
+	tracker := NewTxTracker(&fakeReceipts{})
+
+	old := common.HexToHash("0x01")
+	recent := common.HexToHash("0x02")
+	stale := common.HexToHash("0x03")
+	fresh := common.HexToHash("0x04")
+
+	tracker.MarkIncluded(testReceipt(old, 100, common.HexToHash("0xa")))
+	tracker.MarkIncluded(testReceipt(recent, 150, common.HexToHash("0xb")))
+	tracker.txs[stale] = &TrackedTx{Hash: stale, Status: TxPending, SentAt: time.Now().Add(-txPendingTTL - time.Minute)}
+	tracker.txs[fresh] = &TrackedTx{Hash: fresh, Status: TxPending, SentAt: time.Now()}
+
+	tracker.Prune(100 + txRetainBlocks + 1)
+
+	if _, ok := tracker.Get(old); ok {
+		t.Error("transaction beyond the reorg window kept")
+	}
+	if _, ok := tracker.Get(stale); ok {
+		t.Error("expired pending transaction kept")
+	}
+	if _, ok := tracker.Get(recent); !ok {
+		t.Error("transaction inside the reorg window pruned")
+	}
+	if _, ok := tracker.Get(fresh); !ok {
+		t.Error("fresh pending transaction pruned")
+	}
+	if tracker.Len() != 2 {
+		t.Fatalf("tracking %d transactions, want 2", tracker.Len())
+	}
+}
+
+func TestTxTrackerHandleReorgDoesNotBlock(t *testing.T) {
+	orphaned := &types.Header{Number: big.NewInt(102)}
+	orphanedBlock := orphaned.Hash()
+	newBlock := common.HexToHash("0xb")
+	hash := common.HexToHash("0x01")
+
+	receipts := &fakeReceipts{
+		receipts: map[common.Hash]*types.Receipt{hash: testReceipt(hash, 102, newBlock)},
+		gate:     make(chan struct{}),
+	}
+	tracker := NewTxTracker(receipts)
+	tracker.MarkIncluded(testReceipt(hash, 102, orphanedBlock))
+
+	// The receipt lookup is stuck; the head goroutine must not be
+	event := &blockchain.ReorgEvent{CommonAncestor: 101, Depth: 1, Orphaned: []*types.Header{orphaned}}
+	done := make(chan struct{})
+	go func() {
+		tracker.HandleReorg(event)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("HandleReorg blocked on a receipt lookup")
+	}
+
+	tracked, _ := tracker.Get(hash)
+	if tracked.Status != TxPending || tracked.BlockHash != (common.Hash{}) {
+		t.Fatalf("orphaned transaction not reset to pending: %+v", tracked)
+	}
+
+	// Once the lookup completes the transaction carries its new block
+	close(receipts.gate)
+	deadline := time.Now().Add(time.Second)
+	for {
+		tracked, _ = tracker.Get(hash)
+		if tracked.Status == TxIncluded && tracked.BlockHash == newBlock {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("transaction not re-included: %+v", tracked)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,55 @@
+package flashbots
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// NewBackrunBundle creates a bundle that executes txs right after target
+// NewBackrunBundle 创建跟随（back-run）Bundle：目标交易在前，我们的交易紧随其后
+//
+// 排序规则:
+//  1. 目标交易（他人的待处理交易）必须是第一笔，且只出现一次
+//  2. 我们的交易全部排在目标交易之后 —— 只跟随，不抢跑
+//  3. 目标交易不能列入允许失败的列表：它失败时整个 Bundle 作废，
+//     我们依赖它的套利交易也不会上链
+func NewBackrunBundle(target *types.Transaction, txs []*types.Transaction, targetBlock uint64) (*FlashbotsBundle, error) {
+	bundle := &FlashbotsBundle{
+		Transactions: append([]*types.Transaction{target}, txs...),
+		BlockNumber:  targetBlock,
+		Target:       target,
+	}
+	if err := bundle.CheckOrder(); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// CheckOrder verifies the ordering of a back-run bundle; plain bundles
+// (no target) are always valid
+// CheckOrder 检查跟随 Bundle 的交易顺序（普通 Bundle 不检查）
+func (b *FlashbotsBundle) CheckOrder() error {
+	if b.Target == nil {
+		return nil
+	}
+	if len(b.Transactions) < 2 {
+		return fmt.Errorf("back-run bundle needs the target and at least one transaction")
+	}
+
+	target := b.Target.Hash()
+	if b.Transactions[0].Hash() != target {
+		return fmt.Errorf("back-run bundle must start with target %s", target.Hex())
+	}
+	for _, tx := range b.Transactions[1:] {
+		if tx.Hash() == target {
+			return fmt.Errorf("target %s appears more than once", target.Hex())
+		}
+	}
+	for _, hash := range b.RevertingHashes {
+		if hash == target {
+			return fmt.Errorf("target %s may not be allowed to revert", target.Hex())
+		}
+	}
+	return nil
+}
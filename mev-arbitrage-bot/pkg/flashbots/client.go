@@ -1,15 +1,23 @@
 package flashbots
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"net/http"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
@@ -20,10 +28,14 @@ import (
 type FlashbotsClient struct {
 	relayURL   string
 	signingKey *ecdsa.PrivateKey // Flashbots 签名私钥
+	relay      *rpc.Client       // 请求由 signingTransport 签名
 	ethClient  *ethclient.Client
 	config     *config.Config
 }
 
+// relayTimeout bounds each relay request
+const relayTimeout = 10 * time.Second
+
 // NewFlashbotsClient creates a new Flashbots client
 // NewFlashbotsClient 创建一个新的 Flashbots 客户端
 //
@@ -38,9 +50,19 @@ func NewFlashbotsClient(ethClient *ethclient.Client, cfg *config.Config) (*Flash
 		return nil, fmt.Errorf("failed to parse Flashbots signing key: %w", err)
 	}
 
+	httpClient := &http.Client{
+		Transport: &signingTransport{key: signingKey, base: http.DefaultTransport},
+		Timeout:   relayTimeout,
+	}
+	relay, err := rpc.DialOptions(context.Background(), cfg.FlashbotsRelay, rpc.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Flashbots relay: %w", err)
+	}
+
 	client := &FlashbotsClient{
 		relayURL:   cfg.FlashbotsRelay,
 		signingKey: signingKey,
+		relay:      relay,
 		ethClient:  ethClient,
 		config:     cfg,
 	}
@@ -49,6 +71,83 @@ func NewFlashbotsClient(ethClient *ethclient.Client, cfg *config.Config) (*Flash
 	return client, nil
 }
 
+// signingTransport adds the X-Flashbots-Signature header to relay requests
+// signingTransport 为 Relay 请求添加 X-Flashbots-Signature 签名头
+//
+// 签名内容: 请求体 keccak256 哈希的十六进制字符串（EIP-191 personal_sign）
+// 格式: <签名地址>:<签名>
+type signingTransport struct {
+	key  *ecdsa.PrivateKey
+	base http.RoundTripper
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := crypto.Keccak256Hash(body).Hex()
+	signature, err := crypto.Sign(accounts.TextHash([]byte(hash)), t.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign relay request: %w", err)
+	}
+
+	signed := req.Clone(req.Context())
+	signed.Body = io.NopCloser(bytes.NewReader(body))
+	signed.ContentLength = int64(len(body))
+	signed.Header.Set("X-Flashbots-Signature",
+		crypto.PubkeyToAddress(t.key.PublicKey).Hex()+":"+hexutil.Encode(signature))
+	return t.base.RoundTrip(signed)
+}
+
+// sendBundleArgs are the parameters of eth_sendBundle
+type sendBundleArgs struct {
+	Txs               []hexutil.Bytes `json:"txs"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	MinTimestamp      uint64          `json:"minTimestamp,omitempty"`
+	MaxTimestamp      uint64          `json:"maxTimestamp,omitempty"`
+	RevertingTxHashes []common.Hash   `json:"revertingTxHashes,omitempty"`
+}
+
+// callBundleArgs are the parameters of eth_callBundle
+type callBundleArgs struct {
+	Txs              []hexutil.Bytes `json:"txs"`
+	BlockNumber      hexutil.Uint64  `json:"blockNumber"`
+	StateBlockNumber string          `json:"stateBlockNumber"`
+	Timestamp        uint64          `json:"timestamp,omitempty"`
+}
+
+// callBundleResult is the relay's eth_callBundle response; wei amounts are
+// decimal strings
+type callBundleResult struct {
+	BundleGasPrice   string `json:"bundleGasPrice"`
+	CoinbaseDiff     string `json:"coinbaseDiff"`
+	GasFees          string `json:"gasFees"`
+	StateBlockNumber uint64 `json:"stateBlockNumber"`
+	TotalGasUsed     uint64 `json:"totalGasUsed"`
+	Results          []struct {
+		TxHash  common.Hash `json:"txHash"`
+		GasUsed uint64      `json:"gasUsed"`
+		Error   string      `json:"error"`
+		Revert  string      `json:"revert"`
+	} `json:"results"`
+}
+
+// encodeTxs returns the signed transactions of a bundle in wire format
+func encodeTxs(bundle *FlashbotsBundle) ([]hexutil.Bytes, error) {
+	txs := make([]hexutil.Bytes, 0, len(bundle.Transactions))
+	for _, tx := range bundle.Transactions {
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode transaction %s: %w", tx.Hash().Hex(), err)
+		}
+		txs = append(txs, raw)
+	}
+	return txs, nil
+}
+
 // SendBundle sends a bundle of transactions to Flashbots relay
 // SendBundle 将交易捆绑包发送到 Flashbots 中继
 //
@@ -57,27 +156,41 @@ func NewFlashbotsClient(ethClient *ethclient.Client, cfg *config.Config) (*Flash
 //
 // 工作流程:
 // 1. 对 Bundle 进行签名（使用 Flashbots 私钥）
-// 2. 发送到 Flashbots Relay
+// 2. 通过 eth_sendBundle 发送到 Flashbots Relay
 // 3. Relay 将 Bundle 转发给矿工
 // 4. 矿工验证是否盈利，盈利才打包
+//
+// Relay 拒绝（JSON-RPC 错误）时返回 Success=false 的响应；网络错误返回 error
 func (fc *FlashbotsClient) SendBundle(ctx context.Context, bundle *FlashbotsBundle) (*BundleResponse, error) {
 	log.Infof("Sending bundle to Flashbots (target block: %d)", bundle.BlockNumber)
 
-	// TODO: 实现 Flashbots API 调用
-	// 实际实现需要:
-	// 1. 使用 eth_sendBundle RPC 方法
-	// 2. 使用 Flashbots 签名密钥签名请求
-	// 3. 处理响应
+	txs, err := encodeTxs(bundle)
+	if err != nil {
+		return nil, err
+	}
+	args := sendBundleArgs{
+		Txs:               txs,
+		BlockNumber:       hexutil.Uint64(bundle.BlockNumber),
+		MinTimestamp:      bundle.MinTimestamp,
+		MaxTimestamp:      bundle.MaxTimestamp,
+		RevertingTxHashes: bundle.RevertingHashes,
+	}
 
-	// 当前为模拟实现
-	response := &BundleResponse{
-		BundleHash: common.BytesToHash([]byte("simulated_bundle_hash")),
-		Success:    true,
-		Error:      "",
+	var result struct {
+		BundleHash common.Hash `json:"bundleHash"`
+	}
+	if err := fc.relay.CallContext(ctx, &result, "eth_sendBundle", args); err != nil {
+		// Relay 拒绝：JSON-RPC 错误或 4xx 响应
+		var rpcErr rpc.Error
+		var httpErr rpc.HTTPError
+		if errors.As(err, &rpcErr) || (errors.As(err, &httpErr) && httpErr.StatusCode < 500) {
+			return &BundleResponse{Success: false, Error: err.Error()}, nil
+		}
+		return nil, fmt.Errorf("eth_sendBundle failed: %w", err)
 	}
 
-	log.Info("Bundle sent successfully (simulated)")
-	return response, nil
+	log.Infof("Bundle accepted by relay: %s", result.BundleHash.Hex())
+	return &BundleResponse{BundleHash: result.BundleHash, Success: true}, nil
 }
 
 // SimulateBundle simulates a bundle before sending
@@ -86,33 +199,65 @@ func (fc *FlashbotsClient) SendBundle(ctx context.Context, bundle *FlashbotsBund
 // 目的: 验证交易是否会成功，避免浪费 Gas
 //
 // 模拟过程:
-// 1. 在本地 EVM 中执行交易
-// 2. 检查是否有错误
-// 3. 计算 Gas 消耗和收益
-// 4. 只有盈利的交易才会真正发送
+// 1. 通过 eth_callBundle 在最新状态上执行 Bundle
+// 2. 检查每笔交易是否出错（允许失败的交易除外）
+// 3. 返回每笔交易的 Gas 消耗和矿工收益
 func (fc *FlashbotsClient) SimulateBundle(ctx context.Context, bundle *FlashbotsBundle) (*SimulationResult, error) {
 	log.Info("Simulating bundle before sending")
 
-	// TODO: 实现 Bundle 模拟
-	// 实际实现需要:
-	// 1. 使用 eth_callBundle RPC 方法
-	// 2. 获取模拟结果
-	// 3. 验证盈利性
+	txs, err := encodeTxs(bundle)
+	if err != nil {
+		return nil, err
+	}
+	args := callBundleArgs{
+		Txs:              txs,
+		BlockNumber:      hexutil.Uint64(bundle.BlockNumber),
+		StateBlockNumber: "latest",
+	}
+
+	var response callBundleResult
+	if err := fc.relay.CallContext(ctx, &response, "eth_callBundle", args); err != nil {
+		return nil, fmt.Errorf("eth_callBundle failed: %w", err)
+	}
+
+	allowedToRevert := make(map[common.Hash]bool, len(bundle.RevertingHashes))
+	for _, hash := range bundle.RevertingHashes {
+		allowedToRevert[hash] = true
+	}
 
-	// 当前为模拟实现
 	result := &SimulationResult{
-		Success:          true,
-		GasUsed:          300000,
-		GasPrice:         big.NewInt(25000000000),      // 25 Gwei
-		CoinbaseDiff:     big.NewInt(1000000000000000), // 0.001 ETH
-		TotalGasFees:     big.NewInt(7500000000000000), // Gas费用
-		StateBlockNumber: bundle.BlockNumber - 1,
+		Success:          len(response.Results) == len(bundle.Transactions),
+		GasUsed:          response.TotalGasUsed,
+		GasPrice:         parseWei(response.BundleGasPrice),
+		CoinbaseDiff:     parseWei(response.CoinbaseDiff),
+		TotalGasFees:     parseWei(response.GasFees),
+		StateBlockNumber: response.StateBlockNumber,
+	}
+	for _, tx := range response.Results {
+		txResult := TxResult{TxHash: tx.TxHash, GasUsed: tx.GasUsed, Error: tx.Error}
+		if txResult.Error != "" && tx.Revert != "" {
+			txResult.Error += ": " + tx.Revert
+		}
+		if txResult.Error != "" && !allowedToRevert[tx.TxHash] {
+			log.Warnf("Bundle simulation: %s failed: %s", tx.TxHash.Hex(), txResult.Error)
+			result.Success = false
+		}
+		result.Results = append(result.Results, txResult)
 	}
 
-	log.Info("Bundle simulation completed")
+	log.Infof("Bundle simulation completed: gas=%d, success=%v", result.GasUsed, result.Success)
 	return result, nil
 }
 
+// parseWei parses a decimal wei amount from the relay (0 if malformed)
+func parseWei(s string) *big.Int {
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return value
+}
+
 // BuildBundle creates a bundle from transactions
 // BuildBundle 从交易创建捆绑包
 //
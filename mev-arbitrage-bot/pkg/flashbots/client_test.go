@@ -0,0 +1,210 @@
+package flashbots
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+)
+
+// fakeRelay is a Flashbots relay that checks request signatures and
+// answers with canned results per method
+type fakeRelay struct {
+	t       *testing.T
+	signer  common.Address
+	results map[string]interface{} // method -> result, or *rpcError
+	params  map[string]json.RawMessage
+	mu      sync.Mutex
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (r *fakeRelay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	// X-Flashbots-Signature: <address>:<personal_sign of keccak256(body) hex>
+	parts := strings.SplitN(req.Header.Get("X-Flashbots-Signature"), ":", 2)
+	signature, err := hexutil.Decode(parts[len(parts)-1])
+	if len(parts) != 2 || err != nil {
+		r.t.Errorf("malformed signature header %q", req.Header.Get("X-Flashbots-Signature"))
+		return
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte(crypto.Keccak256Hash(body).Hex())), signature)
+	if err != nil || crypto.PubkeyToAddress(*pub) != r.signer || common.HexToAddress(parts[0]) != r.signer {
+		r.t.Errorf("request not signed by %s", r.signer.Hex())
+	}
+
+	var call struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.Unmarshal(body, &call)
+
+	r.mu.Lock()
+	r.params[call.Method] = call.Params[0]
+	result := r.results[call.Method]
+	r.mu.Unlock()
+
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": call.ID}
+	if rpcErr, ok := result.(*rpcError); ok {
+		response["error"] = rpcErr
+	} else {
+		response["result"] = result
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// respond sets the result of method
+func (r *fakeRelay) respond(method string, result interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results[method] = result
+}
+
+// sent decodes the parameters of the last call of method into args
+func (r *fakeRelay) sent(method string, args interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := json.Unmarshal(r.params[method], args); err != nil {
+		r.t.Fatalf("decode %s parameters: %v", method, err)
+	}
+}
+
+func newTestClient(t *testing.T) (*FlashbotsClient, *fakeRelay) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relay := &fakeRelay{
+		t:       t,
+		signer:  crypto.PubkeyToAddress(key.PublicKey),
+		results: make(map[string]interface{}),
+		params:  make(map[string]json.RawMessage),
+	}
+	server := httptest.NewServer(relay)
+	t.Cleanup(server.Close)
+
+	client, err := NewFlashbotsClient(nil, &config.Config{
+		FlashbotsRelay:      server.URL,
+		FlashbotsSigningKey: hex.EncodeToString(crypto.FromECDSA(key)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, relay
+}
+
+// testBundle returns a bundle of two signed transactions for block 100
+func testBundle(t *testing.T) *FlashbotsBundle {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	var txs []*types.Transaction
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx, err := types.SignTx(types.NewTransaction(nonce, common.HexToAddress("0xc0"), big.NewInt(0), 200000, big.NewInt(1e9), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+	return &FlashbotsBundle{Transactions: txs, BlockNumber: 100, MaxTimestamp: 1700000000}
+}
+
+func TestSimulateBundleReportsPerTransactionResults(t *testing.T) {
+	client, relay := newTestClient(t)
+	bundle := testBundle(t)
+	first, second := bundle.Transactions[0].Hash(), bundle.Transactions[1].Hash()
+
+	relay.respond("eth_callBundle", map[string]interface{}{
+		"bundleGasPrice":   "1000000000",
+		"coinbaseDiff":     "250000000000000",
+		"gasFees":          "250000000000000",
+		"stateBlockNumber": 99,
+		"totalGasUsed":     250000,
+		"results": []map[string]interface{}{
+			{"txHash": first, "gasUsed": 180000},
+			{"txHash": second, "gasUsed": 70000, "error": "execution reverted", "revert": "No profit"},
+		},
+	})
+
+	result, err := client.SimulateBundle(context.Background(), bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success {
+		t.Fatal("bundle with a failing transaction simulated as successful")
+	}
+	if own := result.Result(first); own == nil || own.GasUsed != 180000 || own.Error != "" {
+		t.Fatalf("first transaction result %+v", own)
+	}
+	if failed := result.Result(second); failed == nil || failed.Error != "execution reverted: No profit" {
+		t.Fatalf("second transaction result %+v", failed)
+	}
+	if result.GasUsed != 250000 || result.CoinbaseDiff.Cmp(big.NewInt(250000000000000)) != 0 || result.StateBlockNumber != 99 {
+		t.Fatalf("bundle result %+v", result)
+	}
+
+	var args callBundleArgs
+	relay.sent("eth_callBundle", &args)
+	if len(args.Txs) != 2 || uint64(args.BlockNumber) != 100 || args.StateBlockNumber != "latest" {
+		t.Fatalf("eth_callBundle sent %+v", args)
+	}
+
+	// A transaction allowed to revert does not fail the bundle
+	bundle.RevertingHashes = []common.Hash{second}
+	if result, err := client.SimulateBundle(context.Background(), bundle); err != nil || !result.Success {
+		t.Fatalf("bundle with an allowed revert failed: %v", err)
+	}
+}
+
+func TestSendBundle(t *testing.T) {
+	client, relay := newTestClient(t)
+	bundle := testBundle(t)
+	bundle.RevertingHashes = []common.Hash{bundle.Transactions[1].Hash()}
+
+	bundleHash := common.HexToHash("0xb0")
+	relay.respond("eth_sendBundle", map[string]interface{}{"bundleHash": bundleHash})
+
+	response, err := client.SendBundle(context.Background(), bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.Success || response.BundleHash != bundleHash {
+		t.Fatalf("response %+v", response)
+	}
+
+	var args sendBundleArgs
+	relay.sent("eth_sendBundle", &args)
+	raw, _ := bundle.Transactions[0].MarshalBinary()
+	if len(args.Txs) != 2 || !strings.EqualFold(args.Txs[0].String(), hexutil.Encode(raw)) ||
+		uint64(args.BlockNumber) != 100 || args.MaxTimestamp != 1700000000 || len(args.RevertingTxHashes) != 1 {
+		t.Fatalf("eth_sendBundle sent %+v", args)
+	}
+
+	// A relay error is a rejection, not a transport failure
+	relay.respond("eth_sendBundle", &rpcError{Code: -32000, Message: "bundle block too old"})
+	response, err = client.SendBundle(context.Background(), bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Success || !strings.Contains(response.Error, "too old") {
+		t.Fatalf("rejection reported as %+v", response)
+	}
+}
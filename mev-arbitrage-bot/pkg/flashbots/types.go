@@ -15,6 +15,7 @@ type FlashbotsBundle struct {
 	MinTimestamp    uint64               // 最小时间戳
 	MaxTimestamp    uint64               // 最大时间戳
 	RevertingHashes []common.Hash        // 允许失败的交易哈希
+	Target          *types.Transaction   // 被跟随的交易（back-run 目标），nil 表示普通 Bundle
 }
 
 // BundleResponse represents the response from Flashbots relay
@@ -28,10 +29,31 @@ type BundleResponse struct {
 // SimulationResult represents the result of a bundle simulation
 // SimulationResult 表示 Bundle 模拟的结果
 type SimulationResult struct {
-	Success          bool     // 模拟是否成功
-	GasUsed          uint64   // 使用的 Gas
-	GasPrice         *big.Int // Gas 价格
-	CoinbaseDiff     *big.Int // 矿工收益差异
-	TotalGasFees     *big.Int // 总 Gas 费用
-	StateBlockNumber uint64   // 状态区块号
+	Success          bool       // 模拟是否成功
+	GasUsed          uint64     // 使用的 Gas
+	GasPrice         *big.Int   // Gas 价格
+	CoinbaseDiff     *big.Int   // 矿工收益差异
+	TotalGasFees     *big.Int   // 总 Gas 费用
+	StateBlockNumber uint64     // 状态区块号
+	Results          []TxResult // 每笔交易的结果（按 Bundle 顺序，Relay 未返回时为空）
+}
+
+// TxResult is the simulated outcome of one transaction in a bundle
+// TxResult 表示 Bundle 中单笔交易的模拟结果
+type TxResult struct {
+	TxHash  common.Hash // 交易哈希
+	GasUsed uint64      // 该交易使用的 Gas
+	Error   string      // 回滚原因（成功时为空）
+}
+
+// Result returns the outcome of the transaction with the given hash, or nil
+// if the simulation did not report it
+// Result 返回指定交易的模拟结果（未返回时为 nil）
+func (r *SimulationResult) Result(hash common.Hash) *TxResult {
+	for i := range r.Results {
+		if r.Results[i].TxHash == hash {
+			return &r.Results[i]
+		}
+	}
+	return nil
 }
@@ -0,0 +1,191 @@
+package flashloan
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// AaveV3PoolABI covers pool discovery, the flash-loan premium and reserve
+// data. getReserveData returns a struct of static fields, which encodes
+// the same as the flat outputs listed here.
+const AaveV3PoolABI = `[
+	{"inputs":[],"name":"getPool","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"FLASHLOAN_PREMIUM_TOTAL","outputs":[{"name":"","type":"uint128"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"asset","type":"address"}],"name":"getReserveData","outputs":[
+		{"name":"configuration","type":"uint256"},
+		{"name":"liquidityIndex","type":"uint128"},
+		{"name":"currentLiquidityRate","type":"uint128"},
+		{"name":"variableBorrowIndex","type":"uint128"},
+		{"name":"currentVariableBorrowRate","type":"uint128"},
+		{"name":"currentStableBorrowRate","type":"uint128"},
+		{"name":"lastUpdateTimestamp","type":"uint40"},
+		{"name":"id","type":"uint16"},
+		{"name":"aTokenAddress","type":"address"},
+		{"name":"stableDebtTokenAddress","type":"address"},
+		{"name":"variableDebtTokenAddress","type":"address"},
+		{"name":"interestRateStrategyAddress","type":"address"},
+		{"name":"accruedToTreasury","type":"uint128"},
+		{"name":"unbacked","type":"uint128"},
+		{"name":"isolationModeTotalDebt","type":"uint128"}
+	],"stateMutability":"view","type":"function"}
+]`
+
+// aaveFlashLoanGas is the gas of flashLoanSimple: pool call, callback,
+// premium transfer and repayment
+const aaveFlashLoanGas = 85000
+
+// AaveV3Provider lends from an Aave V3 pool; the available liquidity of an
+// asset is the asset balance held by its aToken
+type AaveV3Provider struct {
+	client     *ethclient.Client
+	pool       common.Address
+	poolABI    abi.ABI
+	erc20ABI   abi.ABI
+	premiumBps int64
+	aTokens    map[common.Address]common.Address
+	balances   *balanceCache
+	mu         sync.RWMutex
+}
+
+// NewAaveV3Provider resolves the pool of a PoolAddressesProvider and its
+// flash-loan premium
+func NewAaveV3Provider(client *ethclient.Client, addressesProvider common.Address) (*AaveV3Provider, error) {
+	poolABI, err := abi.JSON(strings.NewReader(AaveV3PoolABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Aave pool ABI: %w", err)
+	}
+	erc20ABI, err := abi.JSON(strings.NewReader(dex.ERC20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+
+	provider := &AaveV3Provider{
+		client:     client,
+		poolABI:    poolABI,
+		erc20ABI:   erc20ABI,
+		premiumBps: config.AaveFlashLoanFeeBps,
+		aTokens:    make(map[common.Address]common.Address),
+		balances:   newBalanceCache(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	values, err := callContract(ctx, client, poolABI, addressesProvider, "getPool")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Aave pool: %w", err)
+	}
+	provider.pool = values[0].(common.Address)
+
+	values, err = callContract(ctx, client, poolABI, provider.pool, "FLASHLOAN_PREMIUM_TOTAL")
+	if err != nil {
+		log.Warnf("Failed to read Aave flash-loan premium, assuming %d bps: %v", provider.premiumBps, err)
+	} else {
+		provider.premiumBps = values[0].(*big.Int).Int64()
+	}
+
+	log.Infof("Aave V3 flash loans: pool %s, premium %d bps", provider.pool.Hex(), provider.premiumBps)
+	return provider, nil
+}
+
+// Name returns the provider name
+func (p *AaveV3Provider) Name() string {
+	return "aave_v3"
+}
+
+// EntryPoint returns the contract function borrowing from Aave
+func (p *AaveV3Provider) EntryPoint() EntryPoint {
+	return EntryAave
+}
+
+// Quote returns the premium and liquidity for borrowing asset
+func (p *AaveV3Provider) Quote(ctx context.Context, asset common.Address, amount *big.Int, _ []common.Address) (*Quote, error) {
+	liquidity, err := p.balances.get(asset, func() (*big.Int, error) {
+		return p.liquidity(ctx, asset)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fee := feeOf(amount, p.premiumBps, 10000)
+	return &Quote{
+		Provider:    p.Name(),
+		Asset:       asset,
+		Amount:      new(big.Int).Set(amount),
+		Fee:         fee,
+		FeeBps:      int(p.premiumBps),
+		Liquidity:   liquidity,
+		Lender:      p.pool,
+		EntryPoint:  EntryAave,
+		GasOverhead: aaveFlashLoanGas,
+	}, nil
+}
+
+// liquidity returns the asset balance of the asset's aToken
+func (p *AaveV3Provider) liquidity(ctx context.Context, asset common.Address) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	aToken, err := p.aToken(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	if aToken == (common.Address{}) {
+		return big.NewInt(0), nil
+	}
+
+	values, err := callContract(ctx, p.client, p.erc20ABI, asset, "balanceOf", aToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aToken balance: %w", err)
+	}
+	return values[0].(*big.Int), nil
+}
+
+// aToken returns the aToken of an asset (zero if Aave does not list it)
+func (p *AaveV3Provider) aToken(ctx context.Context, asset common.Address) (common.Address, error) {
+	p.mu.RLock()
+	aToken, exists := p.aTokens[asset]
+	p.mu.RUnlock()
+	if exists {
+		return aToken, nil
+	}
+
+	values, err := callContract(ctx, p.client, p.poolABI, p.pool, "getReserveData", asset)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to read Aave reserve data: %w", err)
+	}
+	aToken = values[8].(common.Address)
+
+	p.mu.Lock()
+	p.aTokens[asset] = aToken
+	p.mu.Unlock()
+
+	return aToken, nil
+}
+
+// callContract performs an eth_call and unpacks its outputs
+func callContract(ctx context.Context, client *ethclient.Client, contractABI abi.ABI, to common.Address, method string, args ...interface{}) ([]interface{}, error) {
+	input, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: input}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return contractABI.Unpack(method, output)
+}
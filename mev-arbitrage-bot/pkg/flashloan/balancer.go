@@ -0,0 +1,79 @@
+package flashloan
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// balancerFlashLoanGas is the gas of Vault.flashLoan: callback and
+// balance checks before and after
+const balancerFlashLoanGas = 50000
+
+// BalancerProvider lends any token held by the Balancer Vault at zero fee
+type BalancerProvider struct {
+	client   *ethclient.Client
+	vault    common.Address
+	erc20ABI abi.ABI
+	balances *balanceCache
+}
+
+// NewBalancerProvider creates a provider for a Balancer Vault
+func NewBalancerProvider(client *ethclient.Client, vault common.Address) (*BalancerProvider, error) {
+	erc20ABI, err := abi.JSON(strings.NewReader(dex.ERC20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+
+	return &BalancerProvider{
+		client:   client,
+		vault:    vault,
+		erc20ABI: erc20ABI,
+		balances: newBalanceCache(),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *BalancerProvider) Name() string {
+	return "balancer"
+}
+
+// EntryPoint returns the contract function borrowing from the Vault
+func (p *BalancerProvider) EntryPoint() EntryPoint {
+	return EntryBalancer
+}
+
+// Quote returns the Vault's balance of asset; Balancer charges no fee
+func (p *BalancerProvider) Quote(ctx context.Context, asset common.Address, amount *big.Int, _ []common.Address) (*Quote, error) {
+	liquidity, err := p.balances.get(asset, func() (*big.Int, error) {
+		ctx, cancel := context.WithTimeout(ctx, callTimeout)
+		defer cancel()
+
+		values, err := callContract(ctx, p.client, p.erc20ABI, asset, "balanceOf", p.vault)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault balance: %w", err)
+		}
+		return values[0].(*big.Int), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Quote{
+		Provider:    p.Name(),
+		Asset:       asset,
+		Amount:      new(big.Int).Set(amount),
+		Fee:         big.NewInt(0),
+		Liquidity:   liquidity,
+		Lender:      p.vault,
+		EntryPoint:  EntryBalancer,
+		GasOverhead: balancerFlashLoanGas,
+	}, nil
+}
@@ -0,0 +1,188 @@
+package flashloan
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
+)
+
+// liquidityTTL is how long on-chain lender balances are reused (one block)
+const liquidityTTL = 12 * time.Second
+
+// callTimeout bounds each on-chain lookup of a provider
+const callTimeout = 5 * time.Second
+
+// EntryPoint is the arbitrage contract function that borrows from a source
+// and runs the trade in its callback
+type EntryPoint string
+
+const (
+	EntryAave      EntryPoint = "executeFlashLoanArbitrage" // Aave V3 flashLoanSimple -> executeOperation
+	EntryBalancer  EntryPoint = "executeBalancerFlashLoan"  // Balancer Vault flashLoan -> receiveFlashLoan
+	EntryFlashSwap EntryPoint = "executeFlashSwap"          // Uniswap V2 pair swap -> uniswapV2Call
+)
+
+// Quote is the cost of borrowing an amount of an asset from one source
+type Quote struct {
+	Provider    string
+	Asset       common.Address
+	Amount      *big.Int
+	Fee         *big.Int       // Paid on top of Amount, in asset units
+	FeeBps      int            // Fee rate, rounded up
+	Liquidity   *big.Int       // Largest amount the source can lend
+	Lender      common.Address // Aave pool, Balancer vault or V2 pair
+	EntryPoint  EntryPoint
+	GasOverhead uint64 // Gas of the loan on top of the swaps
+}
+
+// Provider is a source of flash loans
+type Provider interface {
+	// Name returns a short identifier used in logs and config
+	Name() string
+
+	// EntryPoint returns the arbitrage contract function borrowing from
+	// this source
+	EntryPoint() EntryPoint
+
+	// Quote returns the liquidity and fee for borrowing amount of asset.
+	// Pools in exclude are traded by the arbitrage and cannot lend.
+	Quote(ctx context.Context, asset common.Address, amount *big.Int, exclude []common.Address) (*Quote, error)
+}
+
+// Selector picks the cheapest provider able to lend an amount
+type Selector struct {
+	providers   []Provider
+	entryPoints map[EntryPoint]bool // Entry points the contract has (nil = all)
+}
+
+// NewSelector creates a selector over providers
+func NewSelector(providers ...Provider) *Selector {
+	return &Selector{providers: providers}
+}
+
+// SetEntryPoints limits the selector to providers the arbitrage contract
+// can borrow from. A cheaper source without an entry point would make
+// every opportunity unexecutable and understate its cost.
+func (s *Selector) SetEntryPoints(entryPoints ...EntryPoint) {
+	s.entryPoints = make(map[EntryPoint]bool, len(entryPoints))
+	for _, entryPoint := range entryPoints {
+		s.entryPoints[entryPoint] = true
+	}
+
+	for _, provider := range s.providers {
+		if !s.usable(provider) {
+			log.Warnf("Flash-loan provider %s skipped: contract has no %s entry point", provider.Name(), provider.EntryPoint())
+		}
+	}
+}
+
+// usable reports whether the contract can borrow from provider
+func (s *Selector) usable(provider Provider) bool {
+	return s.entryPoints == nil || s.entryPoints[provider.EntryPoint()]
+}
+
+// Names returns the names of the usable providers
+func (s *Selector) Names() []string {
+	names := make([]string, 0, len(s.providers))
+	for _, provider := range s.providers {
+		if s.usable(provider) {
+			names = append(names, provider.Name())
+		}
+	}
+	return names
+}
+
+// Cheapest returns the quote with the lowest fee among usable providers
+// with enough liquidity, preferring lower gas overhead on ties
+func (s *Selector) Cheapest(ctx context.Context, asset common.Address, amount *big.Int, exclude []common.Address) (*Quote, error) {
+	ctx = blockchain.WithPriority(ctx, blockchain.PrioritySimulation)
+
+	var best *Quote
+	for _, provider := range s.providers {
+		if !s.usable(provider) {
+			continue
+		}
+		quote, err := provider.Quote(ctx, asset, amount, exclude)
+		if err != nil {
+			log.Debugf("Flash-loan provider %s cannot lend %s: %v", provider.Name(), asset.Hex(), err)
+			continue
+		}
+		if quote.Liquidity.Cmp(amount) < 0 {
+			continue
+		}
+
+		if best == nil {
+			best = quote
+			continue
+		}
+		if cmp := quote.Fee.Cmp(best.Fee); cmp < 0 || (cmp == 0 && quote.GasOverhead < best.GasOverhead) {
+			best = quote
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no flash-loan source can lend %s of %s", amount.String(), asset.Hex())
+	}
+	return best, nil
+}
+
+// feeOf returns amount * num / den rounded up
+func feeOf(amount *big.Int, num, den int64) *big.Int {
+	fee := new(big.Int).Mul(amount, big.NewInt(num))
+	fee.Add(fee, big.NewInt(den-1))
+	return fee.Div(fee, big.NewInt(den))
+}
+
+// feeBps returns the fee rate of a quote in basis points, rounded up
+func feeBps(fee, amount *big.Int) int {
+	if amount.Sign() <= 0 {
+		return 0
+	}
+	bps := new(big.Int).Mul(fee, big.NewInt(10000))
+	bps.Add(bps, new(big.Int).Sub(amount, big.NewInt(1)))
+	return int(bps.Div(bps, amount).Int64())
+}
+
+// balanceCache keeps recent lender balances per asset
+type balanceCache struct {
+	entries map[common.Address]cachedBalance
+	mu      sync.Mutex
+}
+
+type cachedBalance struct {
+	balance   *big.Int
+	fetchedAt time.Time
+}
+
+func newBalanceCache() *balanceCache {
+	return &balanceCache{entries: make(map[common.Address]cachedBalance)}
+}
+
+// get returns the cached balance of asset, fetching it when stale
+func (c *balanceCache) get(asset common.Address, fetch func() (*big.Int, error)) (*big.Int, error) {
+	c.mu.Lock()
+	entry, exists := c.entries[asset]
+	c.mu.Unlock()
+
+	if exists && time.Since(entry.fetchedAt) < liquidityTTL {
+		return entry.balance, nil
+	}
+
+	balance, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[asset] = cachedBalance{balance: balance, fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	return balance, nil
+}
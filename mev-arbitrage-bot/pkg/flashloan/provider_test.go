@@ -0,0 +1,127 @@
+package flashloan
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// fixedProvider lends up to liquidity at a fixed fee rate
+type fixedProvider struct {
+	name      string
+	feeBps    int64
+	liquidity int64
+	gas       uint64
+	entry     EntryPoint
+}
+
+func (p fixedProvider) Name() string           { return p.name }
+func (p fixedProvider) EntryPoint() EntryPoint { return p.entry }
+
+func (p fixedProvider) Quote(_ context.Context, asset common.Address, amount *big.Int, _ []common.Address) (*Quote, error) {
+	return &Quote{
+		Provider:    p.name,
+		Asset:       asset,
+		Amount:      amount,
+		Fee:         feeOf(amount, p.feeBps, 10000),
+		Liquidity:   big.NewInt(p.liquidity),
+		EntryPoint:  p.entry,
+		GasOverhead: p.gas,
+	}, nil
+}
+
+func TestSelectorPicksCheapestViableSource(t *testing.T) {
+	asset := common.HexToAddress("0x01")
+	selector := NewSelector(
+		fixedProvider{name: "aave", feeBps: 5, liquidity: 1e9, gas: 85000},
+		fixedProvider{name: "balancer", feeBps: 0, liquidity: 1e6, gas: 50000},
+		fixedProvider{name: "free_but_expensive_gas", feeBps: 0, liquidity: 1e9, gas: 90000},
+	)
+
+	// Balancer is free and has enough liquidity
+	quote, err := selector.Cheapest(context.Background(), asset, big.NewInt(1e5), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Provider != "balancer" {
+		t.Fatalf("picked %s, want balancer", quote.Provider)
+	}
+
+	// Too large for Balancer: the other free source wins over Aave
+	quote, err = selector.Cheapest(context.Background(), asset, big.NewInt(1e8), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Provider != "free_but_expensive_gas" {
+		t.Fatalf("picked %s, want free_but_expensive_gas", quote.Provider)
+	}
+
+	// Nobody can lend this much
+	if _, err := selector.Cheapest(context.Background(), asset, big.NewInt(1e10), nil); err == nil {
+		t.Fatal("expected no viable source")
+	}
+}
+
+func TestSelectorSkipsSourcesWithoutEntryPoint(t *testing.T) {
+	asset := common.HexToAddress("0x01")
+	selector := NewSelector(
+		fixedProvider{name: "aave", feeBps: 5, liquidity: 1e9, gas: 85000, entry: EntryAave},
+		fixedProvider{name: "balancer", feeBps: 0, liquidity: 1e9, gas: 50000, entry: EntryBalancer},
+	)
+
+	// Balancer is free, but the contract can only borrow from Aave
+	selector.SetEntryPoints(EntryAave)
+	quote, err := selector.Cheapest(context.Background(), asset, big.NewInt(1e5), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Provider != "aave" || quote.EntryPoint != EntryAave {
+		t.Fatalf("picked %s, want aave", quote.Provider)
+	}
+	if names := selector.Names(); len(names) != 1 || names[0] != "aave" {
+		t.Fatalf("usable providers %v, want [aave]", names)
+	}
+
+	// Without a usable source nothing can be borrowed
+	selector.SetEntryPoints(EntryFlashSwap)
+	if _, err := selector.Cheapest(context.Background(), asset, big.NewInt(1e5), nil); err == nil {
+		t.Fatal("expected no usable source")
+	}
+}
+
+func TestUniswapV2FlashSwapSkipsRoutePools(t *testing.T) {
+	weth := common.HexToAddress("0x01")
+	usdc := common.HexToAddress("0x02")
+
+	deep := &dex.Pool{Address: common.HexToAddress("0xaa"), DEX: dex.UniswapV2, Token0: weth, Token1: usdc,
+		Reserve0: big.NewInt(1_000_000), Reserve1: big.NewInt(1_000_000), Fee: 30}
+	shallow := &dex.Pool{Address: common.HexToAddress("0xbb"), DEX: dex.SushiSwap, Token0: weth, Token1: usdc,
+		Reserve0: big.NewInt(500_000), Reserve1: big.NewInt(500_000), Fee: 30}
+
+	provider := NewUniswapV2FlashSwapProvider(func() []*dex.Pool { return []*dex.Pool{deep, shallow} })
+
+	quote, err := provider.Quote(context.Background(), weth, big.NewInt(99700), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Lender != deep.Address {
+		t.Fatalf("borrowed from %s, want the deepest pair", quote.Lender.Hex())
+	}
+	// Repay 99700 * 10000 / 9970 = 100000
+	if quote.Fee.Cmp(big.NewInt(300)) != 0 || quote.FeeBps != 31 {
+		t.Fatalf("fee %s (%d bps), want 300 (31 bps)", quote.Fee, quote.FeeBps)
+	}
+
+	// The deep pair is on the route and locked
+	quote, err = provider.Quote(context.Background(), weth, big.NewInt(1000), []common.Address{deep.Address})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Lender != shallow.Address {
+		t.Fatalf("borrowed from %s, want the pair off the route", quote.Lender.Hex())
+	}
+}
@@ -0,0 +1,82 @@
+package flashloan
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// flashSwapGas is the gas of borrowing through a V2 pair swap with a
+// uniswapV2Call callback, on top of the arbitrage swaps
+const flashSwapGas = 45000
+
+// UniswapV2FlashSwapProvider borrows from the deepest V2-style pair holding
+// the asset that the arbitrage does not trade through (a pair is locked
+// during its own flash swap)
+type UniswapV2FlashSwapProvider struct {
+	pools func() []*dex.Pool
+}
+
+// NewUniswapV2FlashSwapProvider creates a provider over the pools returned
+// by pools, e.g. the pool monitor's snapshot
+func NewUniswapV2FlashSwapProvider(pools func() []*dex.Pool) *UniswapV2FlashSwapProvider {
+	return &UniswapV2FlashSwapProvider{pools: pools}
+}
+
+// Name returns the provider name
+func (p *UniswapV2FlashSwapProvider) Name() string {
+	return "uniswap_v2_flash_swap"
+}
+
+// EntryPoint returns the contract function borrowing from a pair
+func (p *UniswapV2FlashSwapProvider) EntryPoint() EntryPoint {
+	return EntryFlashSwap
+}
+
+// Quote returns the deepest eligible pair. Repaying in the borrowed token
+// costs amount * fee / (10000 - fee), e.g. 0.3009% for a 0.3% pair.
+func (p *UniswapV2FlashSwapProvider) Quote(_ context.Context, asset common.Address, amount *big.Int, exclude []common.Address) (*Quote, error) {
+	excluded := make(map[common.Address]bool, len(exclude))
+	for _, address := range exclude {
+		excluded[address] = true
+	}
+
+	var best *dex.Pool
+	var bestReserve *big.Int
+	for _, pool := range p.pools() {
+		if excluded[pool.Address] || (pool.DEX != dex.UniswapV2 && pool.DEX != dex.SushiSwap) {
+			continue
+		}
+		reserve, _, err := pool.GetReservesFor(asset)
+		if err != nil {
+			continue
+		}
+		if best == nil || reserve.Cmp(bestReserve) > 0 {
+			best, bestReserve = pool, reserve
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no pair holds %s", asset.Hex())
+	}
+
+	// The pair must keep a non-zero reserve
+	liquidity := new(big.Int).Sub(bestReserve, big.NewInt(1))
+	fee := feeOf(amount, int64(best.Fee), int64(10000-best.Fee))
+
+	return &Quote{
+		Provider:    p.Name(),
+		Asset:       asset,
+		Amount:      new(big.Int).Set(amount),
+		Fee:         fee,
+		FeeBps:      feeBps(fee, amount),
+		Liquidity:   liquidity,
+		Lender:      best.Address,
+		EntryPoint:  EntryFlashSwap,
+		GasOverhead: flashSwapGas,
+	}, nil
+}
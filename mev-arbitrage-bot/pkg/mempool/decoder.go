@@ -0,0 +1,179 @@
+package mempool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// UniswapV2RouterSwapABI lists the swap functions of Uniswap V2-style routers
+const UniswapV2RouterSwapABI = `[
+	{"name":"swapExactTokensForTokens","type":"function","stateMutability":"nonpayable","inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapTokensForExactTokens","type":"function","stateMutability":"nonpayable","inputs":[{"name":"amountOut","type":"uint256"},{"name":"amountInMax","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapExactETHForTokens","type":"function","stateMutability":"payable","inputs":[{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapTokensForExactETH","type":"function","stateMutability":"nonpayable","inputs":[{"name":"amountOut","type":"uint256"},{"name":"amountInMax","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapExactTokensForETH","type":"function","stateMutability":"nonpayable","inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapETHForExactTokens","type":"function","stateMutability":"payable","inputs":[{"name":"amountOut","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapExactTokensForTokensSupportingFeeOnTransferTokens","type":"function","stateMutability":"nonpayable","inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[]},
+	{"name":"swapExactETHForTokensSupportingFeeOnTransferTokens","type":"function","stateMutability":"payable","inputs":[{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[]},
+	{"name":"swapExactTokensForETHSupportingFeeOnTransferTokens","type":"function","stateMutability":"nonpayable","inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[]}
+]`
+
+// ErrNotSwap is returned for transactions that are not router swaps
+var ErrNotSwap = errors.New("not a router swap")
+
+// PendingSwap is a router swap seen in the mempool
+type PendingSwap struct {
+	Hash          common.Hash
+	Tx            *types.Transaction // Signed transaction, for bundling
+	Sender        common.Address
+	Router        common.Address
+	DEX           dex.DEXType
+	Method        string
+	Path          []common.Address // Tokens swapped through, in order
+	Pools         []common.Address // Pair of each hop (zero if it cannot be derived offline)
+	ExactOutput   bool             // AmountOut is fixed, AmountIn is at most AmountInMax
+	AmountIn      *big.Int         // Exact input (nil for exact-output swaps)
+	AmountOutMin  *big.Int         // Slippage bound of exact-input swaps
+	AmountOut     *big.Int         // Exact output (nil for exact-input swaps)
+	AmountInMax   *big.Int         // Slippage bound of exact-output swaps
+	FeeOnTransfer bool             // Fee-on-transfer variant
+	Recipient     common.Address
+	Deadline      uint64   // Unix timestamp after which the swap reverts
+	GasPrice      *big.Int // Gas price, or fee cap for dynamic-fee transactions
+	GasTipCap     *big.Int // Priority fee
+	SeenAt        time.Time
+}
+
+// TokenIn returns the token the swap sells
+func (s *PendingSwap) TokenIn() common.Address {
+	return s.Path[0]
+}
+
+// TokenOut returns the token the swap buys
+func (s *PendingSwap) TokenOut() common.Address {
+	return s.Path[len(s.Path)-1]
+}
+
+// Expired reports whether the swap's deadline is before timestamp
+func (s *PendingSwap) Expired(timestamp uint64) bool {
+	return s.Deadline != 0 && s.Deadline < timestamp
+}
+
+// routerInfo is a router the decoder recognizes
+type routerInfo struct {
+	dexType dex.DEXType
+	pairs   dex.PairAddressComputer // Optional; derives hop pools
+}
+
+// Decoder turns transactions sent to known V2-style routers into pending
+// swaps
+type Decoder struct {
+	routerABI abi.ABI
+	routers   map[common.Address]routerInfo
+}
+
+// NewDecoder creates a decoder with no known routers
+func NewDecoder() (*Decoder, error) {
+	routerABI, err := abi.JSON(strings.NewReader(UniswapV2RouterSwapABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse router ABI: %w", err)
+	}
+
+	return &Decoder{
+		routerABI: routerABI,
+		routers:   make(map[common.Address]routerInfo),
+	}, nil
+}
+
+// AddRouter registers a router; pairs (optional) derives the pool of each hop
+func (d *Decoder) AddRouter(router common.Address, dexType dex.DEXType, pairs dex.PairAddressComputer) {
+	d.routers[router] = routerInfo{dexType: dexType, pairs: pairs}
+}
+
+// IsRouter reports whether address is a registered router
+func (d *Decoder) IsRouter(address common.Address) bool {
+	_, known := d.routers[address]
+	return known
+}
+
+// Decode decodes a swap sent by sender, returning ErrNotSwap for other
+// transactions
+func (d *Decoder) Decode(tx *types.Transaction, sender common.Address) (*PendingSwap, error) {
+	if tx.To() == nil {
+		return nil, ErrNotSwap
+	}
+	router, known := d.routers[*tx.To()]
+	if !known || len(tx.Data()) < 4 {
+		return nil, ErrNotSwap
+	}
+
+	method, err := d.routerABI.MethodById(tx.Data()[:4])
+	if err != nil {
+		return nil, ErrNotSwap
+	}
+
+	args := make(map[string]interface{})
+	if err := method.Inputs.UnpackIntoMap(args, tx.Data()[4:]); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", method.Name, err)
+	}
+
+	path, _ := args["path"].([]common.Address)
+	if len(path) < 2 {
+		return nil, fmt.Errorf("%s has an invalid path of %d tokens", method.Name, len(path))
+	}
+
+	swap := &PendingSwap{
+		Hash:          tx.Hash(),
+		Tx:            tx,
+		Sender:        sender,
+		Router:        *tx.To(),
+		DEX:           router.dexType,
+		Method:        method.Name,
+		Path:          path,
+		Pools:         make([]common.Address, len(path)-1),
+		FeeOnTransfer: strings.HasSuffix(method.Name, "SupportingFeeOnTransferTokens"),
+		GasPrice:      tx.GasPrice(),
+		GasTipCap:     tx.GasTipCap(),
+		SeenAt:        time.Now(),
+	}
+	swap.Recipient, _ = args["to"].(common.Address)
+	if deadline, ok := args["deadline"].(*big.Int); ok && deadline.IsUint64() {
+		swap.Deadline = deadline.Uint64()
+	}
+
+	// ETH-in swaps take their input (or input bound) from the value
+	ethIn := method.IsPayable()
+	if amountOut, ok := args["amountOut"].(*big.Int); ok {
+		swap.ExactOutput = true
+		swap.AmountOut = amountOut
+		swap.AmountInMax, _ = args["amountInMax"].(*big.Int)
+		if ethIn {
+			swap.AmountInMax = tx.Value()
+		}
+	} else {
+		swap.AmountIn, _ = args["amountIn"].(*big.Int)
+		swap.AmountOutMin, _ = args["amountOutMin"].(*big.Int)
+		if ethIn {
+			swap.AmountIn = tx.Value()
+		}
+	}
+
+	if router.pairs != nil {
+		for i := range swap.Pools {
+			if pair, ok := router.pairs.ComputePairAddress(path[i], path[i+1]); ok {
+				swap.Pools[i] = pair
+			}
+		}
+	}
+
+	return swap, nil
+}
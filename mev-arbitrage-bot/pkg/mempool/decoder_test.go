@@ -0,0 +1,197 @@
+package mempool
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+var (
+	testRouter  = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	testFactory = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+	testWETH    = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	testDAI     = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	testUSDC    = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+
+	testRecipient = common.HexToAddress("0xbeef")
+)
+
+// create2Pairs derives pairs of a fixed factory
+type create2Pairs struct{}
+
+func (create2Pairs) ComputePairAddress(tokenA, tokenB common.Address) (common.Address, bool) {
+	return dex.ComputePairAddress(testFactory, tokenA, tokenB, common.Hash{1}), true
+}
+
+// signedSwap signs a router call to testRouter
+func signedSwap(t *testing.T, decoder *Decoder, value *big.Int, method string, args ...interface{}) (*types.Transaction, common.Address) {
+	t.Helper()
+
+	data, err := decoder.routerABI.Pack(method, args...)
+	if err != nil {
+		t.Fatalf("pack %s: %v", method, err)
+	}
+
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     7,
+		GasTipCap: big.NewInt(2e9),
+		GasFeeCap: big.NewInt(40e9),
+		Gas:       200000,
+		To:        &testRouter,
+		Value:     value,
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return tx, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func newTestDecoder(t *testing.T) *Decoder {
+	t.Helper()
+	decoder, err := NewDecoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder.AddRouter(testRouter, dex.UniswapV2, create2Pairs{})
+	return decoder
+}
+
+func TestDecodeRouterSwaps(t *testing.T) {
+	decoder := newTestDecoder(t)
+
+	// Exact input through two pools
+	tx, sender := signedSwap(t, decoder, big.NewInt(0), "swapExactTokensForTokens",
+		big.NewInt(1000), big.NewInt(990), []common.Address{testDAI, testWETH, testUSDC}, testRecipient, big.NewInt(1700000000))
+	swap, err := decoder.Decode(tx, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swap.ExactOutput || swap.AmountIn.Int64() != 1000 || swap.AmountOutMin.Int64() != 990 {
+		t.Fatalf("wrong amounts: in %v, min out %v", swap.AmountIn, swap.AmountOutMin)
+	}
+	if swap.Sender != sender || swap.Recipient != testRecipient || swap.Deadline != 1700000000 {
+		t.Fatalf("wrong sender, recipient or deadline: %+v", swap)
+	}
+	if swap.TokenIn() != testDAI || swap.TokenOut() != testUSDC || len(swap.Pools) != 2 {
+		t.Fatalf("wrong path: %v", swap.Path)
+	}
+	if want, _ := (create2Pairs{}).ComputePairAddress(testWETH, testUSDC); swap.Pools[1] != want {
+		t.Fatalf("hop 2 pool %s, want %s", swap.Pools[1].Hex(), want.Hex())
+	}
+	if swap.GasPrice.Int64() != 40e9 || swap.GasTipCap.Int64() != 2e9 {
+		t.Fatalf("wrong gas price %v / tip %v", swap.GasPrice, swap.GasTipCap)
+	}
+
+	// ETH in, exact output: the value bounds the input
+	tx, sender = signedSwap(t, decoder, big.NewInt(5e17), "swapETHForExactTokens",
+		big.NewInt(300), []common.Address{testWETH, testDAI}, testRecipient, big.NewInt(1700000000))
+	swap, err = decoder.Decode(tx, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !swap.ExactOutput || swap.AmountOut.Int64() != 300 || swap.AmountInMax.Int64() != 5e17 || swap.AmountIn != nil {
+		t.Fatalf("wrong amounts: out %v, max in %v", swap.AmountOut, swap.AmountInMax)
+	}
+
+	// Fee-on-transfer variants are flagged
+	tx, sender = signedSwap(t, decoder, big.NewInt(1e18), "swapExactETHForTokensSupportingFeeOnTransferTokens",
+		big.NewInt(1), []common.Address{testWETH, testDAI}, testRecipient, big.NewInt(1700000000))
+	swap, err = decoder.Decode(tx, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !swap.FeeOnTransfer || swap.AmountIn.Int64() != 1e18 {
+		t.Fatalf("fee-on-transfer swap decoded as %+v", swap)
+	}
+}
+
+func TestDecodeIgnoresOtherTransactions(t *testing.T) {
+	decoder := newTestDecoder(t)
+
+	// Unknown router
+	other := common.HexToAddress("0x1234")
+	tx := types.NewTx(&types.LegacyTx{To: &other, Data: []byte{1, 2, 3, 4, 5}, GasPrice: big.NewInt(1)})
+	if _, err := decoder.Decode(tx, common.Address{}); err != ErrNotSwap {
+		t.Fatalf("expected ErrNotSwap for an unknown router, got %v", err)
+	}
+
+	// Known router, unknown method
+	tx = types.NewTx(&types.LegacyTx{To: &testRouter, Data: []byte{1, 2, 3, 4}, GasPrice: big.NewInt(1)})
+	if _, err := decoder.Decode(tx, common.Address{}); err != ErrNotSwap {
+		t.Fatalf("expected ErrNotSwap for an unknown method, got %v", err)
+	}
+}
+
+func TestMonitorTracksPendingSwaps(t *testing.T) {
+	decoder := newTestDecoder(t)
+	monitor := NewMonitor(nil, decoder, time.Minute)
+	feed := monitor.Subscribe()
+
+	// A full transaction as sent by the node, with its sender
+	tx, sender := signedSwap(t, decoder, big.NewInt(0), "swapExactTokensForTokens",
+		big.NewInt(1000), big.NewInt(990), []common.Address{testDAI, testWETH}, testRecipient, big.NewInt(1000))
+	message := rpcMessage(t, tx, sender)
+
+	monitor.handleMessage(message)
+	monitor.handleMessage(message)
+	if monitor.Size() != 1 || len(feed) != 1 {
+		t.Fatalf("expected one tracked and published swap, got %d tracked, %d published", monitor.Size(), len(feed))
+	}
+	published := <-feed
+	if published.Sender != sender || published.Hash != tx.Hash() {
+		t.Fatal("published swap does not match the transaction")
+	}
+
+	pool := published.Pools[0]
+	if monitor.PendingSwaps(pool) != 1 {
+		t.Fatalf("pool has %d pending swaps, want 1", monitor.PendingSwaps(pool))
+	}
+
+	// Still pending before the deadline, dropped once it passes
+	monitor.prune(nil, 1000, time.Now())
+	if monitor.Size() != 1 {
+		t.Fatal("swap dropped before its deadline")
+	}
+	monitor.prune(nil, 1001, time.Now())
+	if monitor.Size() != 0 || monitor.PendingSwaps(pool) != 0 {
+		t.Fatal("expired swap still tracked")
+	}
+
+	// Mined swaps are dropped
+	monitor.handleMessage(rpcMessage(t, tx, sender))
+	monitor.prune(map[common.Hash]bool{tx.Hash(): true}, 0, time.Now())
+	if monitor.Size() != 0 {
+		t.Fatal("mined swap still tracked")
+	}
+}
+
+// rpcMessage encodes a transaction the way eth_subscribe delivers it
+func rpcMessage(t *testing.T, tx *types.Transaction, from common.Address) json.RawMessage {
+	t.Helper()
+
+	encoded, err := tx.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields["from"] = from
+	message, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
@@ -0,0 +1,321 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/blockchain"
+)
+
+// Mempool subscription settings
+const (
+	retryDelay    = 3 * time.Second  // Initial delay before resubscribing
+	maxRetryDelay = 30 * time.Second // Max delay before resubscribing
+	feedBuffer    = 256              // Per-subscriber channel buffer
+)
+
+// rpcTransaction is a full pending transaction as returned by the node
+type rpcTransaction struct {
+	tx   *types.Transaction
+	from common.Address
+}
+
+// UnmarshalJSON decodes the transaction and its sender
+func (t *rpcTransaction) UnmarshalJSON(data []byte) error {
+	var sender struct {
+		From *common.Address `json:"from"`
+	}
+	if err := json.Unmarshal(data, &sender); err != nil {
+		return err
+	}
+	if sender.From == nil {
+		return errors.New("missing sender")
+	}
+
+	t.tx = new(types.Transaction)
+	if err := t.tx.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	t.from = *sender.From
+	return nil
+}
+
+// Monitor subscribes to full pending transactions over WebSocket, decodes
+// router swaps and publishes them. It keeps the swaps that are still pending
+// until they are mined, expire or exceed maxAge.
+//
+// Reconnecting the WebSocket client is left to the head subscriber; the
+// monitor resubscribes on the current connection after a failure.
+type Monitor struct {
+	client      *blockchain.Client
+	decoder     *Decoder
+	maxAge      time.Duration
+	subscribers []chan *PendingSwap
+	pending     map[common.Hash]*PendingSwap
+	byPool      map[common.Address]int // Pending swaps per pool
+	hashOnly    bool                   // Node sent hashes instead of transactions
+	mu          sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// NewMonitor creates a mempool monitor on client's WebSocket connection
+func NewMonitor(client *blockchain.Client, decoder *Decoder, maxAge time.Duration) *Monitor {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Monitor{
+		client:  client,
+		decoder: decoder,
+		maxAge:  maxAge,
+		pending: make(map[common.Hash]*PendingSwap),
+		byPool:  make(map[common.Address]int),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Subscribe returns a channel receiving every decoded swap. The channel is
+// closed when the monitor stops. Must be called before Start.
+func (m *Monitor) Subscribe() <-chan *PendingSwap {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan *PendingSwap, feedBuffer)
+	m.subscribers = append(m.subscribers, ch)
+	return ch
+}
+
+// Start begins the subscription loop
+func (m *Monitor) Start() {
+	log.Info("Starting mempool monitor...")
+	go m.run()
+}
+
+// Stop stops the subscription loop and closes all subscriber channels
+func (m *Monitor) Stop() {
+	log.Info("Stopping mempool monitor...")
+	m.cancel()
+}
+
+// run keeps a pending transaction subscription alive until stopped
+func (m *Monitor) run() {
+	defer m.closeSubscribers()
+
+	delay := retryDelay
+	for {
+		err := m.listen()
+		if m.ctx.Err() != nil {
+			log.Info("Mempool monitor stopped")
+			return
+		}
+
+		log.Warnf("Mempool subscription lost: %v, resubscribing in %v", err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-m.ctx.Done():
+			timer.Stop()
+			log.Info("Mempool monitor stopped")
+			return
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+// listen subscribes to full pending transactions and handles them until the
+// subscription fails
+func (m *Monitor) listen() error {
+	wssClient := m.client.GetWSSClient()
+	if wssClient == nil {
+		return fmt.Errorf("WSS client not connected")
+	}
+
+	messages := make(chan json.RawMessage, feedBuffer)
+	sub, err := wssClient.Client().EthSubscribe(m.ctx, messages, "newPendingTransactions", true)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to pending transactions: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	log.Info("Subscribed to pending transactions")
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return m.ctx.Err()
+
+		case err := <-sub.Err():
+			return fmt.Errorf("subscription error: %w", err)
+
+		case message := <-messages:
+			m.handleMessage(message)
+		}
+	}
+}
+
+// handleMessage decodes one subscription message
+func (m *Monitor) handleMessage(message json.RawMessage) {
+	// Nodes without full-transaction support send bare hashes
+	if len(message) > 0 && message[0] == '"' {
+		m.mu.Lock()
+		warn := !m.hashOnly
+		m.hashOnly = true
+		m.mu.Unlock()
+		if warn {
+			log.Warn("⚠️  RPC node sends pending transaction hashes only; use a node that supports full pending transactions")
+		}
+		return
+	}
+
+	var pending rpcTransaction
+	if err := json.Unmarshal(message, &pending); err != nil {
+		log.Debugf("Skipping undecodable pending transaction: %v", err)
+		return
+	}
+
+	swap, err := m.decoder.Decode(pending.tx, pending.from)
+	if err != nil {
+		if !errors.Is(err, ErrNotSwap) {
+			log.Debugf("Skipping pending transaction %s: %v", pending.tx.Hash().Hex(), err)
+		}
+		return
+	}
+
+	if m.add(swap) {
+		m.publish(swap)
+	}
+}
+
+// add tracks a swap, returning false if it is already known
+func (m *Monitor) add(swap *PendingSwap) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, known := m.pending[swap.Hash]; known {
+		return false
+	}
+	m.pending[swap.Hash] = swap
+	for _, pool := range swap.Pools {
+		if pool != (common.Address{}) {
+			m.byPool[pool]++
+		}
+	}
+
+	log.Debugf("Pending %s swap %s: %s via %d pools, gas price %s",
+		swap.DEX, swap.Hash.Hex()[:10], swap.Method, len(swap.Pools), swap.GasPrice)
+	return true
+}
+
+// remove forgets a swap; called with mu held
+func (m *Monitor) remove(swap *PendingSwap) {
+	delete(m.pending, swap.Hash)
+	for _, pool := range swap.Pools {
+		if pool == (common.Address{}) {
+			continue
+		}
+		if m.byPool[pool]--; m.byPool[pool] <= 0 {
+			delete(m.byPool, pool)
+		}
+	}
+}
+
+// publish delivers a swap to all subscribers without blocking on slow readers
+func (m *Monitor) publish(swap *PendingSwap) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- swap:
+		default:
+			log.Warnf("Mempool subscriber is lagging, dropped swap %s", swap.Hash.Hex())
+		}
+	}
+}
+
+// closeSubscribers closes all subscriber channels
+func (m *Monitor) closeSubscribers() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, ch := range m.subscribers {
+		close(ch)
+	}
+	m.subscribers = nil
+}
+
+// HandleBlock drops the swaps mined in block, those whose deadline passed
+// and those older than maxAge (dropped or replaced transactions)
+func (m *Monitor) HandleBlock(block *types.Block) {
+	mined := make(map[common.Hash]bool, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		mined[tx.Hash()] = true
+	}
+	m.prune(mined, block.Time(), time.Now())
+}
+
+// prune removes mined, expired and stale swaps
+func (m *Monitor) prune(mined map[common.Hash]bool, blockTime uint64, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for hash, swap := range m.pending {
+		if mined[hash] || swap.Expired(blockTime) || (m.maxAge > 0 && now.Sub(swap.SeenAt) > m.maxAge) {
+			m.remove(swap)
+			removed++
+		}
+	}
+	if removed > 0 {
+		log.Debugf("Mempool: %d swaps resolved, %d pending", removed, len(m.pending))
+	}
+}
+
+// Pending returns the swaps still pending, highest gas price first (the
+// order a block builder would likely include them in)
+func (m *Monitor) Pending() []*PendingSwap {
+	m.mu.Lock()
+	swaps := make([]*PendingSwap, 0, len(m.pending))
+	for _, swap := range m.pending {
+		swaps = append(swaps, swap)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(swaps, func(i, j int) bool {
+		if c := swaps[i].GasPrice.Cmp(swaps[j].GasPrice); c != 0 {
+			return c > 0
+		}
+		return swaps[i].SeenAt.Before(swaps[j].SeenAt)
+	})
+	return swaps
+}
+
+// PendingSwaps returns the number of pending swaps trading through pool
+func (m *Monitor) PendingSwaps(pool common.Address) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.byPool[pool]
+}
+
+// Size returns the number of pending swaps tracked
+func (m *Monitor) Size() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.pending)
+}
@@ -0,0 +1,216 @@
+package pricing
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+const (
+	// maxPathHops bounds the pool path used to price a token against WETH
+	maxPathHops = 3
+
+	// maxLiquidity is the path liquidity of WETH itself
+	maxLiquidity = 1e300
+)
+
+// FallbackSource prices tokens the pools cannot, in wei per raw token unit
+type FallbackSource interface {
+	PriceWei(token common.Address) (*big.Float, error)
+}
+
+// quote is the price of a token and the liquidity of the path it came from
+type quote struct {
+	weiPerUnit float64 // Wei per raw token unit
+	liquidity  float64 // Smallest pool liquidity on the path, in wei
+	hops       int
+}
+
+// Service converts token amounts to ETH using spot prices along the
+// best-liquidity pool path to WETH in the current snapshot
+type Service struct {
+	weth         common.Address
+	minLiquidity float64 // Pools shallower than this (in wei, both sides) are not used
+	fallback     FallbackSource
+	quotes       map[common.Address]quote
+	blockNumber  uint64
+	mu           sync.RWMutex
+}
+
+// NewService creates a pricing service. fallback may be nil.
+func NewService(weth common.Address, minLiquidityWei *big.Int, fallback FallbackSource) *Service {
+	minLiquidity, _ := new(big.Float).SetInt(minLiquidityWei).Float64()
+	return &Service{
+		weth:         weth,
+		minLiquidity: minLiquidity,
+		fallback:     fallback,
+		quotes:       map[common.Address]quote{weth: {weiPerUnit: 1}},
+	}
+}
+
+// Update reprices all tokens from a snapshot of pools. Repeated calls for
+// the same block are no-ops; block 0 always reprices.
+func (s *Service) Update(blockNumber uint64, pools []*dex.Pool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if blockNumber != 0 && blockNumber == s.blockNumber {
+		return
+	}
+
+	s.quotes = s.bestQuotes(pools)
+	s.blockNumber = blockNumber
+
+	log.Debugf("Priced %d tokens against WETH at block %d", len(s.quotes), blockNumber)
+}
+
+// bestQuotes finds, for every token within maxPathHops of WETH, the path
+// whose shallowest pool is deepest (widest path) and prices the token
+// along it
+func (s *Service) bestQuotes(pools []*dex.Pool) map[common.Address]quote {
+	best := map[common.Address]quote{s.weth: {weiPerUnit: 1, liquidity: maxLiquidity}}
+
+	for hop := 1; hop <= maxPathHops; hop++ {
+		improved := false
+		next := make(map[common.Address]quote, len(best))
+		for token, q := range best {
+			next[token] = q
+		}
+
+		for _, pool := range pools {
+			for _, token := range []common.Address{pool.Token0, pool.Token1} {
+				known, exists := best[token]
+				if !exists || known.hops != hop-1 {
+					continue
+				}
+
+				reserveKnown, reserveOther, err := pool.GetReservesFor(token)
+				if err != nil || reserveKnown.Sign() <= 0 || reserveOther.Sign() <= 0 {
+					continue
+				}
+
+				knownUnits, _ := new(big.Float).SetInt(reserveKnown).Float64()
+				otherUnits, _ := new(big.Float).SetInt(reserveOther).Float64()
+
+				liquidity := 2 * knownUnits * known.weiPerUnit
+				if liquidity < s.minLiquidity {
+					continue
+				}
+				if liquidity > known.liquidity {
+					liquidity = known.liquidity
+				}
+
+				other := pool.Token1
+				if token == pool.Token1 {
+					other = pool.Token0
+				}
+				if current, exists := next[other]; exists && current.liquidity >= liquidity {
+					continue
+				}
+
+				next[other] = quote{
+					weiPerUnit: known.weiPerUnit * knownUnits / otherUnits,
+					liquidity:  liquidity,
+					hops:       hop,
+				}
+				improved = true
+			}
+		}
+
+		best = next
+		if !improved {
+			break
+		}
+	}
+
+	return best
+}
+
+// PriceWei returns the price of a token in wei per raw unit
+func (s *Service) PriceWei(token common.Address) (*big.Float, error) {
+	s.mu.RLock()
+	q, exists := s.quotes[token]
+	s.mu.RUnlock()
+
+	if exists {
+		return big.NewFloat(q.weiPerUnit), nil
+	}
+
+	if s.fallback != nil {
+		price, err := s.fallback.PriceWei(token)
+		if err == nil {
+			return price, nil
+		}
+		return nil, fmt.Errorf("no pool path to WETH and fallback failed for %s: %w", token.Hex(), err)
+	}
+
+	return nil, fmt.Errorf("no pool path to WETH for %s", token.Hex())
+}
+
+// ToWei converts a raw token amount to wei
+func (s *Service) ToWei(token common.Address, amount *big.Int) (*big.Int, error) {
+	if token == s.weth {
+		return new(big.Int).Set(amount), nil
+	}
+
+	price, err := s.PriceWei(token)
+	if err != nil {
+		return nil, err
+	}
+
+	wei, _ := new(big.Float).Mul(new(big.Float).SetInt(amount), price).Int(nil)
+	return wei, nil
+}
+
+// FromWei converts an amount of wei to raw token units
+func (s *Service) FromWei(token common.Address, wei *big.Int) (*big.Int, error) {
+	if token == s.weth {
+		return new(big.Int).Set(wei), nil
+	}
+
+	price, err := s.PriceWei(token)
+	if err != nil {
+		return nil, err
+	}
+	if price.Sign() <= 0 {
+		return nil, fmt.Errorf("token %s has no price", token.Hex())
+	}
+
+	amount, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), price).Int(nil)
+	return amount, nil
+}
+
+// StaticPrices is a fallback source of configured prices in ETH per whole
+// token, scaled by the token's decimals from the registry
+type StaticPrices struct {
+	prices map[common.Address]*big.Float
+	tokens *dex.TokenRegistry
+}
+
+// NewStaticPrices creates a fallback source from ETH-per-token prices
+func NewStaticPrices(prices map[common.Address]*big.Float, tokens *dex.TokenRegistry) *StaticPrices {
+	return &StaticPrices{prices: prices, tokens: tokens}
+}
+
+// PriceWei returns the configured price in wei per raw unit
+func (p *StaticPrices) PriceWei(token common.Address) (*big.Float, error) {
+	price, exists := p.prices[token]
+	if !exists {
+		return nil, fmt.Errorf("no configured price for %s", token.Hex())
+	}
+
+	decimals, err := p.tokens.Decimals(token)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := new(big.Int).Exp(config.BigInt10, big.NewInt(int64(decimals)), nil)
+	wei := new(big.Float).Mul(price, new(big.Float).SetInt(config.WeiPerEtherBigInt))
+	return wei.Quo(wei, new(big.Float).SetInt(scale)), nil
+}
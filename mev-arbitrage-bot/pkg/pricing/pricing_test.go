@@ -0,0 +1,101 @@
+package pricing
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+var (
+	weth = common.HexToAddress("0x01")
+	usdc = common.HexToAddress("0x02")
+	dai  = common.HexToAddress("0x03")
+	meme = common.HexToAddress("0x04")
+)
+
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+// pool creates a pool holding reserveA of tokenA and reserveB of tokenB
+func pool(tokenA, tokenB common.Address, reserveA, reserveB *big.Int) *dex.Pool {
+	token0, token1 := dex.SortTokens(tokenA, tokenB)
+	if token0 != tokenA {
+		reserveA, reserveB = reserveB, reserveA
+	}
+	return &dex.Pool{Token0: token0, Token1: token1, Reserve0: reserveA, Reserve1: reserveB, Fee: 30}
+}
+
+type staticFallback map[common.Address]*big.Float
+
+func (f staticFallback) PriceWei(token common.Address) (*big.Float, error) {
+	if price, ok := f[token]; ok {
+		return price, nil
+	}
+	return nil, fmt.Errorf("unknown token")
+}
+
+func TestServicePricesAlongWidestPath(t *testing.T) {
+	usdcUnits := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e6)) }
+
+	pools := []*dex.Pool{
+		// Deep WETH/USDC at 2000 USDC per ETH
+		pool(weth, usdc, ether(1000), usdcUnits(2_000_000)),
+		// Shallow WETH/DAI pool with a manipulated price of 5000 DAI per ETH
+		pool(weth, dai, ether(2), ether(10_000)),
+		// Deep USDC/DAI at parity: DAI should be priced through USDC
+		pool(usdc, dai, usdcUnits(1_000_000), ether(1_000_000)),
+		// Tiny pool below the liquidity threshold
+		pool(weth, meme, big.NewInt(1e17), ether(1_000_000)),
+	}
+
+	service := NewService(weth, ether(1), staticFallback{})
+	service.Update(1, pools)
+
+	// 4000 USDC = 2 ETH
+	wei, err := service.ToWei(usdc, usdcUnits(4000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wei.Cmp(ether(2)) != 0 {
+		t.Fatalf("4000 USDC = %s wei, want 2 ETH", wei)
+	}
+
+	// 2000 DAI = 1 ETH via USDC, not 0.4 ETH via the shallow pool
+	wei, err = service.ToWei(dai, ether(2000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := new(big.Int).Sub(wei, ether(1)); diff.CmpAbs(big.NewInt(1e9)) > 0 {
+		t.Fatalf("2000 DAI = %s wei, want 1 ETH", wei)
+	}
+
+	// Round trip
+	amount, err := service.FromWei(usdc, ether(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount.Cmp(usdcUnits(2000)) != 0 {
+		t.Fatalf("1 ETH = %s USDC units, want 2000e6", amount)
+	}
+
+	// Shallow pools are not used and there is no fallback price
+	if _, err := service.ToWei(meme, ether(1)); err == nil {
+		t.Fatal("priced token through pool below liquidity threshold")
+	}
+
+	// The fallback source is consulted when no pool path exists
+	service = NewService(weth, ether(1), staticFallback{meme: big.NewFloat(0.5)})
+	service.Update(1, pools)
+	wei, err = service.ToWei(meme, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wei.Cmp(big.NewInt(5)) != 0 {
+		t.Fatalf("fallback priced 10 units at %s wei, want 5", wei)
+	}
+}
@@ -0,0 +1,71 @@
+package risk
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// NewAdminHandler serves the risk manager's admin API:
+//
+//	GET  /risk        current status
+//	POST /risk/reset  close the circuit breaker
+//	POST /risk/trip   open the circuit breaker (optional ?reason=)
+//
+// Requests must carry "Authorization: Bearer <token>" when token is set.
+func NewAdminHandler(m *Manager, token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/risk", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeStatus(w, m)
+	})
+
+	mux.HandleFunc("/risk/reset", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Warnf("Circuit breaker reset via admin API from %s", r.RemoteAddr)
+		m.Reset()
+		writeStatus(w, m)
+	})
+
+	mux.HandleFunc("/risk/trip", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		reason := r.URL.Query().Get("reason")
+		if reason == "" {
+			reason = "manual trip via admin API"
+		}
+		m.Trip(reason)
+		writeStatus(w, m)
+	})
+
+	if token == "" {
+		return mux
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// writeStatus writes the manager's status as JSON
+func writeStatus(w http.ResponseWriter, m *Manager) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Status()); err != nil {
+		log.Debugf("Failed to write risk status: %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+package risk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrTripped is returned while the circuit breaker is open
+var ErrTripped = errors.New("circuit breaker tripped")
+
+// Limits are the safety rails enforced before and after every execution.
+// Zero or nil limits are disabled.
+type Limits struct {
+	MaxDailyLossWei       *big.Int                    // Realized loss per UTC day, gas included
+	MaxConsecutiveReverts int                         // Mined reverts in a row
+	MaxGasPerHourWei      *big.Int                    // Gas spent over the last hour, all transactions
+	ExposureCaps          map[common.Address]*big.Int // Raw token amount in flight per token
+	MinWalletBalanceWei   *big.Int                    // ETH the wallet must keep for gas
+}
+
+// Status is the persisted state of the risk manager
+type Status struct {
+	Tripped            bool      `json:"tripped"`
+	Reason             string    `json:"reason,omitempty"`
+	TrippedAt          time.Time `json:"tripped_at,omitempty"`
+	Day                string    `json:"day"`                         // UTC day of DailyPnLWei
+	DailyPnLWei        *big.Int  `json:"daily_pnl_wei"`               // Realized profit today (negative = loss)
+	ConsecutiveReverts int       `json:"consecutive_reverts"`         // Mined reverts since the last success
+	GasLastHourWei     *big.Int  `json:"gas_last_hour_wei,omitempty"` // Not persisted; rebuilt after restart
+}
+
+// gasSample is the gas cost of one mined transaction
+type gasSample struct {
+	at   time.Time
+	cost *big.Int
+}
+
+// Manager enforces risk limits and trips a circuit breaker that forces
+// dry-run mode until it is reset manually. The breaker state and daily
+// P&L are persisted so a restart does not re-arm the bot.
+type Manager struct {
+	limits   Limits
+	path     string // State file ("" = memory only)
+	status   Status
+	gas      []gasSample
+	exposure map[common.Address]*big.Int
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+// NewManager creates a risk manager, restoring its state from path if it
+// exists
+func NewManager(limits Limits, path string) (*Manager, error) {
+	m := &Manager{
+		limits:   limits,
+		path:     path,
+		exposure: make(map[common.Address]*big.Int),
+		now:      time.Now,
+	}
+	m.status.DailyPnLWei = big.NewInt(0)
+
+	status, err := LoadStatus(path)
+	if err != nil {
+		return nil, err
+	}
+	if status != nil {
+		m.status = *status
+		if m.status.Tripped {
+			log.Warnf("🚨 Circuit breaker is tripped since %s: %s", m.status.TrippedAt.Format(time.RFC3339), m.status.Reason)
+		}
+	}
+	return m, nil
+}
+
+// LoadStatus reads a persisted state file (nil if it does not exist)
+func LoadStatus(path string) (*Status, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk state: %w", err)
+	}
+
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to decode risk state: %w", err)
+	}
+	if status.DailyPnLWei == nil {
+		status.DailyPnLWei = big.NewInt(0)
+	}
+	return &status, nil
+}
+
+// SaveStatus writes a state file atomically
+func SaveStatus(path string, status *Status) error {
+	if path == "" {
+		return nil
+	}
+
+	persisted := *status
+	persisted.GasLastHourWei = nil
+	data, err := json.MarshalIndent(&persisted, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode risk state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create risk state directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write risk state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace risk state: %w", err)
+	}
+	return nil
+}
+
+// save persists the state; called with mu held
+func (m *Manager) save() {
+	if err := SaveStatus(m.path, &m.status); err != nil {
+		log.Errorf("Failed to persist risk state: %v", err)
+	}
+}
+
+// trip opens the circuit breaker; called with mu held
+func (m *Manager) trip(reason string) {
+	if m.status.Tripped {
+		return
+	}
+	m.status.Tripped = true
+	m.status.Reason = reason
+	m.status.TrippedAt = m.now().UTC()
+	log.Errorf("🚨 Circuit breaker tripped: %s. Forcing dry-run until reset", reason)
+}
+
+// rollDay starts a new daily P&L at UTC midnight; called with mu held
+func (m *Manager) rollDay() {
+	day := m.now().UTC().Format("2006-01-02")
+	if m.status.Day != day {
+		m.status.Day = day
+		m.status.DailyPnLWei = big.NewInt(0)
+	}
+}
+
+// gasLastHour drops samples older than an hour and sums the rest; called
+// with mu held
+func (m *Manager) gasLastHour() *big.Int {
+	cutoff := m.now().Add(-time.Hour)
+	kept := m.gas[:0]
+	total := big.NewInt(0)
+	for _, sample := range m.gas {
+		if sample.at.After(cutoff) {
+			kept = append(kept, sample)
+			total.Add(total, sample.cost)
+		}
+	}
+	m.gas = kept
+	return total
+}
+
+// Tripped reports whether the circuit breaker is open
+func (m *Manager) Tripped() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status.Tripped
+}
+
+// Trip opens the circuit breaker manually
+func (m *Manager) Trip(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.trip(reason)
+	m.save()
+}
+
+// Reset closes the circuit breaker and clears the revert streak
+func (m *Manager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status.Tripped {
+		log.Warnf("Circuit breaker reset (was: %s)", m.status.Reason)
+	}
+	m.status.Tripped = false
+	m.status.Reason = ""
+	m.status.TrippedAt = time.Time{}
+	m.status.ConsecutiveReverts = 0
+	m.save()
+}
+
+// Status returns a snapshot of the manager's state
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollDay()
+	status := m.status
+	status.DailyPnLWei = new(big.Int).Set(m.status.DailyPnLWei)
+	status.GasLastHourWei = m.gasLastHour()
+	return status
+}
+
+// CheckWallet trips the breaker if the wallet balance is below the minimum
+func (m *Manager) CheckWallet(balanceWei *big.Int) {
+	if m.limits.MinWalletBalanceWei == nil || m.limits.MinWalletBalanceWei.Sign() == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if balanceWei.Cmp(m.limits.MinWalletBalanceWei) < 0 && !m.status.Tripped {
+		m.trip(fmt.Sprintf("wallet balance %s wei below minimum %s wei", balanceWei, m.limits.MinWalletBalanceWei))
+		m.save()
+	}
+}
+
+// Acquire reserves amount of token for a trade, failing if the breaker is
+// open or the token's exposure cap would be exceeded. Each successful
+// Acquire must be followed by Release.
+func (m *Manager) Acquire(token common.Address, amount *big.Int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status.Tripped {
+		return ErrTripped
+	}
+
+	current, exists := m.exposure[token]
+	if !exists {
+		current = big.NewInt(0)
+	}
+	next := new(big.Int).Add(current, amount)
+	if limit, capped := m.limits.ExposureCaps[token]; capped && next.Cmp(limit) > 0 {
+		return fmt.Errorf("exposure to %s would reach %s, cap %s", token.Hex(), next, limit)
+	}
+
+	m.exposure[token] = next
+	return nil
+}
+
+// Release returns an amount reserved by Acquire
+func (m *Manager) Release(token common.Address, amount *big.Int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, exists := m.exposure[token]
+	if !exists {
+		return
+	}
+	current.Sub(current, amount)
+	if current.Sign() <= 0 {
+		delete(m.exposure, token)
+	}
+}
+
+// RecordGas records the gas cost of a mined transaction that is not an
+// arbitrage (e.g. rebalancing); it counts towards the hourly gas limit and
+// the daily loss
+func (m *Manager) RecordGas(gasCostWei *big.Int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollDay()
+	m.status.DailyPnLWei.Sub(m.status.DailyPnLWei, gasCostWei)
+	m.addGas(gasCostWei)
+	m.checkLimits()
+	m.save()
+}
+
+// RecordTrade records a mined arbitrage: its gas cost and realized profit
+// after gas (negative for a loss)
+func (m *Manager) RecordTrade(profitWei, gasCostWei *big.Int, reverted bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollDay()
+	m.status.DailyPnLWei.Add(m.status.DailyPnLWei, profitWei)
+	m.addGas(gasCostWei)
+	if reverted {
+		m.status.ConsecutiveReverts++
+	} else {
+		m.status.ConsecutiveReverts = 0
+	}
+	m.checkLimits()
+	m.save()
+}
+
+// addGas adds a gas sample; called with mu held
+func (m *Manager) addGas(gasCostWei *big.Int) {
+	m.gas = append(m.gas, gasSample{at: m.now(), cost: new(big.Int).Set(gasCostWei)})
+}
+
+// checkLimits trips the breaker if a limit is exceeded; called with mu held
+func (m *Manager) checkLimits() {
+	if limit := m.limits.MaxDailyLossWei; limit != nil && limit.Sign() > 0 {
+		loss := new(big.Int).Neg(m.status.DailyPnLWei)
+		if loss.Cmp(limit) > 0 {
+			m.trip(fmt.Sprintf("daily loss %s wei exceeds limit %s wei", loss, limit))
+		}
+	}
+
+	if limit := m.limits.MaxConsecutiveReverts; limit > 0 && m.status.ConsecutiveReverts >= limit {
+		m.trip(fmt.Sprintf("%d consecutive reverts", m.status.ConsecutiveReverts))
+	}
+
+	if limit := m.limits.MaxGasPerHourWei; limit != nil && limit.Sign() > 0 {
+		if spent := m.gasLastHour(); spent.Cmp(limit) > 0 {
+			m.trip(fmt.Sprintf("gas spent in the last hour %s wei exceeds limit %s wei", spent, limit))
+		}
+	}
+}
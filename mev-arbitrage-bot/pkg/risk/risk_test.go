@@ -0,0 +1,133 @@
+package risk
+
+import (
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func newTestManager(t *testing.T, limits Limits, path string, now *time.Time) *Manager {
+	t.Helper()
+	m, err := NewManager(limits, path)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.now = func() time.Time { return *now }
+	return m
+}
+
+func TestManagerTripsOnLimits(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Consecutive reverts; a success resets the streak
+	m := newTestManager(t, Limits{MaxConsecutiveReverts: 2}, "", &now)
+	m.RecordTrade(big.NewInt(-1), big.NewInt(1), true)
+	m.RecordTrade(big.NewInt(5), big.NewInt(1), false)
+	m.RecordTrade(big.NewInt(-1), big.NewInt(1), true)
+	if m.Tripped() {
+		t.Fatal("tripped after a single revert in a row")
+	}
+	m.RecordTrade(big.NewInt(-1), big.NewInt(1), true)
+	if !m.Tripped() {
+		t.Fatal("not tripped after two reverts in a row")
+	}
+
+	// Daily loss, counted from UTC midnight
+	m = newTestManager(t, Limits{MaxDailyLossWei: big.NewInt(100)}, "", &now)
+	m.RecordTrade(big.NewInt(-80), big.NewInt(10), false)
+	now = now.Add(12 * time.Hour)
+	m.RecordGas(big.NewInt(30))
+	if m.Tripped() {
+		t.Fatal("loss from the previous day counted")
+	}
+	m.RecordGas(big.NewInt(80))
+	if !m.Tripped() {
+		t.Fatal("not tripped after exceeding the daily loss")
+	}
+
+	// Gas over a sliding hour
+	m = newTestManager(t, Limits{MaxGasPerHourWei: big.NewInt(100)}, "", &now)
+	m.RecordGas(big.NewInt(60))
+	now = now.Add(61 * time.Minute)
+	m.RecordGas(big.NewInt(60))
+	if m.Tripped() {
+		t.Fatal("gas older than an hour counted")
+	}
+	m.RecordGas(big.NewInt(60))
+	if !m.Tripped() {
+		t.Fatal("not tripped after exceeding the hourly gas")
+	}
+
+	// Wallet balance
+	m = newTestManager(t, Limits{MinWalletBalanceWei: big.NewInt(50)}, "", &now)
+	m.CheckWallet(big.NewInt(50))
+	if m.Tripped() {
+		t.Fatal("tripped at the minimum balance")
+	}
+	m.CheckWallet(big.NewInt(49))
+	if !m.Tripped() {
+		t.Fatal("not tripped below the minimum balance")
+	}
+}
+
+func TestManagerExposureCaps(t *testing.T) {
+	now := time.Now()
+	token := common.HexToAddress("0x01")
+	m := newTestManager(t, Limits{ExposureCaps: map[common.Address]*big.Int{token: big.NewInt(100)}}, "", &now)
+
+	if err := m.Acquire(token, big.NewInt(60)); err != nil {
+		t.Fatalf("first reservation refused: %v", err)
+	}
+	if err := m.Acquire(token, big.NewInt(50)); err == nil {
+		t.Fatal("reservation above the cap accepted")
+	}
+	m.Release(token, big.NewInt(60))
+	if err := m.Acquire(token, big.NewInt(100)); err != nil {
+		t.Fatalf("reservation refused after release: %v", err)
+	}
+
+	// Uncapped tokens are unlimited; nothing is acquired while tripped
+	if err := m.Acquire(common.HexToAddress("0x02"), big.NewInt(1e18)); err != nil {
+		t.Fatalf("uncapped token refused: %v", err)
+	}
+	m.Trip("test")
+	if err := m.Acquire(common.HexToAddress("0x02"), big.NewInt(1)); !errors.Is(err, ErrTripped) {
+		t.Fatalf("expected ErrTripped, got %v", err)
+	}
+}
+
+func TestManagerPersistsBreaker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "risk", "state.json")
+	limits := Limits{MaxConsecutiveReverts: 1}
+
+	m := newTestManager(t, limits, path, &now)
+	m.RecordTrade(big.NewInt(-7), big.NewInt(7), true)
+	if !m.Tripped() {
+		t.Fatal("not tripped")
+	}
+
+	// A restart keeps the breaker open and the daily P&L
+	restarted := newTestManager(t, limits, path, &now)
+	status := restarted.Status()
+	if !status.Tripped || status.DailyPnLWei.Int64() != -7 {
+		t.Fatalf("state not restored: %+v", status)
+	}
+	if status.GasLastHourWei.Sign() != 0 {
+		t.Fatal("gas window restored from disk")
+	}
+
+	// Resetting clears the breaker and the revert streak only
+	restarted.Reset()
+	saved, err := LoadStatus(path)
+	if err != nil {
+		t.Fatalf("LoadStatus: %v", err)
+	}
+	if saved.Tripped || saved.ConsecutiveReverts != 0 || saved.DailyPnLWei.Int64() != -7 {
+		t.Fatalf("unexpected state after reset: %+v", saved)
+	}
+}
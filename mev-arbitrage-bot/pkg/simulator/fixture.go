@@ -0,0 +1,188 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Fixture is a recorded slice of chain state at a pinned block: the fork
+// block header and every account and storage slot a simulation read. A
+// fixture replays the simulation offline.
+type Fixture struct {
+	ChainID  *hexutil.Big                       `json:"chainId"`
+	Header   *types.Header                      `json:"header"`
+	Accounts map[common.Address]*FixtureAccount `json:"accounts"`
+}
+
+// FixtureAccount is a recorded account
+type FixtureAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   hexutil.Uint64              `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// NewFixture creates an empty fixture for a fork block
+func NewFixture(chainID *big.Int, header *types.Header) *Fixture {
+	return &Fixture{
+		ChainID:  (*hexutil.Big)(new(big.Int).Set(chainID)),
+		Header:   types.CopyHeader(header),
+		Accounts: make(map[common.Address]*FixtureAccount),
+	}
+}
+
+// LoadFixture reads a fixture from a JSON file
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	if fixture.ChainID == nil || fixture.Header == nil {
+		return nil, fmt.Errorf("fixture %s has no chain ID or header", path)
+	}
+	if fixture.Accounts == nil {
+		fixture.Accounts = make(map[common.Address]*FixtureAccount)
+	}
+	return &fixture, nil
+}
+
+// Save writes the fixture to a JSON file
+func (f *Fixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create fixture directory: %w", err)
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// SetAccount records an account, replacing its balance, nonce and code
+func (f *Fixture) SetAccount(address common.Address, balance *big.Int, nonce uint64, code []byte) *FixtureAccount {
+	acct := f.fixtureAccount(address)
+	acct.Balance = (*hexutil.Big)(new(big.Int).Set(balance))
+	acct.Nonce = hexutil.Uint64(nonce)
+	acct.Code = common.CopyBytes(code)
+	return acct
+}
+
+// SetStorage records a storage slot
+func (f *Fixture) SetStorage(address common.Address, slot, value common.Hash) {
+	acct := f.fixtureAccount(address)
+	if acct.Storage == nil {
+		acct.Storage = make(map[common.Hash]common.Hash)
+	}
+	acct.Storage[slot] = value
+}
+
+// fixtureAccount returns the recorded account, creating an empty one
+func (f *Fixture) fixtureAccount(address common.Address) *FixtureAccount {
+	acct, exists := f.Accounts[address]
+	if !exists {
+		acct = &FixtureAccount{Balance: (*hexutil.Big)(new(big.Int))}
+		f.Accounts[address] = acct
+	}
+	return acct
+}
+
+// Session creates a simulation session on top of the fixture
+func (f *Fixture) Session() *Session {
+	return NewSession(FixtureSource{fixture: f}, f.Header, chainConfigFor(f.ChainID.ToInt()))
+}
+
+// FixtureSource serves state from a fixture. Accounts and slots that were
+// not recorded read as empty, as they would on chain if the recording
+// simulation never touched them.
+type FixtureSource struct {
+	fixture *Fixture
+}
+
+// Account returns the balance, nonce and code of an address
+func (s FixtureSource) Account(address common.Address) (*big.Int, uint64, []byte, error) {
+	acct, exists := s.fixture.Accounts[address]
+	if !exists {
+		return new(big.Int), 0, nil, nil
+	}
+
+	balance := new(big.Int)
+	if acct.Balance != nil {
+		balance.Set(acct.Balance.ToInt())
+	}
+	return balance, uint64(acct.Nonce), common.CopyBytes(acct.Code), nil
+}
+
+// Storage returns the value of a storage slot
+func (s FixtureSource) Storage(address common.Address, slot common.Hash) (common.Hash, error) {
+	if acct, exists := s.fixture.Accounts[address]; exists {
+		return acct.Storage[slot], nil
+	}
+	return common.Hash{}, nil
+}
+
+// Recorder wraps a state source and records everything read through it
+// into a fixture
+type Recorder struct {
+	source  StateSource
+	fixture *Fixture
+	mu      sync.Mutex
+}
+
+// NewRecorder creates a recorder for state of the given fork block
+func NewRecorder(source StateSource, chainID *big.Int, header *types.Header) *Recorder {
+	return &Recorder{
+		source:  source,
+		fixture: NewFixture(chainID, header),
+	}
+}
+
+// Fixture returns the state recorded so far
+func (r *Recorder) Fixture() *Fixture {
+	return r.fixture
+}
+
+// Account returns the balance, nonce and code of an address
+func (r *Recorder) Account(address common.Address) (*big.Int, uint64, []byte, error) {
+	balance, nonce, code, err := r.source.Account(address)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	acct := r.fixture.fixtureAccount(address)
+	acct.Balance = (*hexutil.Big)(new(big.Int).Set(balance))
+	acct.Nonce = hexutil.Uint64(nonce)
+	acct.Code = common.CopyBytes(code)
+
+	return balance, nonce, code, nil
+}
+
+// Storage returns the value of a storage slot
+func (r *Recorder) Storage(address common.Address, slot common.Hash) (common.Hash, error) {
+	value, err := r.source.Storage(address, slot)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.SetStorage(address, slot, value)
+
+	return value, nil
+}
@@ -0,0 +1,252 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// DefaultCallGas is the gas limit of a simulated call
+const DefaultCallGas = 30_000_000
+
+// blockTime is the assumed slot time for the simulated next block
+const blockTime = 12
+
+// Simulator executes calls against a local fork of chain state
+type Simulator struct {
+	client      *ethclient.Client
+	chainConfig *params.ChainConfig
+}
+
+// CallResult is the outcome of a simulated call
+type CallResult struct {
+	ReturnData []byte
+	GasUsed    uint64
+	Logs       []*types.Log
+	Err        error // Execution error, e.g. vm.ErrExecutionReverted
+}
+
+// Reverted reports whether the call failed
+func (r *CallResult) Reverted() bool {
+	return r.Err != nil
+}
+
+// RevertReason decodes the Error(string) revert reason, if any
+func (r *CallResult) RevertReason() string {
+	if !errors.Is(r.Err, vm.ErrExecutionReverted) {
+		if r.Err != nil {
+			return r.Err.Error()
+		}
+		return ""
+	}
+
+	if reason, err := abi.UnpackRevert(r.ReturnData); err == nil {
+		return reason
+	}
+	return "execution reverted"
+}
+
+// NewSimulator creates a simulator for the given chain
+func NewSimulator(client *ethclient.Client, chainID *big.Int) *Simulator {
+	return &Simulator{
+		client:      client,
+		chainConfig: chainConfigFor(chainID),
+	}
+}
+
+// chainConfigFor returns the fork schedule of a known chain, defaulting to
+// all protocol changes enabled
+func chainConfigFor(chainID *big.Int) *params.ChainConfig {
+	for _, config := range []*params.ChainConfig{
+		params.MainnetChainConfig,
+		params.SepoliaChainConfig,
+		params.GoerliChainConfig,
+		params.HoleskyChainConfig,
+	} {
+		if config.ChainID.Cmp(chainID) == 0 {
+			return config
+		}
+	}
+
+	config := *params.TestChainConfig
+	config.ChainID = new(big.Int).Set(chainID)
+	return &config
+}
+
+// Fork creates a session on top of the state after blockNumber
+// (nil = latest block). Calls execute as if in the following block.
+func (s *Simulator) Fork(ctx context.Context, blockNumber *big.Int) (*Session, error) {
+	header, err := s.forkHeader(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSession(NewRPCSource(ctx, s.client, header.Number), header, s.chainConfig), nil
+}
+
+// Record creates a session like Fork whose remote reads are recorded; the
+// recorder's fixture replays the session's simulations offline
+func (s *Simulator) Record(ctx context.Context, blockNumber *big.Int) (*Session, *Recorder, error) {
+	header, err := s.forkHeader(ctx, blockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recorder := NewRecorder(NewRPCSource(ctx, s.client, header.Number), s.chainConfig.ChainID, header)
+	return NewSession(recorder, header, s.chainConfig), recorder, nil
+}
+
+// forkHeader fetches the header of the fork block (nil = latest block)
+func (s *Simulator) forkHeader(ctx context.Context, blockNumber *big.Int) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	header, err := s.client.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fork block: %w", err)
+	}
+	return header, nil
+}
+
+// Session is a mutable fork of chain state. Calls are applied in order and
+// see the effects of earlier calls. A session is not safe for concurrent use.
+type Session struct {
+	state       *ForkState
+	parent      *types.Header
+	chainConfig *params.ChainConfig
+	blockCtx    vm.BlockContext
+	rules       params.Rules
+	signer      types.Signer
+	gasPool     *core.GasPool // Gas left in the simulated block
+}
+
+// NewSession creates a session on top of a state source. parent is the
+// header of the fork block.
+func NewSession(source StateSource, parent *types.Header, chainConfig *params.ChainConfig) *Session {
+	number := new(big.Int).Add(parent.Number, common.Big1)
+	timestamp := parent.Time + blockTime
+
+	var random *common.Hash
+	if parent.Difficulty == nil || parent.Difficulty.Sign() == 0 {
+		mixDigest := parent.MixDigest
+		random = &mixDigest
+	}
+
+	// Transactions pay the base fee of the simulated block
+	baseFee := new(big.Int)
+	if parent.BaseFee != nil {
+		baseFee = eip1559.CalcBaseFee(chainConfig, parent)
+	}
+
+	parentHash := parent.Hash()
+	blockCtx := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash: func(n uint64) common.Hash {
+			if n == parent.Number.Uint64() {
+				return parentHash
+			}
+			return common.Hash{}
+		},
+		Coinbase:    parent.Coinbase,
+		GasLimit:    parent.GasLimit,
+		BlockNumber: number,
+		Time:        timestamp,
+		Difficulty:  new(big.Int),
+		BaseFee:     baseFee,
+		BlobBaseFee: new(big.Int),
+		Random:      random,
+	}
+
+	return &Session{
+		state:       NewForkState(source),
+		parent:      parent,
+		chainConfig: chainConfig,
+		blockCtx:    blockCtx,
+		rules:       chainConfig.Rules(number, random != nil, timestamp),
+		signer:      types.MakeSigner(chainConfig, number, timestamp),
+		gasPool:     new(core.GasPool).AddGas(parent.GasLimit),
+	}
+}
+
+// State returns the session's fork state
+func (s *Session) State() *ForkState {
+	return s.state
+}
+
+// BlockNumber returns the fork block number
+func (s *Session) BlockNumber() uint64 {
+	return s.parent.Number.Uint64()
+}
+
+// SetBalance overrides the ETH balance of an account
+func (s *Session) SetBalance(address common.Address, amount *big.Int) {
+	s.state.SetBalance(address, amount)
+	s.state.Finalise()
+}
+
+// Balance returns the ETH balance of an account
+func (s *Session) Balance(address common.Address) *big.Int {
+	return s.state.GetBalance(address)
+}
+
+// Call executes a message from an account and commits its state changes.
+// A reverted call leaves the state unchanged apart from the sender nonce.
+// The returned error is set only if remote state could not be loaded.
+func (s *Session) Call(from, to common.Address, input []byte, value *big.Int) (*CallResult, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	intrinsic, err := core.IntrinsicGas(input, nil, false, true, true, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute intrinsic gas: %w", err)
+	}
+	if intrinsic > DefaultCallGas {
+		return nil, fmt.Errorf("intrinsic gas %d exceeds call gas", intrinsic)
+	}
+
+	logStart := len(s.state.Logs())
+
+	s.state.Prepare(s.rules, from, s.blockCtx.Coinbase, &to, vm.ActivePrecompiles(s.rules), nil)
+	s.state.SetNonce(from, s.state.GetNonce(from)+1)
+
+	txCtx := vm.TxContext{
+		Origin:   from,
+		GasPrice: new(big.Int),
+	}
+	evm := vm.NewEVM(s.blockCtx, txCtx, s.state, s.chainConfig, vm.Config{NoBaseFee: true})
+
+	ret, leftOver, execErr := evm.Call(vm.AccountRef(from), to, input, DefaultCallGas-intrinsic, value)
+
+	gasUsed := DefaultCallGas - leftOver
+	refund := s.state.GetRefund()
+	if maxRefund := gasUsed / params.RefundQuotientEIP3529; refund > maxRefund {
+		refund = maxRefund
+	}
+	gasUsed -= refund
+
+	s.state.Finalise()
+
+	if err := s.state.Error(); err != nil {
+		return nil, fmt.Errorf("failed to load fork state: %w", err)
+	}
+
+	return &CallResult{
+		ReturnData: ret,
+		GasUsed:    gasUsed,
+		Logs:       s.state.Logs()[logStart:],
+		Err:        execErr,
+	}, nil
+}
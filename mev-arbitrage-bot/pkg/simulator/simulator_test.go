@@ -0,0 +1,163 @@
+package simulator
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	testChainID  = big.NewInt(1337)
+	testCoinbase = common.HexToAddress("0xc0ffee")
+	testToken    = common.HexToAddress("0x1000")
+	testReverter = common.HexToAddress("0x2000")
+	testReceiver = common.HexToAddress("0xbeef")
+)
+
+// transferCode emits Transfer(caller, testReceiver, 100) and stops
+func transferCode() []byte {
+	code := []byte{0x60, 0x64, 0x60, 0x00, 0x52} // MSTORE(0, 100)
+	code = append(code, 0x73)                    // PUSH20 receiver
+	code = append(code, testReceiver.Bytes()...)
+	code = append(code, 0x33, 0x7f) // CALLER, PUSH32 topic
+	code = append(code, transferEventSig.Bytes()...)
+	return append(code, 0x60, 0x20, 0x60, 0x00, 0xa3, 0x00) // LOG3(0, 32), STOP
+}
+
+// revertCode reverts with the 32-byte word 42
+var revertCode = []byte{0x60, 0x2a, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xfd}
+
+func testFixture(t *testing.T, sender common.Address) *Fixture {
+	t.Helper()
+
+	fixture := NewFixture(testChainID, &types.Header{
+		Number:     big.NewInt(100),
+		GasLimit:   30_000_000,
+		GasUsed:    15_000_000, // At target: the next base fee is unchanged
+		BaseFee:    big.NewInt(1e9),
+		Coinbase:   testCoinbase,
+		Difficulty: new(big.Int),
+		Time:       1_700_000_000,
+	})
+	fixture.SetAccount(sender, big.NewInt(1e18), 0, nil)
+	fixture.SetAccount(testToken, new(big.Int), 1, transferCode())
+	fixture.SetAccount(testReverter, new(big.Int), 1, revertCode)
+
+	// Saved and loaded like a recorded fixture
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := fixture.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFixture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func signTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, to common.Address) *types.Transaction {
+	t.Helper()
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(testChainID), &types.DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(2e9),
+		GasFeeCap: big.NewInt(10e9),
+		Gas:       100_000,
+		To:        &to,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestSimulateBundleFromFixture(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	fixture := testFixture(t, sender)
+
+	transfer := signTx(t, key, 0, testToken)
+	revert := signTx(t, key, 1, testReverter)
+
+	// The revert is not allowed: the bundle fails on it
+	result, err := fixture.Session().SimulateBundle([]*types.Transaction{transfer, revert}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success() || result.Failed.Hash != revert.Hash() {
+		t.Fatal("bundle with a reverting transaction succeeded")
+	}
+	if new(big.Int).SetBytes(result.Failed.ReturnData).Int64() != 42 {
+		t.Fatalf("revert data %x", result.Failed.ReturnData)
+	}
+
+	// Gas is paid at base fee + tip, the tip goes to the coinbase
+	price := big.NewInt(3e9)
+	if result.Results[0].GasPrice.Cmp(price) != 0 {
+		t.Fatalf("gas price %s, want %s", result.Results[0].GasPrice, price)
+	}
+	fees := new(big.Int).Mul(new(big.Int).SetUint64(result.GasUsed), price)
+	if result.BalanceDeltas[sender].Cmp(new(big.Int).Neg(fees)) != 0 {
+		t.Fatalf("sender balance changed by %s, want -%s", result.BalanceDeltas[sender], fees)
+	}
+	tips := new(big.Int).Mul(new(big.Int).SetUint64(result.GasUsed), big.NewInt(2e9))
+	if result.CoinbaseDiff.Cmp(tips) != 0 {
+		t.Fatalf("coinbase diff %s, want %s", result.CoinbaseDiff, tips)
+	}
+
+	// Token movements come from the Transfer log
+	if len(result.Logs) != 1 || result.Logs[0].TxHash != transfer.Hash() {
+		t.Fatalf("expected the transfer log, got %d logs", len(result.Logs))
+	}
+	deltas := result.TokenDeltas[testToken]
+	if deltas[sender].Int64() != -100 || deltas[testReceiver].Int64() != 100 {
+		t.Fatalf("token deltas %v", deltas)
+	}
+
+	// Allowed to revert: the bundle succeeds
+	result, err = fixture.Session().SimulateBundle([]*types.Transaction{transfer, revert}, []common.Hash{revert.Hash()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success() || len(result.Results) != 2 {
+		t.Fatal("bundle with an allowed revert failed")
+	}
+
+	// A nonce gap makes the transaction invalid
+	if _, err := fixture.Session().ApplyTransaction(revert); err == nil {
+		t.Fatal("applied a transaction with a nonce gap")
+	}
+}
+
+func TestRecorderReplaysOffline(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	source := testFixture(t, sender)
+
+	// Record a simulation reading through a source, then replay the recording
+	recorder := NewRecorder(FixtureSource{fixture: source}, testChainID, source.Header)
+	session := NewSession(recorder, source.Header, chainConfigFor(testChainID))
+
+	transfer := signTx(t, key, 0, testToken)
+	recorded, err := session.ApplyTransaction(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := recorder.Fixture().Accounts[testReverter]; exists {
+		t.Fatal("recorded an account the simulation never read")
+	}
+
+	replayed, err := recorder.Fixture().Session().ApplyTransaction(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.GasUsed != recorded.GasUsed || len(replayed.Logs) != len(recorded.Logs) || replayed.Reverted() {
+		t.Fatalf("replay differs: gas %d vs %d", replayed.GasUsed, recorded.GasUsed)
+	}
+}
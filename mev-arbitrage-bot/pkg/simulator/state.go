@@ -0,0 +1,508 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// StateSource provides account and storage data of a pinned block
+type StateSource interface {
+	// Account returns the balance, nonce and code of an address
+	Account(address common.Address) (*big.Int, uint64, []byte, error)
+
+	// Storage returns the value of a storage slot
+	Storage(address common.Address, slot common.Hash) (common.Hash, error)
+}
+
+// RPCSource fetches state lazily from an RPC node at a pinned block
+type RPCSource struct {
+	ctx         context.Context // Parent of every read (carries the RPC lane)
+	client      *ethclient.Client
+	blockNumber *big.Int
+}
+
+// NewRPCSource creates a state source reading from the given block. Reads
+// are made with ctx as their parent context.
+func NewRPCSource(ctx context.Context, client *ethclient.Client, blockNumber *big.Int) *RPCSource {
+	return &RPCSource{
+		ctx:         ctx,
+		client:      client,
+		blockNumber: new(big.Int).Set(blockNumber),
+	}
+}
+
+// Account returns the balance, nonce and code of an address
+func (s *RPCSource) Account(address common.Address) (*big.Int, uint64, []byte, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	balance, err := s.client.BalanceAt(ctx, address, s.blockNumber)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to fetch balance of %s: %w", address.Hex(), err)
+	}
+
+	nonce, err := s.client.NonceAt(ctx, address, s.blockNumber)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to fetch nonce of %s: %w", address.Hex(), err)
+	}
+
+	code, err := s.client.CodeAt(ctx, address, s.blockNumber)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to fetch code of %s: %w", address.Hex(), err)
+	}
+
+	return balance, nonce, code, nil
+}
+
+// Storage returns the value of a storage slot
+func (s *RPCSource) Storage(address common.Address, slot common.Hash) (common.Hash, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	value, err := s.client.StorageAt(ctx, address, slot, s.blockNumber)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to fetch storage %s of %s: %w", slot.Hex(), address.Hex(), err)
+	}
+
+	return common.BytesToHash(value), nil
+}
+
+// stateAccount is an account loaded into the fork state
+type stateAccount struct {
+	balance        *big.Int
+	nonce          uint64
+	code           []byte
+	codeHash       common.Hash
+	storage        map[common.Hash]common.Hash // Current values
+	committed      map[common.Hash]common.Hash // Values at the start of the transaction
+	created        bool                        // Created during the simulation (no remote storage)
+	createdInTx    bool                        // Created in the current transaction (EIP-6780)
+	selfDestructed bool
+}
+
+// ForkState is an in-memory vm.StateDB that lazily loads accounts and
+// storage from a StateSource and journals changes for snapshots
+type ForkState struct {
+	source      StateSource
+	accounts    map[common.Address]*stateAccount
+	initial     map[common.Address]*big.Int                    // Balances fetched from the source
+	original    map[common.Address]map[common.Hash]common.Hash // Slot values fetched from the source
+	transient   map[common.Address]map[common.Hash]common.Hash
+	accessAddrs map[common.Address]bool
+	accessSlots map[common.Address]map[common.Hash]bool
+	journal     []func()
+	refund      uint64
+	logs        []*types.Log
+	err         error // First source error; results are invalid when set
+}
+
+// NewForkState creates a fork state on top of a source
+func NewForkState(source StateSource) *ForkState {
+	return &ForkState{
+		source:      source,
+		accounts:    make(map[common.Address]*stateAccount),
+		initial:     make(map[common.Address]*big.Int),
+		original:    make(map[common.Address]map[common.Hash]common.Hash),
+		transient:   make(map[common.Address]map[common.Hash]common.Hash),
+		accessAddrs: make(map[common.Address]bool),
+		accessSlots: make(map[common.Address]map[common.Hash]bool),
+	}
+}
+
+// Error returns the first error encountered while loading remote state
+func (s *ForkState) Error() error {
+	return s.err
+}
+
+// Logs returns all logs emitted since the fork was created
+func (s *ForkState) Logs() []*types.Log {
+	return s.logs
+}
+
+// account returns the account, loading it from the source on first access
+func (s *ForkState) account(address common.Address) *stateAccount {
+	if acct, exists := s.accounts[address]; exists {
+		return acct
+	}
+
+	balance, nonce, code, err := s.source.Account(address)
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+		balance, nonce, code = new(big.Int), 0, nil
+	}
+
+	acct := &stateAccount{
+		balance:   balance,
+		nonce:     nonce,
+		code:      code,
+		codeHash:  codeHash(code),
+		storage:   make(map[common.Hash]common.Hash),
+		committed: make(map[common.Hash]common.Hash),
+	}
+	s.accounts[address] = acct
+	s.initial[address] = new(big.Int).Set(balance)
+	return acct
+}
+
+// balances returns the current balances of all loaded accounts
+func (s *ForkState) balances() map[common.Address]*big.Int {
+	balances := make(map[common.Address]*big.Int, len(s.accounts))
+	for address, acct := range s.accounts {
+		balances[address] = new(big.Int).Set(acct.balance)
+	}
+	return balances
+}
+
+// balanceDeltas returns the balance changes since start (a balances()
+// result); accounts loaded after start are compared to their fetched balance
+func (s *ForkState) balanceDeltas(start map[common.Address]*big.Int) map[common.Address]*big.Int {
+	deltas := make(map[common.Address]*big.Int)
+	for address, acct := range s.accounts {
+		before, exists := start[address]
+		if !exists {
+			before = s.initial[address]
+		}
+		if delta := new(big.Int).Sub(acct.balance, before); delta.Sign() != 0 {
+			deltas[address] = delta
+		}
+	}
+	return deltas
+}
+
+// originalState returns a slot value as of the fork block
+func (s *ForkState) originalState(address common.Address, slot common.Hash) common.Hash {
+	slots, exists := s.original[address]
+	if !exists {
+		slots = make(map[common.Hash]common.Hash)
+		s.original[address] = slots
+	}
+
+	if value, exists := slots[slot]; exists {
+		return value
+	}
+
+	value, err := s.source.Storage(address, slot)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	slots[slot] = value
+	return value
+}
+
+// codeHash returns the keccak256 hash of code (EmptyCodeHash for no code)
+func codeHash(code []byte) common.Hash {
+	if len(code) == 0 {
+		return types.EmptyCodeHash
+	}
+	return crypto.Keccak256Hash(code)
+}
+
+// CreateAccount creates a fresh account, carrying over any existing balance
+func (s *ForkState) CreateAccount(address common.Address) {
+	prev, existed := s.accounts[address]
+	balance := new(big.Int)
+	if existed {
+		balance.Set(prev.balance)
+	} else {
+		balance.Set(s.account(address).balance)
+		prev = s.accounts[address]
+	}
+
+	s.accounts[address] = &stateAccount{
+		balance:     balance,
+		codeHash:    types.EmptyCodeHash,
+		storage:     make(map[common.Hash]common.Hash),
+		committed:   make(map[common.Hash]common.Hash),
+		created:     true,
+		createdInTx: true,
+	}
+	s.journal = append(s.journal, func() { s.accounts[address] = prev })
+}
+
+// SubBalance subtracts amount from an account
+func (s *ForkState) SubBalance(address common.Address, amount *big.Int) {
+	acct := s.account(address)
+	prev := new(big.Int).Set(acct.balance)
+	acct.balance = new(big.Int).Sub(acct.balance, amount)
+	s.journal = append(s.journal, func() { acct.balance = prev })
+}
+
+// AddBalance adds amount to an account
+func (s *ForkState) AddBalance(address common.Address, amount *big.Int) {
+	acct := s.account(address)
+	prev := new(big.Int).Set(acct.balance)
+	acct.balance = new(big.Int).Add(acct.balance, amount)
+	s.journal = append(s.journal, func() { acct.balance = prev })
+}
+
+// SetBalance overrides an account balance
+func (s *ForkState) SetBalance(address common.Address, amount *big.Int) {
+	acct := s.account(address)
+	prev := acct.balance
+	acct.balance = new(big.Int).Set(amount)
+	s.journal = append(s.journal, func() { acct.balance = prev })
+}
+
+// GetBalance returns an account balance
+func (s *ForkState) GetBalance(address common.Address) *big.Int {
+	return new(big.Int).Set(s.account(address).balance)
+}
+
+// GetNonce returns an account nonce
+func (s *ForkState) GetNonce(address common.Address) uint64 {
+	return s.account(address).nonce
+}
+
+// SetNonce sets an account nonce
+func (s *ForkState) SetNonce(address common.Address, nonce uint64) {
+	acct := s.account(address)
+	prev := acct.nonce
+	acct.nonce = nonce
+	s.journal = append(s.journal, func() { acct.nonce = prev })
+}
+
+// GetCodeHash returns the code hash, or zero for non-existent accounts
+func (s *ForkState) GetCodeHash(address common.Address) common.Hash {
+	if !s.Exist(address) {
+		return common.Hash{}
+	}
+	return s.account(address).codeHash
+}
+
+// GetCode returns an account's code
+func (s *ForkState) GetCode(address common.Address) []byte {
+	return s.account(address).code
+}
+
+// SetCode sets an account's code
+func (s *ForkState) SetCode(address common.Address, code []byte) {
+	acct := s.account(address)
+	prevCode, prevHash := acct.code, acct.codeHash
+	acct.code = code
+	acct.codeHash = codeHash(code)
+	s.journal = append(s.journal, func() { acct.code, acct.codeHash = prevCode, prevHash })
+}
+
+// GetCodeSize returns the size of an account's code
+func (s *ForkState) GetCodeSize(address common.Address) int {
+	return len(s.account(address).code)
+}
+
+// AddRefund adds gas to the refund counter
+func (s *ForkState) AddRefund(gas uint64) {
+	prev := s.refund
+	s.refund += gas
+	s.journal = append(s.journal, func() { s.refund = prev })
+}
+
+// SubRefund removes gas from the refund counter
+func (s *ForkState) SubRefund(gas uint64) {
+	prev := s.refund
+	if gas > s.refund {
+		panic(fmt.Sprintf("refund counter below zero (gas: %d > refund: %d)", gas, s.refund))
+	}
+	s.refund -= gas
+	s.journal = append(s.journal, func() { s.refund = prev })
+}
+
+// GetRefund returns the refund counter
+func (s *ForkState) GetRefund() uint64 {
+	return s.refund
+}
+
+// GetCommittedState returns a slot value as of the start of the transaction
+func (s *ForkState) GetCommittedState(address common.Address, slot common.Hash) common.Hash {
+	acct := s.account(address)
+	if value, exists := acct.committed[slot]; exists {
+		return value
+	}
+	if acct.created {
+		return common.Hash{}
+	}
+	return s.originalState(address, slot)
+}
+
+// GetState returns the current value of a slot
+func (s *ForkState) GetState(address common.Address, slot common.Hash) common.Hash {
+	acct := s.account(address)
+	if value, exists := acct.storage[slot]; exists {
+		return value
+	}
+	return s.GetCommittedState(address, slot)
+}
+
+// SetState sets the value of a slot
+func (s *ForkState) SetState(address common.Address, slot, value common.Hash) {
+	acct := s.account(address)
+	prev, existed := acct.storage[slot]
+	acct.storage[slot] = value
+	s.journal = append(s.journal, func() {
+		if existed {
+			acct.storage[slot] = prev
+		} else {
+			delete(acct.storage, slot)
+		}
+	})
+}
+
+// GetTransientState returns a transient storage value (EIP-1153)
+func (s *ForkState) GetTransientState(address common.Address, key common.Hash) common.Hash {
+	return s.transient[address][key]
+}
+
+// SetTransientState sets a transient storage value (EIP-1153)
+func (s *ForkState) SetTransientState(address common.Address, key, value common.Hash) {
+	slots, exists := s.transient[address]
+	if !exists {
+		slots = make(map[common.Hash]common.Hash)
+		s.transient[address] = slots
+	}
+
+	prev := slots[key]
+	slots[key] = value
+	s.journal = append(s.journal, func() { slots[key] = prev })
+}
+
+// SelfDestruct marks an account as self-destructed and clears its balance
+func (s *ForkState) SelfDestruct(address common.Address) {
+	acct := s.account(address)
+	prevBalance, prevFlag := acct.balance, acct.selfDestructed
+	acct.selfDestructed = true
+	acct.balance = new(big.Int)
+	s.journal = append(s.journal, func() { acct.balance, acct.selfDestructed = prevBalance, prevFlag })
+}
+
+// HasSelfDestructed reports whether an account self-destructed
+func (s *ForkState) HasSelfDestructed(address common.Address) bool {
+	return s.account(address).selfDestructed
+}
+
+// Selfdestruct6780 self-destructs only accounts created in the same transaction
+func (s *ForkState) Selfdestruct6780(address common.Address) {
+	if s.account(address).createdInTx {
+		s.SelfDestruct(address)
+	}
+}
+
+// Exist reports whether an account exists
+func (s *ForkState) Exist(address common.Address) bool {
+	acct := s.account(address)
+	return acct.created || acct.selfDestructed || !s.Empty(address)
+}
+
+// Empty reports whether an account is empty per EIP-161
+func (s *ForkState) Empty(address common.Address) bool {
+	acct := s.account(address)
+	return acct.balance.Sign() == 0 && acct.nonce == 0 && len(acct.code) == 0
+}
+
+// AddressInAccessList reports whether an address is warm
+func (s *ForkState) AddressInAccessList(address common.Address) bool {
+	return s.accessAddrs[address]
+}
+
+// SlotInAccessList reports whether an address and slot are warm
+func (s *ForkState) SlotInAccessList(address common.Address, slot common.Hash) (bool, bool) {
+	return s.accessAddrs[address], s.accessSlots[address][slot]
+}
+
+// AddAddressToAccessList warms an address
+func (s *ForkState) AddAddressToAccessList(address common.Address) {
+	if s.accessAddrs[address] {
+		return
+	}
+	s.accessAddrs[address] = true
+	s.journal = append(s.journal, func() { delete(s.accessAddrs, address) })
+}
+
+// AddSlotToAccessList warms an address and slot
+func (s *ForkState) AddSlotToAccessList(address common.Address, slot common.Hash) {
+	s.AddAddressToAccessList(address)
+
+	slots, exists := s.accessSlots[address]
+	if !exists {
+		slots = make(map[common.Hash]bool)
+		s.accessSlots[address] = slots
+	}
+	if slots[slot] {
+		return
+	}
+	slots[slot] = true
+	s.journal = append(s.journal, func() { delete(slots, slot) })
+}
+
+// Prepare resets per-transaction state (access list, transient storage)
+func (s *ForkState) Prepare(rules params.Rules, sender, coinbase common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
+	s.accessAddrs = make(map[common.Address]bool)
+	s.accessSlots = make(map[common.Address]map[common.Hash]bool)
+	s.transient = make(map[common.Address]map[common.Hash]common.Hash)
+
+	if rules.IsBerlin {
+		s.accessAddrs[sender] = true
+		if dest != nil {
+			s.accessAddrs[*dest] = true
+		}
+		for _, address := range precompiles {
+			s.accessAddrs[address] = true
+		}
+		for _, entry := range txAccesses {
+			s.AddAddressToAccessList(entry.Address)
+			for _, key := range entry.StorageKeys {
+				s.AddSlotToAccessList(entry.Address, key)
+			}
+		}
+		if rules.IsShanghai {
+			s.accessAddrs[coinbase] = true
+		}
+	}
+}
+
+// Snapshot returns an identifier of the current state
+func (s *ForkState) Snapshot() int {
+	return len(s.journal)
+}
+
+// RevertToSnapshot undoes all changes made after the snapshot
+func (s *ForkState) RevertToSnapshot(id int) {
+	for len(s.journal) > id {
+		last := len(s.journal) - 1
+		s.journal[last]()
+		s.journal = s.journal[:last]
+	}
+}
+
+// AddLog records a log emitted by the EVM
+func (s *ForkState) AddLog(entry *types.Log) {
+	entry.Index = uint(len(s.logs))
+	s.logs = append(s.logs, entry)
+
+	count := len(s.logs) - 1
+	s.journal = append(s.journal, func() { s.logs = s.logs[:count] })
+}
+
+// AddPreimage is a no-op
+func (s *ForkState) AddPreimage(common.Hash, []byte) {}
+
+// Finalise ends a transaction: current storage becomes the committed state,
+// the journal and refund counter are cleared
+func (s *ForkState) Finalise() {
+	for _, acct := range s.accounts {
+		for slot, value := range acct.storage {
+			acct.committed[slot] = value
+		}
+		acct.createdInTx = false
+	}
+
+	s.journal = nil
+	s.refund = 0
+}
@@ -0,0 +1,155 @@
+package simulator
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// transferEventSig is the topic of the ERC20 Transfer event
+var transferEventSig = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// TxResult is the outcome of a simulated transaction. The embedded call
+// result holds the return data, which is the revert data if it reverted.
+type TxResult struct {
+	CallResult
+	Hash     common.Hash
+	From     common.Address
+	GasPrice *big.Int // Effective gas price paid per gas
+}
+
+// BundleResult is the outcome of a simulated bundle
+type BundleResult struct {
+	Results       []*TxResult
+	GasUsed       uint64
+	Logs          []*types.Log
+	BalanceDeltas map[common.Address]*big.Int                    // ETH balance changes
+	TokenDeltas   map[common.Address]map[common.Address]*big.Int // Token -> account -> change, from Transfer logs
+	CoinbaseDiff  *big.Int                                       // ETH paid to the block builder (tips and transfers)
+	Failed        *TxResult                                      // First transaction that reverted and was not allowed to
+}
+
+// Success reports whether every transaction succeeded or was allowed to revert
+func (r *BundleResult) Success() bool {
+	return r.Failed == nil
+}
+
+// ApplyTransaction executes a signed transaction in the simulated block and
+// commits its state changes. Gas is bought and paid like on chain, so the
+// sender needs a matching nonce and enough balance. The returned error is
+// set if the transaction is invalid (it could not be included) or remote
+// state could not be loaded; a revert is reported in the result.
+func (s *Session) ApplyTransaction(tx *types.Transaction) (*TxResult, error) {
+	msg, err := core.TransactionToMessage(tx, s.signer, s.blockCtx.BaseFee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender of %s: %w", tx.Hash().Hex(), err)
+	}
+
+	logStart := len(s.state.Logs())
+
+	s.state.Prepare(s.rules, msg.From, s.blockCtx.Coinbase, msg.To, vm.ActivePrecompiles(s.rules), msg.AccessList)
+	evm := vm.NewEVM(s.blockCtx, core.NewEVMTxContext(msg), s.state, s.chainConfig, vm.Config{})
+
+	result, err := core.ApplyMessage(evm, msg, s.gasPool)
+	if loadErr := s.state.Error(); loadErr != nil {
+		return nil, fmt.Errorf("failed to load fork state: %w", loadErr)
+	}
+	if err != nil {
+		s.state.RevertToSnapshot(0)
+		s.state.Finalise()
+		return nil, fmt.Errorf("transaction %s is invalid: %w", tx.Hash().Hex(), err)
+	}
+	s.state.Finalise()
+
+	logs := s.state.Logs()[logStart:]
+	for _, entry := range logs {
+		entry.TxHash = tx.Hash()
+		entry.BlockNumber = s.blockCtx.BlockNumber.Uint64()
+	}
+
+	return &TxResult{
+		CallResult: CallResult{
+			ReturnData: result.ReturnData,
+			GasUsed:    result.UsedGas,
+			Logs:       logs,
+			Err:        result.Err,
+		},
+		Hash:     tx.Hash(),
+		From:     msg.From,
+		GasPrice: msg.GasPrice,
+	}, nil
+}
+
+// SimulateBundle executes transactions in order, the way a builder includes
+// a bundle: a transaction that reverts fails the bundle unless its hash is
+// in reverting. Execution stops at the first failure or invalid transaction,
+// whose effects on the session are not undone; simulate each bundle on a
+// fresh session.
+func (s *Session) SimulateBundle(txs []*types.Transaction, reverting []common.Hash) (*BundleResult, error) {
+	allowed := make(map[common.Hash]bool, len(reverting))
+	for _, hash := range reverting {
+		allowed[hash] = true
+	}
+
+	coinbase := s.blockCtx.Coinbase
+	start := s.state.balances()
+	if _, exists := start[coinbase]; !exists {
+		start[coinbase] = s.state.GetBalance(coinbase)
+	}
+
+	bundle := &BundleResult{}
+	for _, tx := range txs {
+		result, err := s.ApplyTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		bundle.Results = append(bundle.Results, result)
+		bundle.GasUsed += result.GasUsed
+		bundle.Logs = append(bundle.Logs, result.Logs...)
+
+		if result.Reverted() && !allowed[tx.Hash()] {
+			bundle.Failed = result
+			break
+		}
+	}
+
+	bundle.BalanceDeltas = s.state.balanceDeltas(start)
+	bundle.TokenDeltas = tokenDeltas(bundle.Logs)
+	bundle.CoinbaseDiff = new(big.Int)
+	if delta, exists := bundle.BalanceDeltas[coinbase]; exists {
+		bundle.CoinbaseDiff.Set(delta)
+	}
+	return bundle, nil
+}
+
+// tokenDeltas sums ERC20 Transfer logs into per-account balance changes
+func tokenDeltas(logs []*types.Log) map[common.Address]map[common.Address]*big.Int {
+	deltas := make(map[common.Address]map[common.Address]*big.Int)
+	add := func(token, account common.Address, amount *big.Int) {
+		accounts, exists := deltas[token]
+		if !exists {
+			accounts = make(map[common.Address]*big.Int)
+			deltas[token] = accounts
+		}
+		if accounts[account] == nil {
+			accounts[account] = new(big.Int)
+		}
+		accounts[account].Add(accounts[account], amount)
+	}
+
+	for _, entry := range logs {
+		if len(entry.Topics) != 3 || entry.Topics[0] != transferEventSig || len(entry.Data) != 32 {
+			continue // Not an ERC20 transfer (ERC721 transfers index the token ID)
+		}
+		amount := new(big.Int).SetBytes(entry.Data)
+		add(entry.Address, common.BytesToAddress(entry.Topics[1].Bytes()), new(big.Int).Neg(amount))
+		add(entry.Address, common.BytesToAddress(entry.Topics[2].Bytes()), amount)
+	}
+	return deltas
+}
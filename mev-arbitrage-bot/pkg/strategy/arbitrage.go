@@ -1,8 +1,10 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"sort"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -11,15 +13,32 @@ import (
 
 	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/flashloan"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/pricing"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
 )
 
+// CapitalSource reports whether owned capital can fund a trade
+type CapitalSource interface {
+	CanFund(token common.Address, amount *big.Int) bool
+}
+
 // ArbitrageFinder finds arbitrage opportunities across multiple DEXs
 type ArbitrageFinder struct {
 	poolMonitor    *dex.PoolMonitor
 	minProfitBps   int
-	maxTradeAmount *big.Int
+	minProfitWei   *big.Int // Minimum net profit valued in ETH
+	maxTradeAmount *big.Int // In wei; converted to the start token by pricing
 	minTradeAmount *big.Int
+	maxHops        int                     // Longest cycle searched
+	startTokens    []common.Address        // Tokens cycles start from (empty = any)
+	tokenSafety    *dex.TokenSafetyChecker // Optional; excludes unsafe tokens and applies transfer taxes
+	gasModel       *GasModel
+	pricing        *pricing.Service
+	flashLoans     *flashloan.Selector // Optional; without it the Aave premium is assumed
+	executionMode  string              // How trades are funded (config.ExecutionMode*)
+	capital        CapitalSource       // Inventory for capital and auto modes
+	slippage       *SlippageModel
 }
 
 // NewArbitrageFinder creates a new arbitrage finder
@@ -27,27 +46,173 @@ func NewArbitrageFinder(poolMonitor *dex.PoolMonitor, cfg *config.Config) *Arbit
 	return &ArbitrageFinder{
 		poolMonitor:    poolMonitor,
 		minProfitBps:   cfg.MinProfitBps,
+		minProfitWei:   utils.EtherToWei(cfg.MinProfitETH),
 		maxTradeAmount: utils.EtherToWei(cfg.MaxTradeAmountETH),
 		minTradeAmount: utils.EtherToWei(cfg.MinTradeAmountETH),
+		maxHops:        cfg.MaxHops,
+		startTokens:    cfg.StartTokens,
+		gasModel:       NewGasModel(cfg.GasLimitMultiplier),
+		executionMode:  cfg.ExecutionMode,
+		slippage:       NewSlippageModel(SlippagePolicyFor(cfg.SlippagePolicy, cfg.SlippageMaxBps)),
+		pricing:        pricing.NewService(cfg.WETHAddress, utils.EtherToWei(cfg.PriceMinLiquidityETH), nil),
+	}
+}
+
+// SetPricing replaces the pricing service, e.g. with one that has a
+// fallback price source
+func (af *ArbitrageFinder) SetPricing(service *pricing.Service) {
+	af.pricing = service
+}
+
+// SetFlashLoans sets the flash-loan sources compared for each opportunity
+func (af *ArbitrageFinder) SetFlashLoans(selector *flashloan.Selector) {
+	af.flashLoans = selector
+}
+
+// SetCapital sets the inventory that funds trades in capital and auto modes
+func (af *ArbitrageFinder) SetCapital(capital CapitalSource) {
+	af.capital = capital
+}
+
+// useFlashLoan decides how a path is funded: owned capital when the mode
+// allows it and the inventory covers the start amount, a flash loan otherwise
+func (af *ArbitrageFinder) useFlashLoan(path *ArbitragePath) bool {
+	switch af.executionMode {
+	case config.ExecutionModeCapital:
+		return false
+	case config.ExecutionModeAuto:
+		return af.capital == nil || !af.capital.CanFund(path.StartToken, path.StartAmount)
+	default:
+		return true
+	}
+}
+
+// tradeBounds returns the trade amount bounds in units of a start token
+func (af *ArbitrageFinder) tradeBounds(token common.Address) (minAmount, maxAmount *big.Int, err error) {
+	if minAmount, err = af.pricing.FromWei(token, af.minTradeAmount); err != nil {
+		return nil, nil, err
+	}
+	if maxAmount, err = af.pricing.FromWei(token, af.maxTradeAmount); err != nil {
+		return nil, nil, err
+	}
+	return minAmount, maxAmount, nil
+}
+
+// Slippage returns the model deriving per-hop minimum outputs
+func (af *ArbitrageFinder) Slippage() *SlippageModel {
+	return af.slippage
+}
+
+// GasModel returns the gas model used for net-profit estimation
+func (af *ArbitrageFinder) GasModel() *GasModel {
+	return af.gasModel
+}
+
+// SetTokenSafety makes the finder skip pools with unsafe tokens and reduce
+// hop outputs by each token's measured transfer tax
+func (af *ArbitrageFinder) SetTokenSafety(checker *dex.TokenSafetyChecker) {
+	af.tokenSafety = checker
+}
+
+// usablePool reports whether both pool tokens passed the safety check
+func (af *ArbitrageFinder) usablePool(pool *dex.Pool) bool {
+	if af.tokenSafety == nil {
+		return true
+	}
+	return af.tokenSafety.IsSafe(pool.Token0) && af.tokenSafety.IsSafe(pool.Token1)
+}
+
+// usablePools returns the pools whose tokens are safe
+func (af *ArbitrageFinder) usablePools(all []*dex.Pool) []*dex.Pool {
+	pools := make([]*dex.Pool, 0, len(all))
+	for _, pool := range all {
+		if af.usablePool(pool) {
+			pools = append(pools, pool)
+		}
+	}
+	return pools
+}
+
+// taxBps returns the measured transfer tax of a token (0 without checker)
+func (af *ArbitrageFinder) taxBps(token common.Address) int {
+	if af.tokenSafety == nil {
+		return 0
+	}
+	return af.tokenSafety.EffectiveTaxBps(token)
+}
+
+// afterTax reduces a hop output by the transfer tax of the received token
+func (af *ArbitrageFinder) afterTax(token common.Address, amount *big.Int) *big.Int {
+	taxBps := af.taxBps(token)
+	if taxBps == 0 {
+		return amount
 	}
+
+	net := new(big.Int).Mul(amount, big.NewInt(int64(10000-taxBps)))
+	return net.Div(net, config.BigInt10000)
 }
 
-// FindTriangleArbitrage finds triangle arbitrage opportunities
+// FindArbitrage finds profitable cycles of 2..maxHops pools starting from
+// any of startTokens (all tokens if none are given)
 // Example: WETH -> USDC -> DAI -> WETH
-func (af *ArbitrageFinder) FindTriangleArbitrage(startToken common.Address) ([]*ArbitragePath, error) {
+func (af *ArbitrageFinder) FindArbitrage(startTokens ...common.Address) ([]*ArbitragePath, error) {
 	pools := af.poolMonitor.GetAllPools()
-	if len(pools) < 3 {
-		return nil, fmt.Errorf("insufficient pools: need at least 3, got %d", len(pools))
+	af.pricing.Update(0, pools)
+	return af.findInPools(context.Background(), pools, startTokens)
+}
+
+// Name returns the strategy name
+func (af *ArbitrageFinder) Name() string {
+	return "cycle"
+}
+
+// Evaluate implements Strategy: it searches the snapshot for cycles
+// starting from the configured start tokens
+func (af *ArbitrageFinder) Evaluate(ctx context.Context, snapshot *Snapshot) ([]*ArbitrageOpportunity, error) {
+	af.pricing.Update(snapshot.BlockNumber, snapshot.Pools)
+	af.slippage.Observe(snapshot.BlockNumber, snapshot.Pools)
+	paths, err := af.findInPools(ctx, snapshot.Pools, af.startTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	opportunities := make([]*ArbitrageOpportunity, 0, len(paths))
+	for _, path := range paths {
+		opportunities = append(opportunities, &ArbitrageOpportunity{
+			Path:         path,
+			IsExecutable: true,
+			Priority:     path.ProfitBps,
+		})
 	}
+	return opportunities, nil
+}
+
+// findInPools searches a pool snapshot for profitable cycles
+func (af *ArbitrageFinder) findInPools(ctx context.Context, all []*dex.Pool, startTokens []common.Address) ([]*ArbitragePath, error) {
+	pools := af.usablePools(all)
+	if len(pools) < 2 {
+		return nil, fmt.Errorf("insufficient pools: need at least 2, got %d", len(pools))
+	}
+
+	graph := NewTokenGraph(pools, af.taxBps)
+	cycles := graph.FindCycles(startTokens, af.maxHops)
 
 	opportunities := make([]*ArbitragePath, 0)
 
-	// Try different start amounts to find optimal trade size
-	startAmounts := af.generateStartAmounts()
+	// Trade each cycle at its profit-maximizing size
+	for _, cycle := range cycles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		startAmount := af.optimalAmount(cycle)
+		if startAmount == nil {
+			continue
+		}
 
-	for _, startAmount := range startAmounts {
-		paths := af.searchTrianglePaths(pools, startToken, startAmount)
-		opportunities = append(opportunities, paths...)
+		if path := af.evaluateCycle(cycle, startAmount); path != nil {
+			opportunities = append(opportunities, path)
+		}
 	}
 
 	// Filter by minimum profit
@@ -58,150 +223,177 @@ func (af *ArbitrageFinder) FindTriangleArbitrage(startToken common.Address) ([]*
 		}
 	}
 
-	log.Debugf("Found %d arbitrage opportunities (filtered from %d)",
-		len(filtered), len(opportunities))
+	log.Debugf("Found %d arbitrage opportunities (filtered from %d) in %d cycles over %d tokens",
+		len(filtered), len(opportunities), len(cycles), graph.TokenCount())
 
 	return filtered, nil
 }
 
-// searchTrianglePaths searches for 3-hop arbitrage paths
-func (af *ArbitrageFinder) searchTrianglePaths(pools []*dex.Pool, startToken common.Address, startAmount *big.Int) []*ArbitragePath {
-	paths := make([]*ArbitragePath, 0)
-
-	// First hop: startToken -> intermediateToken1
-	for i, pool1 := range pools {
-		var token1 common.Address
-		var amount1 *big.Int
-
-		if pool1.Token0 == startToken {
-			token1 = pool1.Token1
-			amount1 = utils.CalculateAmountOut(startAmount, pool1.Reserve0, pool1.Reserve1, pool1.Fee)
-		} else if pool1.Token1 == startToken {
-			token1 = pool1.Token0
-			amount1 = utils.CalculateAmountOut(startAmount, pool1.Reserve1, pool1.Reserve0, pool1.Fee)
-		} else {
-			continue
+// simulateCycle returns the output of trading amountIn through a cycle
+// with exact pool math, or nil if a hop yields nothing
+func (af *ArbitrageFinder) simulateCycle(cycle *Cycle, amountIn *big.Int) *big.Int {
+	amount := amountIn
+	for i, pool := range cycle.Pools {
+		reserveIn, reserveOut, err := pool.GetReservesFor(cycle.Tokens[i])
+		if err != nil {
+			return nil
 		}
 
-		if amount1.Cmp(config.BigInt0) <= 0 {
-			continue
+		amount = utils.CalculateAmountOut(amount, reserveIn, reserveOut, pool.Fee)
+		amount = af.afterTax(cycle.Tokens[i+1], amount)
+
+		if amount.Cmp(config.BigInt0) <= 0 {
+			return nil
 		}
+	}
+
+	return amount
+}
 
-		// Second hop: intermediateToken1 -> intermediateToken2
-		for j, pool2 := range pools {
-			if i == j {
-				continue
-			}
-
-			var token2 common.Address
-			var amount2 *big.Int
-
-			if pool2.Token0 == token1 {
-				token2 = pool2.Token1
-				amount2 = utils.CalculateAmountOut(amount1, pool2.Reserve0, pool2.Reserve1, pool2.Fee)
-			} else if pool2.Token1 == token1 {
-				token2 = pool2.Token0
-				amount2 = utils.CalculateAmountOut(amount1, pool2.Reserve1, pool2.Reserve0, pool2.Fee)
-			} else {
-				continue
-			}
-
-			if amount2.Cmp(config.BigInt0) <= 0 {
-				continue
-			}
-
-			// Third hop: intermediateToken2 -> startToken (complete the loop)
-			for k, pool3 := range pools {
-				if k == i || k == j {
-					continue
-				}
-
-				var finalToken common.Address
-				var finalAmount *big.Int
-
-				if pool3.Token0 == token2 && pool3.Token1 == startToken {
-					finalToken = pool3.Token1
-					finalAmount = utils.CalculateAmountOut(amount2, pool3.Reserve0, pool3.Reserve1, pool3.Fee)
-				} else if pool3.Token1 == token2 && pool3.Token0 == startToken {
-					finalToken = pool3.Token0
-					finalAmount = utils.CalculateAmountOut(amount2, pool3.Reserve1, pool3.Reserve0, pool3.Fee)
-				} else {
-					continue
-				}
-
-				if finalToken != startToken {
-					continue
-				}
-
-				// Calculate profit
-				profit := new(big.Int).Sub(finalAmount, startAmount)
-				if profit.Cmp(config.BigInt0) <= 0 {
-					continue
-				}
-
-				profitBps := utils.CalculateProfit(startAmount, finalAmount)
-
-				// Create arbitrage path
-				path := &ArbitragePath{
-					ID:          uuid.New().String(),
-					Pools:       []*dex.Pool{pool1, pool2, pool3},
-					Tokens:      []common.Address{startToken, token1, token2, startToken},
-					StartToken:  startToken,
-					StartAmount: new(big.Int).Set(startAmount),
-					EndAmount:   finalAmount,
-					Profit:      profit,
-					ProfitBps:   profitBps,
-					ProfitETH:   utils.WeiToEther(profit),
-					Timestamp:   time.Now().Unix(),
-				}
-
-				paths = append(paths, path)
-			}
+// hopAmounts returns the quoted output of each hop of a path, before the
+// transfer tax of the received token (routers check amountOutMin against it)
+func (af *ArbitrageFinder) hopAmounts(path *ArbitragePath) []*big.Int {
+	amounts := make([]*big.Int, len(path.Pools))
+	amount := path.StartAmount
+	for i, pool := range path.Pools {
+		reserveIn, reserveOut, err := pool.GetReservesFor(path.Tokens[i])
+		if err != nil {
+			return nil
 		}
+
+		amounts[i] = utils.CalculateAmountOut(amount, reserveIn, reserveOut, pool.Fee)
+		amount = af.afterTax(path.Tokens[i+1], amounts[i])
+	}
+
+	return amounts
+}
+
+// setMinAmountsOut sets the per-hop minimum outputs of a path. It returns
+// false if the last hop cannot pay for gas and the loan fee.
+func (af *ArbitrageFinder) setMinAmountsOut(path *ArbitragePath) bool {
+	quoted := af.hopAmounts(path)
+	if quoted == nil {
+		return false
 	}
 
-	return paths
+	// Output at which the trade only pays back its start amount, gas and loan fee
+	breakEven := new(big.Int).Sub(path.EndAmount, path.NetProfit)
+	path.MinAmountsOut = af.slippage.MinAmountsOut(path.Pools, quoted, breakEven)
+
+	last := len(quoted) - 1
+	return path.MinAmountsOut[last].Cmp(quoted[last]) <= 0
 }
 
-// generateStartAmounts generates different start amounts to test
-func (af *ArbitrageFinder) generateStartAmounts() []*big.Int {
-	amounts := make([]*big.Int, 0)
+// evaluateCycle builds an arbitrage path for a cycle and start amount, or
+// returns nil if it is not profitable
+func (af *ArbitrageFinder) evaluateCycle(cycle *Cycle, startAmount *big.Int) *ArbitragePath {
+	finalAmount := af.simulateCycle(cycle, startAmount)
+	if finalAmount == nil {
+		return nil
+	}
 
-	// Start with min amount
-	current := new(big.Int).Set(af.minTradeAmount)
-	step := new(big.Int).Div(af.maxTradeAmount, big.NewInt(10)) // 10% steps
+	routers := make([]common.Address, len(cycle.Pools))
+	for i, pool := range cycle.Pools {
+		router, err := af.poolMonitor.GetRouter(pool.DEX)
+		if err != nil {
+			return nil
+		}
+		routers[i] = router
+	}
 
-	for current.Cmp(af.maxTradeAmount) <= 0 {
-		amounts = append(amounts, new(big.Int).Set(current))
-		current.Add(current, step)
+	// Calculate profit
+	profit := new(big.Int).Sub(finalAmount, startAmount)
+	if profit.Cmp(config.BigInt0) <= 0 {
+		return nil
 	}
 
-	return amounts
+	profitWei, err := af.pricing.ToWei(cycle.Tokens[0], profit)
+	if err != nil {
+		log.Debugf("Cannot value profit of cycle: %v", err)
+		return nil
+	}
+
+	return &ArbitragePath{
+		ID:          uuid.New().String(),
+		Pools:       cycle.Pools,
+		Routers:     routers,
+		Tokens:      cycle.Tokens,
+		StartToken:  cycle.Tokens[0],
+		StartAmount: new(big.Int).Set(startAmount),
+		EndAmount:   finalAmount,
+		Profit:      profit,
+		FlashLoan:   true,
+		ProfitBps:   utils.CalculateProfit(startAmount, finalAmount),
+		ProfitETH:   utils.WeiToEther(profitWei),
+		Timestamp:   time.Now().Unix(),
+	}
 }
 
 // EstimateGasCost estimates gas cost for an arbitrage path
 func (af *ArbitrageFinder) EstimateGasCost(path *ArbitragePath, gasPrice *big.Int) *big.Int {
-	// Estimate gas units based on number of swaps
-	// Each swap costs approximately 100,000 gas
-	// Plus base transaction cost of 21,000 gas
-	numSwaps := int64(len(path.Pools))
-	gasUnits := big.NewInt(21000 + numSwaps*100000)
+	return af.gasModel.EstimateCost(path, gasPrice)
+}
+
+// flashLoanFee selects the cheapest flash-loan source of a path and returns
+// its fee in start token units (nil if no source can lend the amount)
+func (af *ArbitrageFinder) flashLoanFee(ctx context.Context, path *ArbitragePath) *big.Int {
+	path.LoanSource = nil
+	if !path.FlashLoan {
+		return big.NewInt(0)
+	}
+
+	if af.flashLoans == nil {
+		fee := new(big.Int).Mul(path.StartAmount, big.NewInt(config.AaveFlashLoanFeeBps))
+		return fee.Div(fee, config.BigInt10000)
+	}
 
-	// Total gas cost = gas units * gas price
-	gasCost := new(big.Int).Mul(gasUnits, gasPrice)
+	// Pools of the route are locked during the trade and cannot lend
+	exclude := make([]common.Address, len(path.Pools))
+	for i, pool := range path.Pools {
+		exclude[i] = pool.Address
+	}
 
-	return gasCost
+	quote, err := af.flashLoans.Cheapest(ctx, path.StartToken, path.StartAmount, exclude)
+	if err != nil {
+		log.Debugf("No flash loan for %s: %v", path.ID[:8], err)
+		return nil
+	}
+	path.LoanSource = quote
+	return quote.Fee
 }
 
-// CalculateNetProfit calculates net profit after gas costs
-func (af *ArbitrageFinder) CalculateNetProfit(path *ArbitragePath, gasPrice *big.Int) {
+// CalculateNetProfit calculates net profit after gas costs and the
+// flash-loan fee; ctx bounds the flash-loan quotes
+func (af *ArbitrageFinder) CalculateNetProfit(ctx context.Context, path *ArbitragePath, gasPrice *big.Int) {
+	path.FlashLoan = af.useFlashLoan(path)
+	loanFee := af.flashLoanFee(ctx, path)
+	if loanFee == nil {
+		// Not executable; value the loan at the whole profit
+		loanFee = new(big.Int).Set(path.Profit)
+	}
+
+	path.GasEst = af.gasModel.Estimate(path)
 	gasCost := af.EstimateGasCost(path, gasPrice)
 	path.GasCostEst = gasCost
 
-	// Net profit = profit - gas cost
-	netProfit := new(big.Int).Sub(path.Profit, gasCost)
+	// Gas is paid in ETH; convert it to the start token
+	gasCostInToken, err := af.pricing.FromWei(path.StartToken, gasCost)
+	if err != nil {
+		log.Debugf("Cannot price gas in %s: %v", path.StartToken.Hex(), err)
+		gasCostInToken = new(big.Int).Set(path.Profit)
+	}
+
+	// Net profit = profit - gas cost - loan fee
+	netProfit := new(big.Int).Sub(path.Profit, gasCostInToken)
+	netProfit.Sub(netProfit, loanFee)
 	path.NetProfit = netProfit
 
+	netProfitWei, err := af.pricing.ToWei(path.StartToken, netProfit)
+	if err != nil {
+		netProfitWei = new(big.Int).Neg(gasCost)
+	}
+	path.NetProfitETH = utils.WeiToEther(netProfitWei)
+
 	// Calculate net profit in bps
 	if netProfit.Cmp(config.BigInt0) > 0 {
 		path.NetProfitBps = utils.CalculateProfit(path.StartAmount,
@@ -212,7 +404,7 @@ func (af *ArbitrageFinder) CalculateNetProfit(path *ArbitragePath, gasPrice *big
 }
 
 // ValidateOpportunity validates if an opportunity is executable
-func (af *ArbitrageFinder) ValidateOpportunity(path *ArbitragePath, gasPrice *big.Int) *ArbitrageOpportunity {
+func (af *ArbitrageFinder) ValidateOpportunity(ctx context.Context, path *ArbitragePath, gasPrice *big.Int) *ArbitrageOpportunity {
 	opportunity := &ArbitrageOpportunity{
 		Path:         path,
 		IsExecutable: true,
@@ -220,7 +412,19 @@ func (af *ArbitrageFinder) ValidateOpportunity(path *ArbitragePath, gasPrice *bi
 	}
 
 	// Calculate net profit
-	af.CalculateNetProfit(path, gasPrice)
+	af.CalculateNetProfit(ctx, path, gasPrice)
+
+	if !path.FlashLoan && (af.capital == nil || !af.capital.CanFund(path.StartToken, path.StartAmount)) {
+		opportunity.IsExecutable = false
+		opportunity.Reason = "insufficient inventory for capital mode"
+		return opportunity
+	}
+
+	if path.FlashLoan && af.flashLoans != nil && path.LoanSource == nil {
+		opportunity.IsExecutable = false
+		opportunity.Reason = "no flash-loan source can lend the start amount"
+		return opportunity
+	}
 
 	// Check if still profitable after gas
 	if path.NetProfit.Cmp(config.BigInt0) <= 0 {
@@ -237,31 +441,82 @@ func (af *ArbitrageFinder) ValidateOpportunity(path *ArbitragePath, gasPrice *bi
 		return opportunity
 	}
 
+	// Check absolute net profit threshold (in ETH)
+	minProfitETH := utils.WeiToEther(af.minProfitWei)
+	if path.NetProfitETH.Cmp(minProfitETH) < 0 {
+		opportunity.IsExecutable = false
+		opportunity.Reason = fmt.Sprintf("net profit %s ETH < minimum %s ETH",
+			path.NetProfitETH.Text('f', 6), minProfitETH.Text('f', 6))
+		return opportunity
+	}
+
 	// Check trade amount bounds
-	if path.StartAmount.Cmp(af.minTradeAmount) < 0 {
+	minAmount, maxAmount, err := af.tradeBounds(path.StartToken)
+	if err != nil {
+		opportunity.IsExecutable = false
+		opportunity.Reason = fmt.Sprintf("cannot price start token: %v", err)
+		return opportunity
+	}
+
+	if path.StartAmount.Cmp(minAmount) < 0 {
 		opportunity.IsExecutable = false
 		opportunity.Reason = "trade amount below minimum"
 		return opportunity
 	}
 
-	if path.StartAmount.Cmp(af.maxTradeAmount) > 0 {
+	if path.StartAmount.Cmp(maxAmount) > 0 {
 		opportunity.IsExecutable = false
 		opportunity.Reason = "trade amount above maximum"
 		return opportunity
 	}
 
+	// Per-hop minimum outputs, so the trade reverts rather than lose money
+	if !af.setMinAmountsOut(path) {
+		opportunity.IsExecutable = false
+		opportunity.Reason = "last hop cannot cover gas and loan fee"
+		return opportunity
+	}
+
 	// All checks passed
 	log.Infof("✅ Valid arbitrage opportunity: %s (Net Profit: %.4f%%, %s ETH)",
 		path.ID[:8], utils.BpsToPercentage(path.NetProfitBps),
-		path.ProfitETH.Text('f', 6))
+		path.NetProfitETH.Text('f', 6))
 
 	return opportunity
 }
 
+// ValidateOpportunities validates candidates against a live gas price and
+// returns only the executable ones, one per route, highest net profit first
+func (af *ArbitrageFinder) ValidateOpportunities(ctx context.Context, candidates []*ArbitrageOpportunity, gasPrice *big.Int) []*ArbitrageOpportunity {
+	executable := make([]*ArbitrageOpportunity, 0, len(candidates))
+	for _, candidate := range candidates {
+		opp := af.ValidateOpportunity(ctx, candidate.Path, gasPrice)
+		opp.Strategy = candidate.Strategy
+		opp.Backrun = candidate.Backrun
+		if !opp.IsExecutable {
+			log.Debugf("Rejected %s opportunity %s: %s", opp.Strategy, opp.Path.ID[:8], opp.Reason)
+			continue
+		}
+		executable = append(executable, opp)
+	}
+
+	// Keep the most profitable executable candidate of each route, so a
+	// rotation that fails validation does not displace one that passes
+	executable = collapseDuplicates(executable)
+
+	sort.SliceStable(executable, func(i, j int) bool {
+		return executable[i].Path.NetProfitETH.Cmp(executable[j].Path.NetProfitETH) > 0
+	})
+
+	log.Debugf("%d unique routes executable of %d candidates", len(executable), len(candidates))
+
+	return executable
+}
+
 // FindBestOpportunity finds the best arbitrage opportunity
 func (af *ArbitrageFinder) FindBestOpportunity(startToken common.Address, gasPrice *big.Int) (*ArbitrageOpportunity, error) {
 	// Find all opportunities
-	paths, err := af.FindTriangleArbitrage(startToken)
+	paths, err := af.FindArbitrage(startToken)
 	if err != nil {
 		return nil, err
 	}
@@ -271,23 +526,15 @@ func (af *ArbitrageFinder) FindBestOpportunity(startToken common.Address, gasPri
 	}
 
 	// Validate and find best
-	var bestOpp *ArbitrageOpportunity
-
+	candidates := make([]*ArbitrageOpportunity, 0, len(paths))
 	for _, path := range paths {
-		opp := af.ValidateOpportunity(path, gasPrice)
-
-		if !opp.IsExecutable {
-			continue
-		}
-
-		if bestOpp == nil || opp.Path.NetProfitBps > bestOpp.Path.NetProfitBps {
-			bestOpp = opp
-		}
+		candidates = append(candidates, &ArbitrageOpportunity{Path: path})
 	}
 
-	if bestOpp == nil {
+	executable := af.ValidateOpportunities(context.Background(), candidates, gasPrice)
+	if len(executable) == 0 {
 		return nil, fmt.Errorf("no executable opportunities found")
 	}
 
-	return bestOpp, nil
+	return executable[0], nil
 }
@@ -0,0 +1,127 @@
+package strategy
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// units returns n whole tokens of a token with the given decimals
+func units(n int64, decimals int) *big.Int {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	return scale.Mul(scale, big.NewInt(n))
+}
+
+// unitsPool creates a pool on dexType holding reserveA of tokenA and
+// reserveB of tokenB, in base units
+func unitsPool(id int, dexType dex.DEXType, tokenA, tokenB common.Address, reserveA, reserveB *big.Int) *dex.Pool {
+	pool := testPool(id, tokenA, tokenB, 1, 1)
+	pool.DEX = dexType
+	if pool.Token0 == tokenA {
+		pool.Reserve0, pool.Reserve1 = reserveA, reserveB
+	} else {
+		pool.Reserve0, pool.Reserve1 = reserveB, reserveA
+	}
+	return pool
+}
+
+// testFinder returns a flash-loan finder over pools with WETH as the
+// price reference
+func testFinder(weth common.Address, pools []*dex.Pool) *ArbitrageFinder {
+	cfg := &config.Config{
+		WETHAddress:          weth,
+		MinProfitBps:         1,
+		MinProfitETH:         big.NewFloat(0),
+		MinTradeAmountETH:    big.NewFloat(0.01),
+		MaxTradeAmountETH:    big.NewFloat(100),
+		MaxHops:              3,
+		GasLimitMultiplier:   1.25,
+		PriceMinLiquidityETH: big.NewFloat(0),
+		ExecutionMode:        config.ExecutionModeFlashLoan,
+		SlippagePolicy:       config.SlippagePolicyBalanced,
+	}
+	monitor := dex.NewPoolMonitor(nil, cfg)
+	monitor.RegisterRouter(dex.UniswapV2, common.HexToAddress("0xa1"))
+	monitor.RegisterRouter(dex.SushiSwap, common.HexToAddress("0xa2"))
+
+	finder := NewArbitrageFinder(monitor, cfg)
+	finder.pricing.Update(0, pools)
+	return finder
+}
+
+// testCandidate sizes a 2-hop cycle through pools and wraps it as a
+// candidate; a nil amount uses the optimal amount
+func testCandidate(t *testing.T, af *ArbitrageFinder, start common.Address, pools []*dex.Pool, amount *big.Int) *ArbitrageOpportunity {
+	t.Helper()
+	path := testPath(start, pools, true)
+	cycle := &Cycle{Pools: path.Pools, Tokens: path.Tokens}
+	if amount == nil {
+		amount = af.optimalAmount(cycle)
+	}
+	evaluated := af.evaluateCycle(cycle, amount)
+	if evaluated == nil {
+		t.Fatalf("cycle from %s not profitable", start.Hex())
+	}
+	return &ArbitrageOpportunity{Path: evaluated, Strategy: "cross_dex"}
+}
+
+func TestValidateOpportunitiesDedupsRejectsAndRanksByETH(t *testing.T) {
+	weth, usdc, dai := testToken(0), testToken(1), testToken(2)
+
+	// USDC has 6 decimals: its routes earn far fewer raw units per ETH
+	wethUSDC := unitsPool(0, dex.UniswapV2, weth, usdc, units(1000, 18), units(2_000_000, 6))
+	wethUSDCDear := unitsPool(1, dex.SushiSwap, weth, usdc, units(1000, 18), units(2_020_000, 6))
+	usdcDAI := unitsPool(2, dex.UniswapV2, usdc, dai, units(2_000_000, 6), units(2_000_000, 18))
+	usdcDAIDear := unitsPool(3, dex.SushiSwap, usdc, dai, units(2_000_000, 6), units(2_100_000, 18))
+	wethDAI := unitsPool(4, dex.UniswapV2, weth, dai, units(1000, 18), units(2_000_000, 18))
+	wethDAIDear := unitsPool(5, dex.SushiSwap, weth, dai, units(1000, 18), units(2_014_000, 18))
+
+	af := testFinder(weth, []*dex.Pool{wethUSDC, wethUSDCDear, usdcDAI, usdcDAIDear, wethDAI, wethDAIDear})
+
+	// The WETH route at two amounts, a USDC route and a WETH route whose
+	// profit does not cover gas
+	wethRoute := testCandidate(t, af, weth, []*dex.Pool{wethUSDCDear, wethUSDC}, nil)
+	wethRouteSmall := testCandidate(t, af, weth, []*dex.Pool{wethUSDCDear, wethUSDC}, units(1, 17))
+	usdcRoute := testCandidate(t, af, usdc, []*dex.Pool{usdcDAIDear, usdcDAI}, nil)
+	thin := testCandidate(t, af, weth, []*dex.Pool{wethDAIDear, wethDAI}, nil)
+
+	// The USDC route rotated to start from DAI with 10 DAI: far more raw
+	// units of profit, but it does not cover gas and must not displace the
+	// executable rotation
+	daiRotation := testCandidate(t, af, dai, []*dex.Pool{usdcDAI, usdcDAIDear}, units(10, 18))
+	if daiRotation.Path.Key() != usdcRoute.Path.Key() || daiRotation.Path.Profit.Cmp(usdcRoute.Path.Profit) <= 0 {
+		t.Fatal("DAI rotation is not a more profitable duplicate in raw units")
+	}
+
+	gasPrice := big.NewInt(1e9)
+	executable := af.ValidateOpportunities(context.Background(),
+		[]*ArbitrageOpportunity{wethRouteSmall, daiRotation, usdcRoute, thin, wethRoute}, gasPrice)
+
+	if len(executable) != 2 {
+		t.Fatalf("got %d executable opportunities, want 2", len(executable))
+	}
+	if executable[0].Path != usdcRoute.Path || executable[1].Path != wethRoute.Path {
+		t.Fatalf("wrong order: %s ETH then %s ETH",
+			executable[0].Path.NetProfitETH.Text('f', 6), executable[1].Path.NetProfitETH.Text('f', 6))
+	}
+	if executable[0].Strategy != "cross_dex" || !executable[0].IsExecutable {
+		t.Fatalf("strategy %q not carried over", executable[0].Strategy)
+	}
+
+	// Ranked by value in ETH, not by raw units of different tokens
+	if executable[0].Path.NetProfit.Cmp(executable[1].Path.NetProfit) >= 0 {
+		t.Fatal("test routes do not differ in raw net profit order")
+	}
+
+	// The thin route and the DAI rotation were validated and rejected
+	for _, rejected := range []*ArbitrageOpportunity{thin, daiRotation} {
+		if rejected.Path.NetProfit == nil || rejected.Path.NetProfit.Sign() > 0 {
+			t.Fatalf("net profit %v, want a loss after gas", rejected.Path.NetProfit)
+		}
+	}
+}
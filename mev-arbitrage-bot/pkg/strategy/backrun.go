@@ -0,0 +1,214 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/mempool"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
+)
+
+// maxBackrunSwaps caps the pending swaps projected per snapshot, in the
+// snapshot's order (likeliest to be included first)
+const maxBackrunSwaps = 20
+
+// ErrVictimReverts is returned when a pending swap would revert on the
+// snapshot, leaving nothing to back-run
+var ErrVictimReverts = errors.New("pending swap would revert")
+
+// BackrunFinder searches for arbitrage created by pending swaps: it applies
+// each swap to a copy of the snapshot and looks for cycles through the pools
+// the swap moved. Opportunities carry the swap and must execute right after
+// it in the same bundle; the strategy never trades ahead of a swap.
+type BackrunFinder struct {
+	finder *ArbitrageFinder
+}
+
+// NewBackrunFinder creates a back-run finder sharing the cycle search, trade
+// bounds and token safety of an arbitrage finder
+func NewBackrunFinder(finder *ArbitrageFinder) *BackrunFinder {
+	return &BackrunFinder{finder: finder}
+}
+
+// Name returns the strategy name
+func (bf *BackrunFinder) Name() string {
+	return "backrun"
+}
+
+// Evaluate implements Strategy for the snapshot's pending swaps
+func (bf *BackrunFinder) Evaluate(ctx context.Context, snapshot *Snapshot) ([]*ArbitrageOpportunity, error) {
+	af := bf.finder
+	af.pricing.Update(snapshot.BlockNumber, snapshot.Pools)
+	af.slippage.Observe(snapshot.BlockNumber, snapshot.Pools)
+
+	now := uint64(snapshot.Timestamp.Unix())
+	opportunities := make([]*ArbitrageOpportunity, 0)
+
+	for i, swap := range snapshot.Pending {
+		if i >= maxBackrunSwaps {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if swap.Expired(now) {
+			continue
+		}
+
+		projected, moved, err := ProjectSwap(snapshot.Pools, swap)
+		if err != nil {
+			log.Debugf("Cannot back-run %s: %v", swap.Hash.Hex()[:10], err)
+			continue
+		}
+
+		paths, err := af.findInPools(ctx, projected, af.startTokens)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+
+		for _, path := range paths {
+			if !touchesAny(path, moved) {
+				continue // Existed before the swap; found by the cycle strategy
+			}
+			opportunities = append(opportunities, &ArbitrageOpportunity{
+				Path:         path,
+				IsExecutable: true,
+				Priority:     path.ProfitBps,
+				Backrun:      swap,
+			})
+		}
+	}
+
+	return opportunities, nil
+}
+
+// ProjectSwap returns a copy of pools with a pending swap applied, and the
+// addresses of the pools it moved. Pools the swap trades through must be in
+// the snapshot; fee-on-transfer swaps and swaps that would revert on their
+// slippage bound cannot be projected.
+func ProjectSwap(pools []*dex.Pool, swap *mempool.PendingSwap) ([]*dex.Pool, map[common.Address]bool, error) {
+	if swap.FeeOnTransfer {
+		return nil, nil, fmt.Errorf("fee-on-transfer swap cannot be projected")
+	}
+
+	// Copy the snapshot; moved pools get their own reserves
+	projected := make([]*dex.Pool, len(pools))
+	for i, pool := range pools {
+		poolCopy := *pool
+		projected[i] = &poolCopy
+	}
+
+	hops := make([]*dex.Pool, len(swap.Path)-1)
+	for i := range hops {
+		hops[i] = findSwapPool(projected, swap, i)
+		if hops[i] == nil {
+			return nil, nil, fmt.Errorf("pool for hop %d not monitored", i+1)
+		}
+	}
+
+	amounts, err := swapAmounts(hops, swap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	moved := make(map[common.Address]bool, len(hops))
+	for i, pool := range hops {
+		if moved[pool.Address] {
+			return nil, nil, fmt.Errorf("swap trades through pool %s twice", pool.Address.Hex())
+		}
+		moved[pool.Address] = true
+
+		pool.Reserve0 = new(big.Int).Set(pool.Reserve0)
+		pool.Reserve1 = new(big.Int).Set(pool.Reserve1)
+		if swap.Path[i] == pool.Token0 {
+			pool.Reserve0.Add(pool.Reserve0, amounts[i])
+			pool.Reserve1.Sub(pool.Reserve1, amounts[i+1])
+		} else {
+			pool.Reserve1.Add(pool.Reserve1, amounts[i])
+			pool.Reserve0.Sub(pool.Reserve0, amounts[i+1])
+		}
+	}
+
+	return projected, moved, nil
+}
+
+// findSwapPool returns the pool of a swap's hop: the decoded pair address if
+// known, otherwise the pool of the router's DEX for the hop's tokens
+func findSwapPool(pools []*dex.Pool, swap *mempool.PendingSwap, hop int) *dex.Pool {
+	token0, token1 := dex.SortTokens(swap.Path[hop], swap.Path[hop+1])
+	for _, pool := range pools {
+		if swap.Pools[hop] != (common.Address{}) {
+			if pool.Address == swap.Pools[hop] {
+				return pool
+			}
+			continue
+		}
+		if pool.DEX == swap.DEX && pool.Token0 == token0 && pool.Token1 == token1 {
+			return pool
+		}
+	}
+	return nil
+}
+
+// swapAmounts returns the token amounts along a swap's path the way the
+// router computes them (getAmountsOut / getAmountsIn), or ErrVictimReverts
+// if the swap's slippage bound would not hold
+func swapAmounts(hops []*dex.Pool, swap *mempool.PendingSwap) ([]*big.Int, error) {
+	amounts := make([]*big.Int, len(swap.Path))
+
+	if !swap.ExactOutput {
+		if swap.AmountIn == nil || swap.AmountIn.Sign() <= 0 {
+			return nil, fmt.Errorf("swap has no input amount")
+		}
+		amounts[0] = swap.AmountIn
+		for i, pool := range hops {
+			reserveIn, reserveOut, err := pool.GetReservesFor(swap.Path[i])
+			if err != nil {
+				return nil, err
+			}
+			amounts[i+1] = utils.CalculateAmountOut(amounts[i], reserveIn, reserveOut, pool.Fee)
+		}
+		if swap.AmountOutMin != nil && amounts[len(amounts)-1].Cmp(swap.AmountOutMin) < 0 {
+			return nil, ErrVictimReverts
+		}
+		return amounts, nil
+	}
+
+	if swap.AmountOut == nil || swap.AmountOut.Sign() <= 0 {
+		return nil, fmt.Errorf("swap has no output amount")
+	}
+	amounts[len(amounts)-1] = swap.AmountOut
+	for i := len(hops) - 1; i >= 0; i-- {
+		reserveIn, reserveOut, err := hops[i].GetReservesFor(swap.Path[i])
+		if err != nil {
+			return nil, err
+		}
+		amounts[i] = utils.CalculateAmountIn(amounts[i+1], reserveIn, reserveOut, hops[i].Fee)
+		if amounts[i].Sign() <= 0 {
+			return nil, ErrVictimReverts
+		}
+	}
+	if swap.AmountInMax != nil && amounts[0].Cmp(swap.AmountInMax) > 0 {
+		return nil, ErrVictimReverts
+	}
+	return amounts, nil
+}
+
+// touchesAny reports whether a path trades through one of pools
+func touchesAny(path *ArbitragePath, pools map[common.Address]bool) bool {
+	for _, pool := range path.Pools {
+		if pools[pool.Address] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package strategy
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/mempool"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
+)
+
+func TestProjectSwapAppliesExactInputSwap(t *testing.T) {
+	a, b, c := testToken(0), testToken(1), testToken(2)
+	ab, bc := testPool(0, a, b, 100, 200), testPool(1, b, c, 300, 300)
+	untouched := testPool(2, a, c, 100, 100)
+	pools := []*dex.Pool{ab, bc, untouched}
+
+	amountIn := big.NewInt(5e12)
+	swap := &mempool.PendingSwap{
+		DEX:      dex.UniswapV2,
+		Path:     []common.Address{a, b, c},
+		Pools:    []common.Address{ab.Address, {}}, // Second hop found by DEX and tokens
+		AmountIn: amountIn,
+	}
+
+	projected, moved, err := ProjectSwap(pools, swap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moved) != 2 || !moved[ab.Address] || !moved[bc.Address] {
+		t.Fatalf("moved pools %v", moved)
+	}
+
+	// Hop by hop with the router's math
+	reserveA, reserveB, _ := ab.GetReservesFor(a)
+	outB := utils.CalculateAmountOut(amountIn, reserveA, reserveB, ab.Fee)
+	reserveB2, reserveC, _ := bc.GetReservesFor(b)
+	outC := utils.CalculateAmountOut(outB, reserveB2, reserveC, bc.Fee)
+
+	gotA, gotB, _ := projected[0].GetReservesFor(a)
+	if gotA.Cmp(new(big.Int).Add(reserveA, amountIn)) != 0 || gotB.Cmp(new(big.Int).Sub(reserveB, outB)) != 0 {
+		t.Fatalf("hop 1 reserves %s/%s", gotA, gotB)
+	}
+	gotB2, gotC, _ := projected[1].GetReservesFor(b)
+	if gotB2.Cmp(new(big.Int).Add(reserveB2, outB)) != 0 || gotC.Cmp(new(big.Int).Sub(reserveC, outC)) != 0 {
+		t.Fatalf("hop 2 reserves %s/%s", gotB2, gotC)
+	}
+
+	// The snapshot itself is not modified
+	if current, _, _ := ab.GetReservesFor(a); current.Cmp(reserveA) != 0 {
+		t.Fatal("snapshot pool mutated")
+	}
+	if projected[2].Reserve0.Cmp(untouched.Reserve0) != 0 {
+		t.Fatal("untouched pool changed")
+	}
+
+	// A swap whose minimum output cannot be met reverts and is not projected
+	swap.AmountOutMin = new(big.Int).Add(outC, big.NewInt(1))
+	if _, _, err := ProjectSwap(pools, swap); !errors.Is(err, ErrVictimReverts) {
+		t.Fatalf("expected ErrVictimReverts, got %v", err)
+	}
+}
+
+func TestProjectSwapAppliesExactOutputSwap(t *testing.T) {
+	a, b := testToken(0), testToken(1)
+	ab := testPool(0, a, b, 100, 200)
+
+	amountOut := big.NewInt(1e13)
+	reserveA, reserveB, _ := ab.GetReservesFor(a)
+	amountIn := utils.CalculateAmountIn(amountOut, reserveA, reserveB, ab.Fee)
+
+	swap := &mempool.PendingSwap{
+		DEX:         dex.UniswapV2,
+		Path:        []common.Address{a, b},
+		Pools:       []common.Address{ab.Address},
+		ExactOutput: true,
+		AmountOut:   amountOut,
+		AmountInMax: amountIn,
+	}
+
+	projected, _, err := ProjectSwap([]*dex.Pool{ab}, swap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotA, gotB, _ := projected[0].GetReservesFor(a)
+	if gotA.Cmp(new(big.Int).Add(reserveA, amountIn)) != 0 || gotB.Cmp(new(big.Int).Sub(reserveB, amountOut)) != 0 {
+		t.Fatalf("reserves %s/%s", gotA, gotB)
+	}
+
+	// One wei less allowed input and the router reverts
+	swap.AmountInMax = new(big.Int).Sub(amountIn, big.NewInt(1))
+	if _, _, err := ProjectSwap([]*dex.Pool{ab}, swap); !errors.Is(err, ErrVictimReverts) {
+		t.Fatalf("expected ErrVictimReverts, got %v", err)
+	}
+
+	// Pools outside the snapshot cannot be projected
+	swap.Pools = []common.Address{common.HexToAddress("0xdead")}
+	if _, _, err := ProjectSwap([]*dex.Pool{ab}, swap); err == nil {
+		t.Fatal("projected a swap through an unknown pool")
+	}
+}
@@ -0,0 +1,154 @@
+package strategy
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// CrossDEXFinder finds the same token pair priced differently on two DEXs
+// (e.g. Uniswap vs SushiSwap): buy on the cheaper pool, sell on the other
+type CrossDEXFinder struct {
+	finder *ArbitrageFinder
+}
+
+// NewCrossDEXFinder creates a cross-DEX finder sharing the pool snapshot,
+// trade bounds and token safety of an arbitrage finder
+func NewCrossDEXFinder(finder *ArbitrageFinder) *CrossDEXFinder {
+	return &CrossDEXFinder{finder: finder}
+}
+
+// Name returns the strategy name
+func (cf *CrossDEXFinder) Name() string {
+	return "cross_dex"
+}
+
+// Evaluate implements Strategy for the snapshot's pools and gas price
+func (cf *CrossDEXFinder) Evaluate(ctx context.Context, snapshot *Snapshot) ([]*ArbitrageOpportunity, error) {
+	cf.finder.pricing.Update(snapshot.BlockNumber, snapshot.Pools)
+	cf.finder.slippage.Observe(snapshot.BlockNumber, snapshot.Pools)
+	paths := cf.findInPools(ctx, snapshot.Pools, snapshot.GasPrice, cf.finder.startTokens)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	opportunities := make([]*ArbitrageOpportunity, 0, len(paths))
+	for _, path := range paths {
+		opportunities = append(opportunities, &ArbitrageOpportunity{
+			Path:         path,
+			IsExecutable: true,
+			Priority:     path.ProfitBps,
+		})
+	}
+	return opportunities, nil
+}
+
+// FindArbitrage compares every monitored pair listed on more than one DEX
+// and returns 2-hop paths that stay profitable after gas, most profitable
+// first. Paths start from one of startTokens (either pair token if none are
+// given).
+func (cf *CrossDEXFinder) FindArbitrage(gasPrice *big.Int, startTokens ...common.Address) []*ArbitragePath {
+	pools := cf.finder.poolMonitor.GetAllPools()
+	cf.finder.pricing.Update(0, pools)
+	return cf.findInPools(context.Background(), pools, gasPrice, startTokens)
+}
+
+// findInPools runs the cross-DEX comparison on a pool snapshot
+func (cf *CrossDEXFinder) findInPools(ctx context.Context, all []*dex.Pool, gasPrice *big.Int, startTokens []common.Address) []*ArbitragePath {
+	af := cf.finder
+
+	allowed := make(map[common.Address]bool, len(startTokens))
+	for _, token := range startTokens {
+		allowed[token] = true
+	}
+
+	// Group pools by pair
+	pairs := make(map[[2]common.Address][]*dex.Pool)
+	for _, pool := range af.usablePools(all) {
+		key := [2]common.Address{pool.Token0, pool.Token1}
+		pairs[key] = append(pairs[key], pool)
+	}
+
+	paths := make([]*ArbitragePath, 0)
+	compared := 0
+
+	for pair, pools := range pairs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		for _, buy := range pools {
+			for _, sell := range pools {
+				if buy.DEX == sell.DEX {
+					continue
+				}
+				compared++
+
+				for i, start := range pair {
+					if len(allowed) > 0 && !allowed[start] {
+						continue
+					}
+
+					cycle := &Cycle{
+						Pools:  []*dex.Pool{buy, sell},
+						Tokens: []common.Address{start, pair[1-i], start},
+					}
+					if path := cf.evaluate(ctx, cycle, gasPrice); path != nil {
+						paths = append(paths, path)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].NetProfitETH.Cmp(paths[j].NetProfitETH) > 0
+	})
+
+	log.Debugf("Found %d cross-DEX opportunities in %d pool pairs", len(paths), compared)
+
+	return paths
+}
+
+// evaluate sizes a 2-hop cycle and returns its path if it clears the
+// minimum profit and gas
+func (cf *CrossDEXFinder) evaluate(ctx context.Context, cycle *Cycle, gasPrice *big.Int) *ArbitragePath {
+	af := cf.finder
+
+	// Skip cycles that lose money even at the margin
+	weight := 0.0
+	for i, pool := range cycle.Pools {
+		reserveIn, reserveOut, err := pool.GetReservesFor(cycle.Tokens[i])
+		if err != nil || reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+			return nil
+		}
+		weight += edgeWeight(reserveIn, reserveOut, pool.Fee, af.taxBps(cycle.Tokens[i+1]))
+	}
+	if weight >= 0 {
+		return nil
+	}
+	cycle.Weight = weight
+
+	amount := af.optimalAmount(cycle)
+	if amount == nil {
+		return nil
+	}
+
+	path := af.evaluateCycle(cycle, amount)
+	if path == nil || path.ProfitBps < af.minProfitBps {
+		return nil
+	}
+
+	af.CalculateNetProfit(ctx, path, gasPrice)
+	if path.NetProfit.Cmp(config.BigInt0) <= 0 {
+		return nil
+	}
+
+	return path
+}
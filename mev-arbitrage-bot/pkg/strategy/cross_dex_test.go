@@ -0,0 +1,87 @@
+package strategy
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+func TestCrossDEXFinderBuysLowSellsHigh(t *testing.T) {
+	weth, usdc := testToken(0), testToken(1)
+	uniRouter, sushiRouter := common.HexToAddress("0xa1"), common.HexToAddress("0xa2")
+
+	// WETH is 5% dearer on SushiSwap and on a second Uniswap pool; the two
+	// Uniswap pools are never compared with each other
+	cheap := testPool(0, weth, usdc, 1_000_000_000, 2_000_000_000_000)
+	dear := testPool(1, weth, usdc, 1_000_000_000, 2_100_000_000_000)
+	dear.DEX = dex.SushiSwap
+	sameDEX := testPool(2, weth, usdc, 1_000_000_000, 2_100_000_000_000)
+
+	cfg := &config.Config{
+		WETHAddress:          weth,
+		MinProfitBps:         10,
+		MinProfitETH:         big.NewFloat(0),
+		MinTradeAmountETH:    big.NewFloat(0.01),
+		MaxTradeAmountETH:    big.NewFloat(100),
+		MaxHops:              3,
+		StartTokens:          []common.Address{weth},
+		GasLimitMultiplier:   1.25,
+		PriceMinLiquidityETH: big.NewFloat(0),
+		ExecutionMode:        config.ExecutionModeFlashLoan,
+		SlippagePolicy:       config.SlippagePolicyBalanced,
+	}
+	monitor := dex.NewPoolMonitor(nil, cfg)
+	monitor.RegisterRouter(dex.UniswapV2, uniRouter)
+	monitor.RegisterRouter(dex.SushiSwap, sushiRouter)
+	finder := NewCrossDEXFinder(NewArbitrageFinder(monitor, cfg))
+
+	snapshot := &Snapshot{
+		BlockNumber: 100,
+		Pools:       []*dex.Pool{cheap, dear, sameDEX},
+		GasPrice:    big.NewInt(1e9),
+	}
+	opportunities, err := finder.Evaluate(context.Background(), snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only WETH starts are allowed: sell WETH where it is dear, buy it back
+	// where it is cheap
+	if len(opportunities) != 1 {
+		t.Fatalf("got %d opportunities, want 1", len(opportunities))
+	}
+	path := opportunities[0].Path
+	if len(path.Pools) != 2 || path.Pools[0] != dear || path.Pools[1] != cheap {
+		t.Fatalf("wrong pools: %v", path.Pools)
+	}
+	if len(path.Tokens) != 3 || path.Tokens[0] != weth || path.Tokens[1] != usdc || path.Tokens[2] != weth {
+		t.Fatalf("wrong tokens: %v", path.Tokens)
+	}
+	if len(path.Routers) != 2 || path.Routers[0] != sushiRouter || path.Routers[1] != uniRouter {
+		t.Fatalf("wrong routers: %v", path.Routers)
+	}
+
+	// Sized inside the trade bounds and profitable after gas and the loan fee
+	if path.StartAmount.Cmp(new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))) > 0 {
+		t.Fatalf("start amount %s above the trade maximum", path.StartAmount)
+	}
+	if !path.FlashLoan || path.NetProfit.Sign() <= 0 || path.NetProfit.Cmp(path.Profit) >= 0 || path.NetProfitETH.Sign() <= 0 {
+		t.Fatalf("profit %s, net %s not reduced by costs", path.Profit, path.NetProfit)
+	}
+	if path.ProfitBps < cfg.MinProfitBps {
+		t.Fatalf("profit %d bps below the minimum", path.ProfitBps)
+	}
+
+	// Without a price gap there is nothing to trade
+	level := testPool(1, weth, usdc, 1_000_000_000, 2_000_000_000_000)
+	level.DEX = dex.SushiSwap
+	snapshot = &Snapshot{BlockNumber: 101, Pools: []*dex.Pool{cheap, level}, GasPrice: big.NewInt(1e9)}
+	if opportunities, _ := finder.Evaluate(context.Background(), snapshot); len(opportunities) != 0 {
+		t.Fatalf("got %d opportunities without a price gap", len(opportunities))
+	}
+}
@@ -0,0 +1,181 @@
+package strategy
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// Gas components of an arbitrage transaction, used until the model has
+// observed executions of the same DEX and token
+const (
+	txBaseGas           = 21000 // Intrinsic transaction cost
+	contractOverheadGas = 35000 // Calldata, entry checks and final profit check
+	flashLoanGas        = 85000 // Aave flashLoanSimple, when no loan source was selected
+	approveGas          = 25000 // approve(router, amountIn) before each swap
+	coldTokenGas        = 6800  // First access to a token: cold account + two balance slots
+)
+
+// defaultSwapGas is the cost of one router swap per DEX, including the
+// cold pair account and reserve slots
+var defaultSwapGas = map[dex.DEXType]uint64{
+	dex.UniswapV2: 95000,
+	dex.SushiSwap: 95000,
+	dex.UniswapV3: 130000,
+}
+
+const fallbackSwapGas = 130000
+
+// Weights of a new observation in the moving averages. Receipts are what we
+// actually paid; eth_estimateGas samples ignore refunds and are noisier.
+const (
+	receiptWeight  = 0.3
+	estimateWeight = 0.1
+)
+
+// hopKey identifies a swap cost: the DEX and the token sold into the pool
+type hopKey struct {
+	dex   dex.DEXType
+	token common.Address
+}
+
+// GasModel estimates the gas of arbitrage transactions per hop and learns
+// per-(DEX, token) swap costs from our receipts and eth_estimateGas samples
+type GasModel struct {
+	hopGas          map[hopKey]float64 // Learned cost of one hop, approval included
+	receipts        map[hopKey]int     // Receipt samples per hop
+	limitMultiplier float64            // Gas limit = estimate x multiplier
+	mu              sync.RWMutex
+}
+
+// NewGasModel creates a gas model with default per-DEX costs
+func NewGasModel(limitMultiplier float64) *GasModel {
+	if limitMultiplier < 1 {
+		limitMultiplier = 1
+	}
+	return &GasModel{
+		hopGas:          make(map[hopKey]float64),
+		receipts:        make(map[hopKey]int),
+		limitMultiplier: limitMultiplier,
+	}
+}
+
+// fixedGas returns the part of a transaction's gas that does not depend on
+// which pools are traded: base cost, contract, flash loan and cold tokens
+func fixedGas(path *ArbitragePath) uint64 {
+	gas := uint64(txBaseGas + contractOverheadGas)
+	if path.FlashLoan {
+		if path.LoanSource != nil {
+			gas += path.LoanSource.GasOverhead
+		} else {
+			gas += flashLoanGas
+		}
+	}
+
+	distinct := make(map[common.Address]bool, len(path.Tokens))
+	for _, token := range path.Tokens {
+		distinct[token] = true
+	}
+	return gas + uint64(len(distinct))*coldTokenGas
+}
+
+// hopEstimate returns the learned cost of a hop, or the DEX default
+func (g *GasModel) hopEstimate(key hopKey) float64 {
+	if gas, exists := g.hopGas[key]; exists {
+		return gas
+	}
+
+	swapGas, exists := defaultSwapGas[key.dex]
+	if !exists {
+		swapGas = fallbackSwapGas
+	}
+	return float64(swapGas + approveGas)
+}
+
+// hopKeys returns the cost key of every hop of a path
+func hopKeys(path *ArbitragePath) []hopKey {
+	keys := make([]hopKey, len(path.Pools))
+	for i, pool := range path.Pools {
+		keys[i] = hopKey{dex: pool.DEX, token: path.Tokens[i]}
+	}
+	return keys
+}
+
+// Estimate returns the expected gas used by executing a path
+func (g *GasModel) Estimate(path *ArbitragePath) uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	gas := float64(fixedGas(path))
+	for _, key := range hopKeys(path) {
+		gas += g.hopEstimate(key)
+	}
+	return uint64(gas)
+}
+
+// GasLimit returns the gas limit to send a path with
+func (g *GasModel) GasLimit(path *ArbitragePath) uint64 {
+	return uint64(float64(g.Estimate(path)) * g.limitMultiplier)
+}
+
+// EstimateCost returns the expected gas cost of a path in wei
+func (g *GasModel) EstimateCost(path *ArbitragePath, gasPrice *big.Int) *big.Int {
+	gas := new(big.Int).SetUint64(g.Estimate(path))
+	return gas.Mul(gas, gasPrice)
+}
+
+// ObserveReceipt learns from the gas used by a successful execution
+func (g *GasModel) ObserveReceipt(path *ArbitragePath, gasUsed uint64) {
+	g.observe(path, gasUsed, receiptWeight, true)
+}
+
+// ObserveEstimate learns from an eth_estimateGas result for a path
+func (g *GasModel) ObserveEstimate(path *ArbitragePath, gas uint64) {
+	g.observe(path, gas, estimateWeight, false)
+}
+
+// observe attributes the gas above the fixed costs to the hops in
+// proportion to their current estimates and moves each hop's estimate
+// towards its share. The first receipt of a hop replaces its estimate
+// outright; estimates are always blended in with their weight.
+func (g *GasModel) observe(path *ArbitragePath, gasUsed uint64, weight float64, receipt bool) {
+	if len(path.Pools) == 0 || len(path.Tokens) <= len(path.Pools) {
+		return
+	}
+
+	fixed := fixedGas(path)
+	if gasUsed <= fixed {
+		log.Debugf("Ignoring gas sample %d below fixed cost %d", gasUsed, fixed)
+		return
+	}
+	swapGas := float64(gasUsed - fixed)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	keys := hopKeys(path)
+	estimates := make([]float64, len(keys))
+	total := 0.0
+	for i, key := range keys {
+		estimates[i] = g.hopEstimate(key)
+		total += estimates[i]
+	}
+
+	for i, key := range keys {
+		share := swapGas * estimates[i] / total
+		if receipt && g.receipts[key] == 0 {
+			g.hopGas[key] = share
+		} else {
+			g.hopGas[key] = (1-weight)*estimates[i] + weight*share
+		}
+		if receipt {
+			g.receipts[key]++
+		}
+	}
+
+	log.Debugf("Gas model: observed %d gas for %d hops (fixed %d)", gasUsed, len(keys), fixed)
+}
@@ -0,0 +1,85 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// testPath builds a path from start through pools
+func testPath(start common.Address, pools []*dex.Pool, flashLoan bool) *ArbitragePath {
+	path := &ArbitragePath{Pools: pools, FlashLoan: flashLoan}
+	path.Tokens = append(path.Tokens, start)
+	for i, pool := range pools {
+		next := pool.Token0
+		if next == path.Tokens[i] {
+			next = pool.Token1
+		}
+		path.Tokens = append(path.Tokens, next)
+	}
+	return path
+}
+
+func TestGasModelLearnsFromReceipts(t *testing.T) {
+	a, b, c := testToken(0), testToken(1), testToken(2)
+	pools := []*dex.Pool{testPool(0, a, b, 100, 100), testPool(1, b, c, 100, 100), testPool(2, c, a, 100, 100)}
+	pools[2].DEX = dex.SushiSwap
+
+	model := NewGasModel(1.25)
+
+	path := testPath(a, pools, true)
+	withoutLoan := testPath(a, pools, false)
+	if model.Estimate(path)-model.Estimate(withoutLoan) != flashLoanGas {
+		t.Fatalf("flash loan overhead not included")
+	}
+
+	// Executions cost 60k less than the defaults; the model should converge
+	actual := model.Estimate(path) - 60000
+	for i := 0; i < 30; i++ {
+		model.ObserveReceipt(path, actual)
+	}
+
+	estimate := model.Estimate(path)
+	if diff := int64(estimate) - int64(actual); diff < -100 || diff > 100 {
+		t.Fatalf("estimate %d did not converge to %d", estimate, actual)
+	}
+	if limit := model.GasLimit(path); limit != uint64(float64(estimate)*1.25) {
+		t.Fatalf("gas limit %d not scaled from estimate %d", limit, estimate)
+	}
+
+	// A path through other tokens keeps the defaults
+	d, e := testToken(3), testToken(4)
+	other := testPath(d, []*dex.Pool{testPool(3, d, e, 100, 100), testPool(4, e, d, 100, 100)}, true)
+	fresh := NewGasModel(1.25)
+	if model.Estimate(other) != fresh.Estimate(other) {
+		t.Fatalf("unrelated hops changed: %d != %d", model.Estimate(other), fresh.Estimate(other))
+	}
+
+	// Samples below the fixed overhead are ignored
+	model.ObserveReceipt(path, 1000)
+	if model.Estimate(path) != estimate {
+		t.Fatalf("implausible sample was learned")
+	}
+}
+
+func TestGasModelBlendsEstimates(t *testing.T) {
+	a, b := testToken(0), testToken(1)
+	path := testPath(a, []*dex.Pool{testPool(0, a, b, 100, 100), testPool(1, b, a, 100, 100)}, false)
+
+	model := NewGasModel(1)
+	initial := model.Estimate(path)
+
+	// An estimate 100k below the defaults moves them by its weight only
+	model.ObserveEstimate(path, initial-100000)
+	if got, want := model.Estimate(path), initial-10000; got < want-1 || got > want+1 {
+		t.Fatalf("estimate sample gave %d, want %d", got, want)
+	}
+
+	// The first receipt replaces the learned value outright
+	model.ObserveReceipt(path, initial-50000)
+	if got, want := model.Estimate(path), initial-50000; got < want-1 || got > want+1 {
+		t.Fatalf("first receipt gave %d, want %d", got, want)
+	}
+}
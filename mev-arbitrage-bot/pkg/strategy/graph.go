@@ -0,0 +1,251 @@
+package strategy
+
+import (
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// TaxFunc returns the transfer tax in bps a token loses on every hop
+type TaxFunc func(token common.Address) int
+
+// edge is a directed swap through a pool
+type edge struct {
+	from   int
+	to     int
+	pool   *dex.Pool
+	weight float64 // -log(marginal rate after fees and taxes)
+}
+
+// TokenGraph is a directed token graph built from a pool snapshot. Every
+// pool contributes one edge per direction, weighted by the negative log of
+// its marginal exchange rate, so a cycle with negative total weight is
+// profitable for an infinitesimal trade.
+type TokenGraph struct {
+	tokens  []common.Address
+	index   map[common.Address]int
+	out     [][]edge
+	between map[uint64][]edge // (from, to) -> parallel edges
+}
+
+// Cycle is a closed swap route through distinct tokens
+type Cycle struct {
+	Pools  []*dex.Pool
+	Tokens []common.Address // Tokens[0] == Tokens[len(Tokens)-1]
+	Weight float64          // Sum of edge weights; < 0 is profitable at the margin
+}
+
+// Key identifies a cycle by its pools and direction, independent of the
+// token it starts from. The key is an opaque binary string.
+func (c *Cycle) Key() string {
+	hops := len(c.Pools)
+	parts := make([]string, hops)
+	for i := range c.Pools {
+		parts[i] = string(c.Pools[i].Address.Bytes()) + string(c.Tokens[i].Bytes())
+	}
+
+	// Rotate so the smallest hop comes first
+	first := 0
+	for i := 1; i < hops; i++ {
+		if parts[i] < parts[first] {
+			first = i
+		}
+	}
+
+	return strings.Join(append(parts[first:], parts[:first]...), "")
+}
+
+// NewTokenGraph builds a graph from pools with non-zero reserves. taxBps
+// may be nil.
+func NewTokenGraph(pools []*dex.Pool, taxBps TaxFunc) *TokenGraph {
+	g := &TokenGraph{
+		index:   make(map[common.Address]int),
+		between: make(map[uint64][]edge),
+	}
+
+	for _, pool := range pools {
+		if pool.Reserve0 == nil || pool.Reserve1 == nil ||
+			pool.Reserve0.Sign() <= 0 || pool.Reserve1.Sign() <= 0 {
+			continue
+		}
+
+		i0, i1 := g.tokenIndex(pool.Token0), g.tokenIndex(pool.Token1)
+		g.addEdge(i0, i1, pool, edgeWeight(pool.Reserve0, pool.Reserve1, pool.Fee, taxOf(taxBps, pool.Token1)))
+		g.addEdge(i1, i0, pool, edgeWeight(pool.Reserve1, pool.Reserve0, pool.Fee, taxOf(taxBps, pool.Token0)))
+	}
+
+	return g
+}
+
+// TokenCount returns the number of tokens in the graph
+func (g *TokenGraph) TokenCount() int {
+	return len(g.tokens)
+}
+
+// tokenIndex returns the index of a token, adding it if new
+func (g *TokenGraph) tokenIndex(token common.Address) int {
+	if i, exists := g.index[token]; exists {
+		return i
+	}
+
+	i := len(g.tokens)
+	g.index[token] = i
+	g.tokens = append(g.tokens, token)
+	g.out = append(g.out, nil)
+	return i
+}
+
+func (g *TokenGraph) addEdge(from, to int, pool *dex.Pool, weight float64) {
+	e := edge{from: from, to: to, pool: pool, weight: weight}
+	g.out[from] = append(g.out[from], e)
+
+	key := edgeKey(from, to)
+	g.between[key] = append(g.between[key], e)
+}
+
+func edgeKey(from, to int) uint64 {
+	return uint64(from)<<32 | uint64(to)
+}
+
+// taxOf returns the tax of a token or 0 without a tax function
+func taxOf(taxBps TaxFunc, token common.Address) int {
+	if taxBps == nil {
+		return 0
+	}
+	return taxBps(token)
+}
+
+// edgeWeight returns -log of the marginal rate reserveOut/reserveIn after
+// the pool fee and the output token's transfer tax
+func edgeWeight(reserveIn, reserveOut *big.Int, feeBps int, taxBps int) float64 {
+	in, _ := new(big.Float).SetInt(reserveIn).Float64()
+	out, _ := new(big.Float).SetInt(reserveOut).Float64()
+
+	rate := out / in * float64(10000-feeBps) / 10000 * float64(10000-taxBps) / 10000
+	if rate <= 0 {
+		return math.Inf(1)
+	}
+	return -math.Log(rate)
+}
+
+// FindCycles returns every simple cycle of 2..maxHops pools whose total
+// weight is negative, found by a depth-bounded DFS. Cycles start at one of
+// startTokens; with no start tokens every token is considered and each
+// cycle is reported once, starting at its first-indexed token. Results are
+// sorted by weight, most profitable first.
+func (g *TokenGraph) FindCycles(startTokens []common.Address, maxHops int) []*Cycle {
+	if maxHops < 2 {
+		return nil
+	}
+
+	anyStart := len(startTokens) == 0
+	starts := make([]int, 0, len(startTokens))
+	if anyStart {
+		for i := range g.tokens {
+			starts = append(starts, i)
+		}
+	} else {
+		for _, token := range startTokens {
+			if i, exists := g.index[token]; exists {
+				starts = append(starts, i)
+			}
+		}
+	}
+
+	search := &cycleSearch{
+		graph:    g,
+		maxHops:  maxHops,
+		anyStart: anyStart,
+		visited:  make([]bool, len(g.tokens)),
+		seen:     make(map[string]bool),
+	}
+
+	for _, start := range starts {
+		search.start = start
+		search.visited[start] = true
+		search.dfs(start, 0)
+		search.visited[start] = false
+	}
+
+	sort.Slice(search.cycles, func(i, j int) bool {
+		return search.cycles[i].Weight < search.cycles[j].Weight
+	})
+
+	return search.cycles
+}
+
+// cycleSearch holds the DFS state of FindCycles
+type cycleSearch struct {
+	graph    *TokenGraph
+	maxHops  int
+	anyStart bool
+	start    int
+	visited  []bool
+	path     []edge
+	seen     map[string]bool
+	cycles   []*Cycle
+}
+
+// dfs extends the current path from token node. The closing hop back to
+// the start is looked up directly instead of enumerated.
+func (s *cycleSearch) dfs(node int, weight float64) {
+	hops := len(s.path)
+
+	if hops >= 1 {
+		for _, closing := range s.graph.between[edgeKey(node, s.start)] {
+			// A 2-hop cycle must use two different pools
+			if closing.pool.Address == s.path[hops-1].pool.Address {
+				continue
+			}
+			if total := weight + closing.weight; total < 0 {
+				s.record(append(s.path, closing), total)
+			}
+		}
+	}
+
+	if hops+1 >= s.maxHops {
+		return
+	}
+
+	for _, e := range s.graph.out[node] {
+		if s.visited[e.to] {
+			continue
+		}
+		// Canonical start: only visit tokens after the start token
+		if s.anyStart && e.to < s.start {
+			continue
+		}
+
+		s.visited[e.to] = true
+		s.path = append(s.path, e)
+		s.dfs(e.to, weight+e.weight)
+		s.path = s.path[:len(s.path)-1]
+		s.visited[e.to] = false
+	}
+}
+
+// record stores a profitable cycle unless already found from another start
+func (s *cycleSearch) record(edges []edge, weight float64) {
+	cycle := &Cycle{
+		Pools:  make([]*dex.Pool, len(edges)),
+		Tokens: make([]common.Address, len(edges)+1),
+		Weight: weight,
+	}
+	for i, e := range edges {
+		cycle.Pools[i] = e.pool
+		cycle.Tokens[i] = s.graph.tokens[e.from]
+	}
+	cycle.Tokens[len(edges)] = s.graph.tokens[s.start]
+
+	key := cycle.Key()
+	if s.seen[key] {
+		return
+	}
+	s.seen[key] = true
+	s.cycles = append(s.cycles, cycle)
+}
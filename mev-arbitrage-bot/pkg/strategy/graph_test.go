@@ -0,0 +1,155 @@
+package strategy
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+func testToken(i int) common.Address {
+	return common.BigToAddress(big.NewInt(int64(i + 1)))
+}
+
+// testPool creates a pool holding reserveA of tokenA and reserveB of tokenB
+func testPool(id int, tokenA, tokenB common.Address, reserveA, reserveB int64) *dex.Pool {
+	token0, token1 := dex.SortTokens(tokenA, tokenB)
+	reserve0, reserve1 := big.NewInt(reserveA), big.NewInt(reserveB)
+	if token0 != tokenA {
+		reserve0, reserve1 = reserve1, reserve0
+	}
+
+	return &dex.Pool{
+		Address:  common.BigToAddress(big.NewInt(int64(0x10000 + id))),
+		Token0:   token0,
+		Token1:   token1,
+		Reserve0: new(big.Int).Mul(reserve0, big.NewInt(1e12)),
+		Reserve1: new(big.Int).Mul(reserve1, big.NewInt(1e12)),
+		Fee:      30,
+		DEX:      dex.UniswapV2,
+	}
+}
+
+// randomPools builds a market of tokens priced consistently against each
+// other, with reserves perturbed by up to ±noise so some cycles are
+// profitable. Token 0 is a hub paired with a large share of the pools.
+func randomPools(rng *rand.Rand, numTokens, numPools int, noise float64) []*dex.Pool {
+	prices := make([]float64, numTokens)
+	for i := range prices {
+		prices[i] = 0.5 + rng.Float64()
+	}
+
+	pools := make([]*dex.Pool, 0, numPools)
+	for len(pools) < numPools {
+		a := rng.Intn(numTokens)
+		if rng.Intn(3) == 0 {
+			a = 0
+		}
+		b := rng.Intn(numTokens)
+		if a == b {
+			continue
+		}
+
+		liquidity := 1e6 * (1 + rng.Float64())
+		reserveA := liquidity / prices[a]
+		reserveB := liquidity / prices[b] * (1 + noise*(2*rng.Float64()-1))
+		pools = append(pools, testPool(len(pools), testToken(a), testToken(b), int64(reserveA), int64(reserveB)))
+	}
+
+	return pools
+}
+
+func TestFindCyclesTriangleAndCrossDEX(t *testing.T) {
+	a, b, c := testToken(0), testToken(1), testToken(2)
+
+	pools := []*dex.Pool{
+		// A -> B -> C -> A returns ~1.2x before fees
+		testPool(1, a, b, 1_000_000, 1_000_000),
+		testPool(2, b, c, 1_000_000, 1_200_000),
+		testPool(3, c, a, 1_000_000, 1_000_000),
+		// Same A/B pair priced 5% apart on a second DEX
+		testPool(4, a, b, 1_000_000, 950_000),
+	}
+
+	cycles := NewTokenGraph(pools, nil).FindCycles([]common.Address{a}, 3)
+
+	found := make(map[string]bool)
+	for _, cycle := range cycles {
+		if cycle.Weight >= 0 {
+			t.Fatalf("cycle %s has non-negative weight %f", cycle.Key(), cycle.Weight)
+		}
+		if cycle.Tokens[0] != a || cycle.Tokens[len(cycle.Tokens)-1] != a {
+			t.Fatalf("cycle %s does not start and end at A", cycle.Key())
+		}
+		if found[cycle.Key()] {
+			t.Fatalf("duplicate cycle %s", cycle.Key())
+		}
+		found[cycle.Key()] = true
+	}
+
+	triangle := (&Cycle{Pools: []*dex.Pool{pools[0], pools[1], pools[2]}, Tokens: []common.Address{a, b, c, a}}).Key()
+	if !found[triangle] {
+		t.Errorf("triangle A->B->C->A not found")
+	}
+
+	crossDEX := (&Cycle{Pools: []*dex.Pool{pools[0], pools[3]}, Tokens: []common.Address{a, b, a}}).Key()
+	if !found[crossDEX] {
+		t.Errorf("2-hop cycle A->B (pool 1) -> A (pool 4) not found")
+	}
+}
+
+func TestFindCyclesAnyStartReportsEachCycleOnce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	graph := NewTokenGraph(randomPools(rng, 40, 200, 0.02), nil)
+
+	seen := make(map[string]bool)
+	for _, cycle := range graph.FindCycles(nil, 3) {
+		if seen[cycle.Key()] {
+			t.Fatalf("cycle %s reported twice", cycle.Key())
+		}
+		seen[cycle.Key()] = true
+	}
+
+	if len(seen) == 0 {
+		t.Fatal("expected profitable cycles in a noisy market")
+	}
+}
+
+func BenchmarkNewTokenGraph(b *testing.B) {
+	for _, numPools := range []int{1000, 5000} {
+		pools := randomPools(rand.New(rand.NewSource(1)), numPools/4, numPools, 0.01)
+
+		b.Run(fmt.Sprintf("pools=%d", numPools), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewTokenGraph(pools, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkFindCycles(b *testing.B) {
+	hub := []common.Address{testToken(0)}
+
+	for _, numPools := range []int{1000, 5000} {
+		pools := randomPools(rand.New(rand.NewSource(1)), numPools/4, numPools, 0.01)
+		graph := NewTokenGraph(pools, nil)
+
+		for _, maxHops := range []int{2, 3, 4} {
+			b.Run(fmt.Sprintf("pools=%d/hops=%d/hub", numPools, maxHops), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					graph.FindCycles(hub, maxHops)
+				}
+			})
+		}
+
+		b.Run(fmt.Sprintf("pools=%d/hops=3/any", numPools), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				graph.FindCycles(nil, 3)
+			}
+		})
+	}
+}
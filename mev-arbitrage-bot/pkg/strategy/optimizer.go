@@ -0,0 +1,187 @@
+package strategy
+
+import (
+	"math/big"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
+)
+
+// goldenSectionIterations bounds the fallback search; each iteration
+// shrinks the interval by ~38%, so 100 iterations resolve 10^20 to one wei
+const goldenSectionIterations = 100
+
+// Golden ratio conjugate as a fraction (0.618034)
+var (
+	goldenNum = big.NewInt(618034)
+	goldenDen = big.NewInt(1000000)
+)
+
+// CycleFunc returns the output of a cycle for an input amount, or nil if
+// the trade fails
+type CycleFunc func(amountIn *big.Int) *big.Int
+
+// Hop is one constant-product swap of a cycle
+type Hop struct {
+	ReserveIn  *big.Int
+	ReserveOut *big.Int
+	FeeBps     int
+	TaxBps     int // Transfer tax of the output token
+}
+
+// mobius is the output curve A*x / (B + C*x) of one or more composed
+// constant-product hops (the composite virtual reserves of a route)
+type mobius struct {
+	a, b, c *big.Int
+}
+
+// toMobius returns the curve of a single hop:
+// out = (10000-tax)*(10000-fee)*Rout*x / (10000*(10000*Rin + (10000-fee)*x))
+func (h Hop) toMobius() mobius {
+	gamma := big.NewInt(int64(10000 - h.FeeBps))
+	tau := big.NewInt(int64(10000 - h.TaxBps))
+
+	a := new(big.Int).Mul(gamma, h.ReserveOut)
+	a.Mul(a, tau)
+
+	b := new(big.Int).Mul(h.ReserveIn, config.BigInt10000)
+	b.Mul(b, config.BigInt10000)
+
+	c := new(big.Int).Mul(gamma, config.BigInt10000)
+
+	return mobius{a: a, b: b, c: c}
+}
+
+// then composes m followed by n: n(m(x))
+func (m mobius) then(n mobius) mobius {
+	a := new(big.Int).Mul(m.a, n.a)
+	b := new(big.Int).Mul(m.b, n.b)
+
+	c := new(big.Int).Mul(n.b, m.c)
+	c.Add(c, new(big.Int).Mul(m.a, n.c))
+
+	return mobius{a: a, b: b, c: c}
+}
+
+// OptimalAmountV2 returns the profit-maximizing input of a cycle of
+// constant-product hops in closed form. Profit A*x/(B+C*x) - x peaks at
+// x* = (sqrt(A*B) - B) / C, which is positive only if A > B (the cycle is
+// profitable at the margin). Returns nil for unprofitable cycles.
+func OptimalAmountV2(hops []Hop) *big.Int {
+	if len(hops) == 0 {
+		return nil
+	}
+
+	curve := hops[0].toMobius()
+	for _, hop := range hops[1:] {
+		curve = curve.then(hop.toMobius())
+	}
+
+	if curve.a.Cmp(curve.b) <= 0 || curve.c.Sign() == 0 {
+		return nil
+	}
+
+	amount := new(big.Int).Mul(curve.a, curve.b)
+	amount.Sqrt(amount)
+	amount.Sub(amount, curve.b)
+	amount.Div(amount, curve.c)
+
+	if amount.Sign() <= 0 {
+		return nil
+	}
+	return amount
+}
+
+// GoldenSectionSearch returns the input in [lo, hi] maximizing
+// output - input of a cycle whose profit is unimodal in the input
+func GoldenSectionSearch(cycle CycleFunc, lo, hi *big.Int) *big.Int {
+	profit := func(amountIn *big.Int) *big.Int {
+		out := cycle(amountIn)
+		if out == nil {
+			return new(big.Int).Neg(amountIn)
+		}
+		return new(big.Int).Sub(out, amountIn)
+	}
+
+	a, b := new(big.Int).Set(lo), new(big.Int).Set(hi)
+	step := func() *big.Int {
+		width := new(big.Int).Sub(b, a)
+		width.Mul(width, goldenNum)
+		return width.Div(width, goldenDen)
+	}
+
+	c := new(big.Int).Sub(b, step())
+	d := new(big.Int).Add(a, step())
+	fc, fd := profit(c), profit(d)
+
+	for i := 0; i < goldenSectionIterations && new(big.Int).Sub(b, a).Cmp(config.BigInt1) > 0; i++ {
+		if fc.Cmp(fd) >= 0 {
+			// Maximum lies in [a, d]
+			b = d
+			d, fd = c, fc
+			c = new(big.Int).Sub(b, step())
+			fc = profit(c)
+		} else {
+			// Maximum lies in [c, b]
+			a = c
+			c, fc = d, fd
+			d = new(big.Int).Add(a, step())
+			fd = profit(d)
+		}
+	}
+
+	if fc.Cmp(fd) >= 0 {
+		return c
+	}
+	return d
+}
+
+// isConstantProduct reports whether a pool uses x*y=k pricing
+func isConstantProduct(pool *dex.Pool) bool {
+	return pool.DEX == dex.UniswapV2 || pool.DEX == dex.SushiSwap
+}
+
+// optimalAmount returns the profit-maximizing start amount of a cycle
+// clamped to the configured trade bounds (valued in ETH): closed form when every leg is a
+// V2-style pool, golden-section search otherwise
+func (af *ArbitrageFinder) optimalAmount(cycle *Cycle) *big.Int {
+	var amount *big.Int
+
+	minAmount, maxAmount, err := af.tradeBounds(cycle.Tokens[0])
+	if err != nil {
+		return nil
+	}
+
+	hops := make([]Hop, 0, len(cycle.Pools))
+	for i, pool := range cycle.Pools {
+		if !isConstantProduct(pool) {
+			hops = nil
+			break
+		}
+
+		reserveIn, reserveOut, err := pool.GetReservesFor(cycle.Tokens[i])
+		if err != nil {
+			return nil
+		}
+		hops = append(hops, Hop{
+			ReserveIn:  reserveIn,
+			ReserveOut: reserveOut,
+			FeeBps:     pool.Fee,
+			TaxBps:     af.taxBps(cycle.Tokens[i+1]),
+		})
+	}
+
+	if hops != nil {
+		amount = OptimalAmountV2(hops)
+		if amount == nil {
+			return nil
+		}
+	} else {
+		amount = GoldenSectionSearch(func(amountIn *big.Int) *big.Int {
+			return af.simulateCycle(cycle, amountIn)
+		}, minAmount, maxAmount)
+	}
+
+	return utils.MaxBigInt(minAmount, utils.MinBigInt(amount, maxAmount))
+}
@@ -0,0 +1,104 @@
+package strategy
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/utils"
+)
+
+// cycleOutput trades through hops with the exact integer pool math
+func cycleOutput(hops []Hop, amountIn *big.Int) *big.Int {
+	amount := amountIn
+	for _, hop := range hops {
+		amount = utils.CalculateAmountOut(amount, hop.ReserveIn, hop.ReserveOut, hop.FeeBps)
+		amount.Mul(amount, big.NewInt(int64(10000-hop.TaxBps)))
+		amount.Div(amount, big.NewInt(10000))
+	}
+	return amount
+}
+
+func cycleProfit(hops []Hop, amountIn *big.Int) *big.Int {
+	return new(big.Int).Sub(cycleOutput(hops, amountIn), amountIn)
+}
+
+// randomCycle returns a cycle of 2-4 hops with reserves around 1e21
+// perturbed by up to 5%, which is profitable about half of the time
+func randomCycle(rng *rand.Rand) []Hop {
+	hops := make([]Hop, 2+rng.Intn(3))
+	for i := range hops {
+		base := new(big.Int).Mul(big.NewInt(1+rng.Int63n(1000)), big.NewInt(1e18))
+		skew := new(big.Int).Mul(base, big.NewInt(9500+rng.Int63n(1001)))
+		hops[i] = Hop{
+			ReserveIn:  base,
+			ReserveOut: skew.Div(skew, big.NewInt(10000)),
+			FeeBps:     30,
+		}
+		if rng.Intn(4) == 0 {
+			hops[i].TaxBps = rng.Intn(50)
+		}
+	}
+	return hops
+}
+
+func TestOptimalAmountV2IsLocalMaximum(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	profitable := 0
+
+	for i := 0; i < 500; i++ {
+		hops := randomCycle(rng)
+		optimal := OptimalAmountV2(hops)
+		if optimal == nil {
+			// Unprofitable at the margin: a tiny trade must lose
+			if cycleProfit(hops, big.NewInt(1e15)).Sign() > 0 {
+				t.Fatalf("cycle %d: no optimum but a small trade is profitable", i)
+			}
+			continue
+		}
+		profitable++
+
+		best := cycleProfit(hops, optimal)
+		if best.Sign() <= 0 {
+			t.Fatalf("cycle %d: profit at optimum %s is %s", i, optimal, best)
+		}
+
+		// Moving 1% either way must not beat the optimum by more than
+		// integer rounding
+		delta := new(big.Int).Div(optimal, big.NewInt(100))
+		for _, amount := range []*big.Int{new(big.Int).Sub(optimal, delta), new(big.Int).Add(optimal, delta)} {
+			if profit := cycleProfit(hops, amount); profit.Cmp(new(big.Int).Add(best, big.NewInt(int64(len(hops))))) > 0 {
+				t.Fatalf("cycle %d: profit %s at %s beats optimum %s at %s", i, profit, amount, best, optimal)
+			}
+		}
+	}
+
+	if profitable == 0 {
+		t.Fatal("no profitable cycles generated")
+	}
+}
+
+func TestGoldenSectionSearchMatchesClosedForm(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	lo, hi := big.NewInt(1), new(big.Int).Mul(big.NewInt(1e6), big.NewInt(1e18))
+
+	for i := 0; i < 200; i++ {
+		hops := randomCycle(rng)
+		optimal := OptimalAmountV2(hops)
+		if optimal == nil || optimal.Cmp(hi) > 0 {
+			continue
+		}
+
+		found := GoldenSectionSearch(func(amountIn *big.Int) *big.Int {
+			return cycleOutput(hops, amountIn)
+		}, lo, hi)
+
+		// Profit is flat near the peak, so compare profits, not inputs
+		want, got := cycleProfit(hops, optimal), cycleProfit(hops, found)
+		tolerance := new(big.Int).Div(want, big.NewInt(1e6))
+		if new(big.Int).Sub(want, got).Cmp(tolerance) > 0 {
+			t.Fatalf("cycle %d: golden-section profit %s at %s, closed form %s at %s",
+				i, got, found, want, optimal)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package strategy
+
+import (
+	"math"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// SlippagePolicy sets how far a hop's output may fall below its quote
+type SlippagePolicy struct {
+	BaseBps          int     // Tolerance of a fresh, quiet pool
+	PerBlockBps      int     // Added per block the reserves are older than the target block
+	VolatilityFactor float64 // Multiple of the pool's average per-block move (in bps)
+	PendingBps       int     // Added per pending swap competing for the pool
+	MaxBps           int     // Cap on any hop's tolerance
+}
+
+// slippagePolicies are the presets selected by config.SlippagePolicy
+var slippagePolicies = map[string]SlippagePolicy{
+	config.SlippagePolicyConservative: {BaseBps: 5, PerBlockBps: 5, VolatilityFactor: 1, PendingBps: 10, MaxBps: 50},
+	config.SlippagePolicyBalanced:     {BaseBps: 10, PerBlockBps: 10, VolatilityFactor: 2, PendingBps: 20, MaxBps: 100},
+	config.SlippagePolicyAggressive:   {BaseBps: 20, PerBlockBps: 20, VolatilityFactor: 3, PendingBps: 40, MaxBps: 300},
+}
+
+// SlippagePolicyFor returns a preset, with its cap overridden if maxBps > 0.
+// Unknown names get the balanced preset.
+func SlippagePolicyFor(name string, maxBps int) SlippagePolicy {
+	policy, exists := slippagePolicies[name]
+	if !exists {
+		policy = slippagePolicies[config.SlippagePolicyBalanced]
+	}
+	if maxBps > 0 {
+		policy.MaxBps = maxBps
+	}
+	return policy
+}
+
+// PendingCounter reports pending transactions that will trade a pool
+// before ours (implemented by the mempool watcher)
+type PendingCounter interface {
+	PendingSwaps(pool common.Address) int
+}
+
+// volatilityWeight is the weight of a new per-block move in the average
+const volatilityWeight = 0.2
+
+// poolVolatility tracks a pool's average per-block price move
+type poolVolatility struct {
+	logPrice float64
+	block    uint64
+	moveBps  float64 // EWMA of |log price change| per block, in bps
+}
+
+// SlippageModel derives per-hop minimum outputs from snapshot age, pool
+// volatility and competing pending swaps
+type SlippageModel struct {
+	policy  SlippagePolicy
+	pending PendingCounter // Optional
+	pools   map[common.Address]*poolVolatility
+	block   uint64 // Latest observed block
+	mu      sync.RWMutex
+}
+
+// NewSlippageModel creates a slippage model for a policy
+func NewSlippageModel(policy SlippagePolicy) *SlippageModel {
+	return &SlippageModel{
+		policy: policy,
+		pools:  make(map[common.Address]*poolVolatility),
+	}
+}
+
+// SetPendingCounter sets the source of competing pending swaps
+func (s *SlippageModel) SetPendingCounter(pending PendingCounter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = pending
+}
+
+// Policy returns the model's policy
+func (s *SlippageModel) Policy() SlippagePolicy {
+	return s.policy
+}
+
+// Observe records the pool prices of a block; repeated calls for the same
+// block and calls without a block number are ignored
+func (s *SlippageModel) Observe(block uint64, pools []*dex.Pool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if block == 0 || block <= s.block {
+		return
+	}
+	s.block = block
+
+	for _, pool := range pools {
+		if pool.Reserve0 == nil || pool.Reserve1 == nil || pool.Reserve0.Sign() == 0 || pool.Reserve1.Sign() == 0 {
+			continue
+		}
+		r0, _ := new(big.Float).SetInt(pool.Reserve0).Float64()
+		r1, _ := new(big.Float).SetInt(pool.Reserve1).Float64()
+		logPrice := math.Log(r1 / r0)
+
+		stats, exists := s.pools[pool.Address]
+		if !exists {
+			s.pools[pool.Address] = &poolVolatility{logPrice: logPrice, block: pool.BlockNumber}
+			continue
+		}
+
+		blocks := 1.0
+		if pool.BlockNumber > stats.block && stats.block != 0 {
+			blocks = float64(pool.BlockNumber - stats.block)
+		}
+		move := math.Abs(logPrice-stats.logPrice) * 10000 / blocks
+		stats.moveBps = (1-volatilityWeight)*stats.moveBps + volatilityWeight*move
+		stats.logPrice = logPrice
+		stats.block = pool.BlockNumber
+	}
+}
+
+// ToleranceBps returns how far a swap through pool may fall short of its
+// quote when the trade lands in the block after the latest observed one
+func (s *SlippageModel) ToleranceBps(pool *dex.Pool) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tolerance := float64(s.policy.BaseBps)
+
+	// Blocks between the reserves and the block the trade lands in
+	age := uint64(1)
+	if pool.BlockNumber != 0 && s.block >= pool.BlockNumber {
+		age = s.block + 1 - pool.BlockNumber
+	}
+	tolerance += float64(age) * float64(s.policy.PerBlockBps)
+
+	if stats, exists := s.pools[pool.Address]; exists {
+		tolerance += s.policy.VolatilityFactor * stats.moveBps * math.Sqrt(float64(age))
+	}
+
+	if s.pending != nil {
+		tolerance += float64(s.pending.PendingSwaps(pool.Address) * s.policy.PendingBps)
+	}
+
+	if tolerance > float64(s.policy.MaxBps) {
+		return s.policy.MaxBps
+	}
+	return int(math.Ceil(tolerance))
+}
+
+// MinAmountsOut returns the minimum output of each hop given the quoted
+// outputs. The last hop never accepts less than breakEven, the output at
+// which the trade stops paying for its gas and loan fee.
+func (s *SlippageModel) MinAmountsOut(pools []*dex.Pool, quoted []*big.Int, breakEven *big.Int) []*big.Int {
+	minAmounts := make([]*big.Int, len(quoted))
+	for i, amount := range quoted {
+		keep := big.NewInt(int64(10000 - s.ToleranceBps(pools[i])))
+		minAmounts[i] = keep.Mul(keep, amount)
+		minAmounts[i].Div(minAmounts[i], config.BigInt10000)
+	}
+
+	last := len(minAmounts) - 1
+	if last >= 0 && minAmounts[last].Cmp(breakEven) < 0 {
+		minAmounts[last] = new(big.Int).Set(breakEven)
+	}
+	return minAmounts
+}
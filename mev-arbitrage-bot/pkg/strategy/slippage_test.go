@@ -0,0 +1,78 @@
+package strategy
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/config"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+// pendingSwaps is a fixed count of pending swaps per pool
+type pendingSwaps map[common.Address]int
+
+func (p pendingSwaps) PendingSwaps(pool common.Address) int { return p[pool] }
+
+func TestSlippageToleranceGrowsWithRisk(t *testing.T) {
+	a, b := testToken(0), testToken(1)
+	quiet := testPool(0, a, b, 100, 100)
+	busy := testPool(1, a, b, 100, 100)
+
+	model := NewSlippageModel(SlippagePolicyFor(config.SlippagePolicyBalanced, 0))
+	for block := uint64(100); block <= 110; block++ {
+		quiet.BlockNumber, busy.BlockNumber = block, block
+		// busy moves 1% back and forth every block
+		if block%2 == 0 {
+			busy.Reserve0 = new(big.Int).Mul(busy.Reserve1, big.NewInt(101))
+			busy.Reserve0.Div(busy.Reserve0, big.NewInt(100))
+		} else {
+			busy.Reserve0 = new(big.Int).Set(busy.Reserve1)
+		}
+		model.Observe(block, []*dex.Pool{quiet, busy})
+	}
+
+	// Fresh, quiet pool: base + one block until inclusion
+	if got := model.ToleranceBps(quiet); got != 20 {
+		t.Fatalf("quiet pool tolerance %d bps, want 20", got)
+	}
+
+	// Older reserves widen the tolerance
+	stale := *quiet
+	stale.BlockNumber = 108
+	if got := model.ToleranceBps(&stale); got != 40 {
+		t.Fatalf("stale pool tolerance %d bps, want 40", got)
+	}
+
+	// Volatility and pending swaps widen it, up to the cap
+	volatile := model.ToleranceBps(busy)
+	if volatile <= 20 {
+		t.Fatalf("volatile pool tolerance %d bps not above quiet pool", volatile)
+	}
+	model.SetPendingCounter(pendingSwaps{busy.Address: 10})
+	if got := model.ToleranceBps(busy); got != model.Policy().MaxBps {
+		t.Fatalf("contested pool tolerance %d bps, want cap %d", got, model.Policy().MaxBps)
+	}
+}
+
+func TestMinAmountsOutKeepsLastHopAboveBreakEven(t *testing.T) {
+	a, b, c := testToken(0), testToken(1), testToken(2)
+	pools := []*dex.Pool{testPool(0, a, b, 100, 100), testPool(1, b, c, 100, 100), testPool(2, c, a, 100, 100)}
+
+	model := NewSlippageModel(SlippagePolicy{BaseBps: 100, MaxBps: 100})
+	quoted := []*big.Int{big.NewInt(10000), big.NewInt(20000), big.NewInt(10500)}
+
+	minAmounts := model.MinAmountsOut(pools, quoted, big.NewInt(10000))
+	for i, want := range []int64{9900, 19800, 10395} {
+		if minAmounts[i].Int64() != want {
+			t.Fatalf("hop %d min out %s, want %d", i, minAmounts[i], want)
+		}
+	}
+
+	// 1% below the quote would lose money after gas; break-even wins
+	minAmounts = model.MinAmountsOut(pools, quoted, big.NewInt(10450))
+	if minAmounts[2].Int64() != 10450 {
+		t.Fatalf("last hop min out %s, want break-even 10450", minAmounts[2])
+	}
+}
@@ -0,0 +1,150 @@
+package strategy
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/mempool"
+)
+
+// Snapshot is the market state strategies evaluate for one block
+type Snapshot struct {
+	BlockNumber uint64
+	Pools       []*dex.Pool // Copies; strategies may not mutate shared state
+	GasPrice    *big.Int
+	Timestamp   time.Time
+	Pending     []*mempool.PendingSwap // Router swaps pending in the mempool, likeliest first
+}
+
+// Strategy searches a snapshot for arbitrage opportunities
+type Strategy interface {
+	// Name returns a short identifier used in logs and config
+	Name() string
+
+	// Evaluate returns the opportunities found in the snapshot. It should
+	// return promptly once ctx is done.
+	Evaluate(ctx context.Context, snapshot *Snapshot) ([]*ArbitrageOpportunity, error)
+}
+
+// registeredStrategy is a strategy with its time budget
+type registeredStrategy struct {
+	strategy Strategy
+	timeout  time.Duration
+}
+
+// Registry runs a set of strategies against each snapshot
+type Registry struct {
+	strategies []registeredStrategy
+	mu         sync.RWMutex
+}
+
+// NewRegistry creates an empty strategy registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a strategy that may run for at most timeout per snapshot
+func (r *Registry) Register(strategy Strategy, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.strategies = append(r.strategies, registeredStrategy{strategy: strategy, timeout: timeout})
+	log.Infof("Registered strategy: %s (timeout %v)", strategy.Name(), timeout)
+}
+
+// Names returns the names of registered strategies
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, len(r.strategies))
+	for i, registered := range r.strategies {
+		names[i] = registered.strategy.Name()
+	}
+	return names
+}
+
+// Run evaluates all strategies in parallel and returns their merged
+// opportunities ranked by priority, then profit. A strategy that errors or
+// exceeds its timeout contributes nothing for this snapshot.
+func (r *Registry) Run(ctx context.Context, snapshot *Snapshot) []*ArbitrageOpportunity {
+	r.mu.RLock()
+	strategies := append([]registeredStrategy(nil), r.strategies...)
+	r.mu.RUnlock()
+
+	results := make([][]*ArbitrageOpportunity, len(strategies))
+
+	var wg sync.WaitGroup
+	for i, registered := range strategies {
+		wg.Add(1)
+		go func(i int, registered registeredStrategy) {
+			defer wg.Done()
+			results[i] = r.runOne(ctx, registered, snapshot)
+		}(i, registered)
+	}
+	wg.Wait()
+
+	merged := make([]*ArbitrageOpportunity, 0)
+	for _, opportunities := range results {
+		merged = append(merged, opportunities...)
+	}
+
+	RankOpportunities(merged)
+	return merged
+}
+
+// runOne evaluates a single strategy within its timeout
+func (r *Registry) runOne(ctx context.Context, registered registeredStrategy, snapshot *Snapshot) []*ArbitrageOpportunity {
+	name := registered.strategy.Name()
+
+	ctx, cancel := context.WithTimeout(ctx, registered.timeout)
+	defer cancel()
+
+	type result struct {
+		opportunities []*ArbitrageOpportunity
+		err           error
+	}
+	done := make(chan result, 1)
+
+	start := time.Now()
+	go func() {
+		opportunities, err := registered.strategy.Evaluate(ctx, snapshot)
+		done <- result{opportunities, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			log.Debugf("Strategy %s failed on block %d: %v", name, snapshot.BlockNumber, res.err)
+			return nil
+		}
+
+		for _, opp := range res.opportunities {
+			opp.Strategy = name
+		}
+		log.Debugf("Strategy %s found %d opportunities in %v",
+			name, len(res.opportunities), time.Since(start).Round(time.Millisecond))
+		return res.opportunities
+
+	case <-ctx.Done():
+		log.Warnf("Strategy %s timed out after %v on block %d", name, registered.timeout, snapshot.BlockNumber)
+		return nil
+	}
+}
+
+// RankOpportunities sorts opportunities by priority, then profit, highest first
+func RankOpportunities(opportunities []*ArbitrageOpportunity) {
+	sort.SliceStable(opportunities, func(i, j int) bool {
+		a, b := opportunities[i], opportunities[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.Path.Profit.Cmp(b.Path.Profit) > 0
+	})
+}
@@ -0,0 +1,65 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// fakeStrategy returns fixed opportunities after a delay
+type fakeStrategy struct {
+	name    string
+	delay   time.Duration
+	profits []int64
+	err     error
+}
+
+func (f *fakeStrategy) Name() string { return f.name }
+
+func (f *fakeStrategy) Evaluate(ctx context.Context, snapshot *Snapshot) ([]*ArbitrageOpportunity, error) {
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	opportunities := make([]*ArbitrageOpportunity, 0, len(f.profits))
+	for _, profit := range f.profits {
+		opportunities = append(opportunities, &ArbitrageOpportunity{
+			Path:     &ArbitragePath{Profit: big.NewInt(profit)},
+			Priority: int(profit),
+		})
+	}
+	return opportunities, f.err
+}
+
+func TestRegistryMergesRanksAndDropsSlowStrategies(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&fakeStrategy{name: "a", profits: []int64{5, 1}}, time.Second)
+	registry.Register(&fakeStrategy{name: "b", profits: []int64{3}}, time.Second)
+	registry.Register(&fakeStrategy{name: "slow", delay: time.Second, profits: []int64{100}}, 20*time.Millisecond)
+	registry.Register(&fakeStrategy{name: "broken", profits: []int64{50}, err: errors.New("boom")}, time.Second)
+
+	start := time.Now()
+	opportunities := registry.Run(context.Background(), &Snapshot{})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Run took %v, slow strategy was not cut off", elapsed)
+	}
+
+	want := []struct {
+		profit   int64
+		strategy string
+	}{{5, "a"}, {3, "b"}, {1, "a"}}
+
+	if len(opportunities) != len(want) {
+		t.Fatalf("got %d opportunities, want %d", len(opportunities), len(want))
+	}
+	for i, w := range want {
+		if opportunities[i].Path.Profit.Int64() != w.profit || opportunities[i].Strategy != w.strategy {
+			t.Errorf("opportunity %d = %s from %q, want %d from %q", i,
+				opportunities[i].Path.Profit, opportunities[i].Strategy, w.profit, w.strategy)
+		}
+	}
+}
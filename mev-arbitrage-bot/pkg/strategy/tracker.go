@@ -0,0 +1,202 @@
+package strategy
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AttemptOutcome is the result of trying to execute an opportunity
+type AttemptOutcome int
+
+const (
+	// AttemptSubmitted: sent without knowing whether it landed (bundle whose
+	// target block was not seen); the route stays pending until the attempt
+	// expires
+	AttemptSubmitted AttemptOutcome = iota
+	// AttemptLanded: included and succeeded
+	AttemptLanded
+	// AttemptReverted: reverted on-chain or in simulation
+	AttemptReverted
+	// AttemptFailed: not sent for reasons unrelated to the route (gas
+	// price cap, RPC errors)
+	AttemptFailed
+	// AttemptNotSent: deliberately not sent (dry run, circuit breaker open);
+	// the route is released without affecting its backoff
+	AttemptNotSent
+)
+
+// TrackerConfig sets how long routes are suppressed
+type TrackerConfig struct {
+	PendingBlocks    uint64 // Blocks an unresolved attempt stays pending
+	BackoffAfter     int    // Consecutive reverts before backoff starts
+	BackoffBlocks    uint64 // First backoff; doubles with each further revert
+	MaxBackoffBlocks uint64 // Backoff cap; revert history is forgotten this long after backoff ends
+}
+
+// routeState is the execution history of one route
+type routeState struct {
+	lastAttempt  uint64 // Block of the latest attempt
+	pendingUntil uint64 // Last block an attempt is pending through (0 = none)
+	reverts      int    // Consecutive reverts
+	backoffUntil uint64 // Last block the route is suppressed through
+}
+
+// OpportunityTracker follows execution attempts per route (canonical
+// cycle: pools and direction). A route is skipped while an attempt is
+// pending and for an exponentially growing number of blocks after
+// repeated reverts.
+type OpportunityTracker struct {
+	cfg    TrackerConfig
+	routes map[string]*routeState
+	block  uint64 // Latest block seen
+	mu     sync.Mutex
+}
+
+// NewOpportunityTracker creates an opportunity tracker
+func NewOpportunityTracker(cfg TrackerConfig) *OpportunityTracker {
+	if cfg.PendingBlocks == 0 {
+		cfg.PendingBlocks = 1
+	}
+	if cfg.BackoffAfter < 1 {
+		cfg.BackoffAfter = 1
+	}
+	if cfg.MaxBackoffBlocks < cfg.BackoffBlocks {
+		cfg.MaxBackoffBlocks = cfg.BackoffBlocks
+	}
+	return &OpportunityTracker{
+		cfg:    cfg,
+		routes: make(map[string]*routeState),
+	}
+}
+
+// collapseDuplicates keeps the validated candidate with the highest net
+// profit in ETH of each route, in order of first appearance. Strategies may
+// find the same cycle (e.g. a 2-hop cycle is also a cross-DEX pair), from
+// another start token or at several amounts.
+func collapseDuplicates(candidates []*ArbitrageOpportunity) []*ArbitrageOpportunity {
+	best := make(map[string]int)
+	unique := make([]*ArbitrageOpportunity, 0, len(candidates))
+	for _, candidate := range candidates {
+		key := candidate.Path.Key()
+		i, exists := best[key]
+		if !exists {
+			best[key] = len(unique)
+			unique = append(unique, candidate)
+			continue
+		}
+		if candidate.Path.NetProfitETH.Cmp(unique[i].Path.NetProfitETH) > 0 {
+			unique[i] = candidate
+		}
+	}
+	return unique
+}
+
+// Allowed reports whether a route may be executed at block
+func (t *OpportunityTracker) Allowed(path *ArbitragePath, block uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, exists := t.routes[path.Key()]
+	if !exists {
+		return true
+	}
+	return block > state.pendingUntil && block > state.backoffUntil
+}
+
+// Filter drops opportunities whose route is pending or backing off
+func (t *OpportunityTracker) Filter(opportunities []*ArbitrageOpportunity, block uint64) []*ArbitrageOpportunity {
+	t.advance(block)
+
+	allowed := make([]*ArbitrageOpportunity, 0, len(opportunities))
+	for _, opp := range opportunities {
+		if !t.Allowed(opp.Path, block) {
+			log.Debugf("Suppressed %s opportunity %s: route pending or backing off", opp.Strategy, opp.Path.ID[:8])
+			continue
+		}
+		allowed = append(allowed, opp)
+	}
+	return allowed
+}
+
+// Begin marks a route pending from block; it returns false if an attempt
+// for the route is already pending or the route is backing off
+func (t *OpportunityTracker) Begin(path *ArbitragePath, block uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := path.Key()
+	state, exists := t.routes[key]
+	if !exists {
+		state = &routeState{}
+		t.routes[key] = state
+	}
+	if block <= state.pendingUntil || block <= state.backoffUntil {
+		return false
+	}
+
+	if block > t.block {
+		t.block = block
+	}
+	state.lastAttempt = block
+	state.pendingUntil = block + t.cfg.PendingBlocks - 1
+	return true
+}
+
+// Complete records the outcome of a route's attempt; backoff counts from
+// the latest block seen, as mempool attempts may take several blocks
+func (t *OpportunityTracker) Complete(path *ArbitragePath, outcome AttemptOutcome) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := path.Key()
+	state, exists := t.routes[key]
+	if !exists {
+		return
+	}
+
+	switch outcome {
+	case AttemptSubmitted:
+		// Stays pending until pendingUntil
+	case AttemptLanded:
+		delete(t.routes, key)
+	case AttemptReverted:
+		state.pendingUntil = 0
+		state.reverts++
+		if state.reverts >= t.cfg.BackoffAfter && t.cfg.BackoffBlocks > 0 {
+			backoff := t.cfg.BackoffBlocks << uint(state.reverts-t.cfg.BackoffAfter)
+			if backoff > t.cfg.MaxBackoffBlocks || backoff < t.cfg.BackoffBlocks {
+				backoff = t.cfg.MaxBackoffBlocks
+			}
+			state.backoffUntil = t.block + backoff
+			log.Warnf("Route %s reverted %d times in a row, backing off %d blocks",
+				path.ID[:8], state.reverts, backoff)
+		}
+	case AttemptFailed, AttemptNotSent:
+		state.pendingUntil = 0
+	}
+}
+
+// advance moves to block and forgets routes that have been idle for
+// MaxBackoffBlocks
+func (t *OpportunityTracker) advance(block uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if block > t.block {
+		t.block = block
+	}
+
+	for key, state := range t.routes {
+		idleSince := state.lastAttempt
+		if state.pendingUntil > idleSince {
+			idleSince = state.pendingUntil
+		}
+		if state.backoffUntil > idleSince {
+			idleSince = state.backoffUntil
+		}
+		if block > idleSince+t.cfg.MaxBackoffBlocks {
+			delete(t.routes, key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package strategy
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+)
+
+func TestTrackerCollapsesAndSuppressesRoutes(t *testing.T) {
+	a, b, c := testToken(0), testToken(1), testToken(2)
+	pools := []*dex.Pool{testPool(0, a, b, 100, 100), testPool(1, b, c, 100, 100), testPool(2, c, a, 100, 100)}
+
+	tokens := []common.Address{a, b, c}
+
+	// opportunity trades the triangle starting from its start-th pool; raw
+	// profit is in units of the start token
+	opportunity := func(id string, start int, profit int64, profitETH float64) *ArbitrageOpportunity {
+		rotated := append(append([]*dex.Pool{}, pools[start:]...), pools[:start]...)
+		path := testPath(tokens[start], rotated, true)
+		path.ID = id + "-0000000"
+		path.Profit = big.NewInt(profit)
+		path.NetProfitETH = big.NewFloat(profitETH)
+		return &ArbitrageOpportunity{Path: path}
+	}
+
+	// The same triangle at two amounts and from another start token whose
+	// raw units are worth less
+	unique := collapseDuplicates([]*ArbitrageOpportunity{
+		opportunity("small", 0, 5, 0.005), opportunity("large", 0, 9, 0.009), opportunity("rotated", 1, 7e12, 0.007),
+	})
+	if len(unique) != 1 || unique[0].Path.ID[:5] != "large" {
+		t.Fatalf("expected the duplicate earning the most ETH only, got %d", len(unique))
+	}
+	route := unique[0].Path
+
+	tracker := NewOpportunityTracker(TrackerConfig{PendingBlocks: 2, BackoffAfter: 2, BackoffBlocks: 4, MaxBackoffBlocks: 10})
+
+	// An attempt that was not sent (dry run) releases the route at once
+	tracker.Begin(route, 99)
+	tracker.Complete(route, AttemptNotSent)
+	if !tracker.Allowed(route, 99) {
+		t.Fatal("route suppressed after a dry run")
+	}
+
+	// A submitted attempt keeps the route pending for two blocks
+	if !tracker.Begin(route, 100) {
+		t.Fatal("first attempt refused")
+	}
+	tracker.Complete(route, AttemptSubmitted)
+	if len(tracker.Filter(unique, 101)) != 0 || tracker.Begin(route, 101) {
+		t.Fatal("route not suppressed while pending")
+	}
+	if len(tracker.Filter(unique, 102)) != 1 {
+		t.Fatal("route still suppressed after the attempt expired")
+	}
+
+	// One revert is tolerated, the second backs off 4 blocks, the third 8
+	tracker.Begin(route, 102)
+	tracker.Complete(route, AttemptReverted)
+	if !tracker.Begin(route, 103) {
+		t.Fatal("backed off after a single revert")
+	}
+	tracker.Complete(route, AttemptReverted)
+	if tracker.Allowed(route, 107) || !tracker.Allowed(route, 108) {
+		t.Fatal("expected a 4-block backoff after the second revert")
+	}
+	tracker.Filter(nil, 108)
+	tracker.Begin(route, 108)
+	tracker.Complete(route, AttemptReverted)
+	if tracker.Allowed(route, 116) || !tracker.Allowed(route, 117) {
+		t.Fatal("expected an 8-block backoff after the third revert")
+	}
+
+	// Landing resets the history
+	tracker.Filter(nil, 117)
+	tracker.Begin(route, 117)
+	tracker.Complete(route, AttemptLanded)
+	tracker.Begin(route, 118)
+	tracker.Complete(route, AttemptReverted)
+	if !tracker.Allowed(route, 119) {
+		t.Fatal("revert count not reset after landing")
+	}
+}
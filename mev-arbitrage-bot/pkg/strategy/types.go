@@ -5,30 +5,45 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ljlin/mev-arbitrage-bot/pkg/dex"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/flashloan"
+	"github.com/ljlin/mev-arbitrage-bot/pkg/mempool"
 )
 
 // ArbitragePath represents a profitable arbitrage opportunity
 type ArbitragePath struct {
-	ID           string           // Unique identifier
-	Pools        []*dex.Pool      // Sequence of pools to trade through
-	Tokens       []common.Address // Token addresses in order
-	StartToken   common.Address   // Starting token
-	StartAmount  *big.Int         // Initial amount
-	EndAmount    *big.Int         // Final amount after arbitrage
-	Profit       *big.Int         // Profit amount (EndAmount - StartAmount)
-	ProfitBps    int              // Profit in basis points
-	ProfitETH    *big.Float       // Profit in ETH (for display)
-	GasCostEst   *big.Int         // Estimated gas cost
-	NetProfit    *big.Int         // Profit after gas
-	NetProfitBps int              // Net profit in basis points
-	PriceImpact  *big.Float       // Total price impact
-	Timestamp    int64            // Discovery timestamp
+	ID            string           // Unique identifier
+	Pools         []*dex.Pool      // Sequence of pools to trade through
+	Routers       []common.Address // Router of each pool's DEX, one per hop
+	Tokens        []common.Address // Token addresses in order
+	StartToken    common.Address   // Starting token
+	StartAmount   *big.Int         // Initial amount
+	EndAmount     *big.Int         // Final amount after arbitrage
+	Profit        *big.Int         // Profit amount (EndAmount - StartAmount)
+	ProfitBps     int              // Profit in basis points
+	ProfitETH     *big.Float       // Profit valued in ETH
+	FlashLoan     bool             // Start amount is borrowed with a flash loan
+	LoanSource    *flashloan.Quote // Cheapest flash-loan source (nil if none can lend)
+	GasEst        uint64           // Estimated gas units
+	GasCostEst    *big.Int         // Estimated gas cost in wei
+	NetProfit     *big.Int         // Profit after gas, in start token units
+	NetProfitETH  *big.Float       // Profit after gas valued in ETH
+	NetProfitBps  int              // Net profit in basis points
+	PriceImpact   *big.Float       // Total price impact
+	MinAmountsOut []*big.Int       // Minimum output of each hop, enforced on-chain
+	Timestamp     int64            // Discovery timestamp
 }
 
 // ArbitrageOpportunity represents a validated arbitrage opportunity
 type ArbitrageOpportunity struct {
 	Path         *ArbitragePath
 	IsExecutable bool
-	Reason       string // Reason if not executable
-	Priority     int    // Execution priority (higher = more urgent)
+	Reason       string               // Reason if not executable
+	Priority     int                  // Execution priority (higher = more urgent)
+	Strategy     string               // Name of the strategy that found it
+	Backrun      *mempool.PendingSwap // Pending swap the path relies on; executes right after it (nil = none)
+}
+
+// Key identifies the route of a path by its pools and direction
+func (p *ArbitragePath) Key() string {
+	return (&Cycle{Pools: p.Pools, Tokens: p.Tokens}).Key()
 }
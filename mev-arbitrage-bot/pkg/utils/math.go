@@ -134,6 +134,15 @@ func BpsToPercentage(bps int) float64 {
 	return float64(bps) / 100.0
 }
 
+// ApplyMultiplier scales an amount by a float factor (e.g. gas price x 1.2)
+func ApplyMultiplier(amount *big.Int, multiplier float64) *big.Int {
+	scaled := new(big.Float).SetInt(amount)
+	scaled.Mul(scaled, big.NewFloat(multiplier))
+
+	result, _ := scaled.Int(nil)
+	return result
+}
+
 // WeiToEther converts Wei to Ether
 func WeiToEther(wei *big.Int) *big.Float {
 	fbalance := new(big.Float).SetInt(wei)
@@ -148,6 +157,20 @@ func EtherToWei(ether *big.Float) *big.Int {
 	return result
 }
 
+// ToDecimal converts a raw token amount to a decimal value (amount / 10^decimals)
+func ToDecimal(amount *big.Int, decimals uint8) *big.Float {
+	scale := new(big.Int).Exp(config.BigInt10, big.NewInt(int64(decimals)), nil)
+	return new(big.Float).Quo(new(big.Float).SetInt(amount), new(big.Float).SetInt(scale))
+}
+
+// FromDecimal converts a decimal value to a raw token amount (value * 10^decimals)
+func FromDecimal(value *big.Float, decimals uint8) *big.Int {
+	scale := new(big.Int).Exp(config.BigInt10, big.NewInt(int64(decimals)), nil)
+	raw := new(big.Float).Mul(value, new(big.Float).SetInt(scale))
+	result, _ := raw.Int(nil)
+	return result
+}
+
 // GweiToWei converts Gwei to Wei
 func GweiToWei(gwei uint64) *big.Int {
 	return new(big.Int).Mul(big.NewInt(int64(gwei)), big.NewInt(config.GweiPerEther))